@@ -0,0 +1,94 @@
+package chord
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Acker is implemented by events whose source expects to be told the
+// outcome of processing them, such as queue messages that are redelivered
+// unless acknowledged.
+type Acker interface {
+	Ack()
+	Nack()
+}
+
+type ackerKey struct{}
+
+// settlement settles an event once, whichever of RunFlow and the flow
+// gets to it first.
+type settlement struct {
+	a    Acker
+	once sync.Once
+}
+
+// WithAcker returns a result context for an event emitted by a trigger.
+// RunFlow acks the event once its output passes OnSuccess and nacks it
+// once OnError has handled its failure, so the source only forgets events
+// that were processed.
+func WithAcker(ctx context.Context, a Acker) context.Context {
+	return context.WithValue(ctx, ackerKey{}, &settlement{a: a})
+}
+
+// acker returns the Acker of the event the result context was emitted
+// with, if any.
+func acker(ctx context.Context) Acker {
+	if s, ok := ctx.Value(ackerKey{}).(*settlement); ok {
+		return s.a
+	}
+	return nil
+}
+
+// Ack acknowledges the event the result context was emitted with. RunFlow
+// does so after OnSuccess; stages that drop events on purpose call it. It
+// is a no-op for events that carry no Acker or are already settled.
+func Ack(ctx context.Context) {
+	if s, ok := ctx.Value(ackerKey{}).(*settlement); ok {
+		s.once.Do(s.a.Ack)
+	}
+}
+
+// Nack rejects the event the result context was emitted with so the source
+// can redeliver it. RunFlow does so after OnError.
+func Nack(ctx context.Context) {
+	if s, ok := ctx.Value(ackerKey{}).(*settlement); ok {
+		s.once.Do(s.a.Nack)
+	}
+}
+
+// joinAcker acks an event once every branch it was forked into has, and
+// nacks it as soon as one of them does.
+type joinAcker struct {
+	ctx     context.Context
+	pending atomic.Int32
+}
+
+func (j *joinAcker) Ack() {
+	if j.pending.Add(-1) == 0 {
+		Ack(j.ctx)
+	}
+}
+
+func (j *joinAcker) Nack() { Nack(j.ctx) }
+
+// ForkAcks gives each of n branches processing the event of ctx, such as
+// the windows or batches an event ends up in, its own result context. The
+// event is acked once every branch is acked and nacked as soon as one
+// branch is nacked.
+func ForkAcks(ctx context.Context, n int) []context.Context {
+	ctxs := make([]context.Context, n)
+	if _, ok := ctx.Value(ackerKey{}).(*settlement); !ok || n == 1 {
+		for i := range ctxs {
+			ctxs[i] = ctx
+		}
+		return ctxs
+	}
+
+	j := &joinAcker{ctx: ctx}
+	j.pending.Store(int32(n))
+	for i := range ctxs {
+		ctxs[i] = WithAcker(ctx, j)
+	}
+	return ctxs
+}
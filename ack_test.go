@@ -0,0 +1,205 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// testAcker counts how its event was settled.
+type testAcker struct {
+	mu          sync.Mutex
+	acks, nacks int
+}
+
+func (a *testAcker) Ack() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acks++
+}
+
+func (a *testAcker) Nack() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nacks++
+}
+
+func (a *testAcker) settled() (acks, nacks int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.acks, a.nacks
+}
+
+// ackedSource emits vs, each with its own acker.
+func ackedSource[T any](vs ...T) (Stage[T], []*testAcker) {
+	ackers := make([]*testAcker, len(vs))
+	for i := range ackers {
+		ackers[i] = new(testAcker)
+	}
+	return NewProducer(context.Background(), func(ctx context.Context, emit Emit[T]) {
+		for i, v := range vs {
+			emit(WithAcker(ctx, ackers[i]), v, nil)
+		}
+	}), ackers
+}
+
+type funcSink[T any] struct {
+	onSuccess func(context.Context, T) error
+	settles   bool
+
+	mu   sync.Mutex
+	errs []error
+}
+
+func (s *funcSink[T]) OnSuccess(ctx context.Context, v T) error { return s.onSuccess(ctx, v) }
+
+func (s *funcSink[T]) OnError(_ context.Context, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errs = append(s.errs, err)
+}
+
+func (s *funcSink[T]) SettlesOutputs() bool { return s.settles }
+
+func identity[T any](s Stage[T]) Stage[T] { return s }
+
+func checkSettled(t *testing.T, ackers []*testAcker, want [][2]int) {
+	t.Helper()
+	for i, a := range ackers {
+		acks, nacks := a.settled()
+		if got := [2]int{acks, nacks}; got != want[i] {
+			t.Errorf("event %d: (acks, nacks) = %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestRunFlowSettlesEvents(t *testing.T) {
+	errOdd := errors.New("odd")
+	src, ackers := ackedSource(0, 1, 2, 3)
+	sink := &funcSink[int]{onSuccess: func(_ context.Context, v int) error {
+		if v%2 == 1 {
+			return errOdd
+		}
+		return nil
+	}}
+
+	if err := RunFlow(src, NewFlow(identity[int], Sink[int](sink))); err != nil {
+		t.Fatal(err)
+	}
+	checkSettled(t, ackers, [][2]int{{1, 0}, {0, 1}, {1, 0}, {0, 1}})
+	if len(sink.errs) != 2 {
+		t.Errorf("OnError got %v, want two failures", sink.errs)
+	}
+}
+
+func TestRunFlowNacksPipelineErrors(t *testing.T) {
+	errTwo := errors.New("two")
+	src, ackers := ackedSource(1, 2, 3)
+	sink := &funcSink[int]{onSuccess: func(context.Context, int) error { return nil }}
+	pipeline := func(s Stage[int]) Stage[int] {
+		return NewStage(s, func(_ context.Context, v int) (int, error) {
+			if v == 2 {
+				return 0, errTwo
+			}
+			return v, nil
+		})
+	}
+
+	if err := RunFlow(src, NewFlow(pipeline, Sink[int](sink))); err != nil {
+		t.Fatal(err)
+	}
+	checkSettled(t, ackers, [][2]int{{1, 0}, {0, 1}, {1, 0}})
+	if !slices.Equal(sink.errs, []error{errTwo}) {
+		t.Errorf("OnError got %v, want %v", sink.errs, errTwo)
+	}
+}
+
+func TestRunFlowLeavesSelfSettlingSinksToAck(t *testing.T) {
+	errFail := errors.New("fail")
+	src, ackers := ackedSource(1, 2, 3)
+
+	var held []context.Context
+	sink := &funcSink[int]{settles: true, onSuccess: func(ctx context.Context, v int) error {
+		if v == 3 {
+			return errFail
+		}
+		held = append(held, ctx)
+		return nil
+	}}
+
+	if err := RunFlow(src, NewFlow(identity[int], Sink[int](sink))); err != nil {
+		t.Fatal(err)
+	}
+	checkSettled(t, ackers, [][2]int{{0, 0}, {0, 0}, {0, 1}})
+
+	for _, ctx := range held {
+		Ack(ctx)
+	}
+	checkSettled(t, ackers, [][2]int{{1, 0}, {1, 0}, {0, 1}})
+}
+
+func TestSettlesOnce(t *testing.T) {
+	a := new(testAcker)
+	ctx := WithAcker(context.Background(), a)
+
+	Ack(ctx)
+	Nack(ctx)
+	Ack(ctx)
+	checkSettled(t, []*testAcker{a}, [][2]int{{1, 0}})
+
+	// Contexts without an acker are ignored.
+	Ack(context.Background())
+	Nack(context.Background())
+}
+
+func TestForkAcks(t *testing.T) {
+	t.Run("AckedOnceAllBranchesAre", func(t *testing.T) {
+		a := new(testAcker)
+		ctxs := ForkAcks(WithAcker(context.Background(), a), 3)
+
+		Ack(ctxs[0])
+		Ack(ctxs[2])
+		checkSettled(t, []*testAcker{a}, [][2]int{{0, 0}})
+		Ack(ctxs[1])
+		checkSettled(t, []*testAcker{a}, [][2]int{{1, 0}})
+	})
+
+	t.Run("NackedWithTheFirstBranch", func(t *testing.T) {
+		a := new(testAcker)
+		ctxs := ForkAcks(WithAcker(context.Background(), a), 3)
+
+		Ack(ctxs[0])
+		Nack(ctxs[1])
+		checkSettled(t, []*testAcker{a}, [][2]int{{0, 1}})
+		Ack(ctxs[2])
+		checkSettled(t, []*testAcker{a}, [][2]int{{0, 1}})
+	})
+
+	t.Run("SingleBranchSharesTheContext", func(t *testing.T) {
+		ctx := WithAcker(context.Background(), new(testAcker))
+		if got := ForkAcks(ctx, 1); got[0] != ctx {
+			t.Error("ForkAcks(ctx, 1) returned a new context")
+		}
+	})
+}
+
+func TestDeliver(t *testing.T) {
+	for _, settles := range []bool{false, true} {
+		a := new(testAcker)
+		ctx := WithAcker(context.Background(), a)
+		sink := &funcSink[int]{settles: settles, onSuccess: func(context.Context, int) error { return nil }}
+
+		if err := Deliver(ctx, Sink[int](sink), 1); err != nil {
+			t.Fatal(err)
+		}
+		want := 1
+		if settles {
+			want = 0
+		}
+		if acks, _ := a.settled(); acks != want {
+			t.Errorf("Deliver to a sink settling outputs %v: %d acks, want %d", settles, acks, want)
+		}
+	}
+}
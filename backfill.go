@@ -0,0 +1,171 @@
+package chord
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type BackfillConfig struct {
+	// Rate caps the events read from the source per second, to spare the
+	// systems the flow writes to. Zero reads as fast as the flow takes
+	// them.
+	Rate float64
+	// Total is the number of events the source holds, if known, for the
+	// percentage and ETA of the progress reports.
+	Total int
+	// Interval is how often Progress is called. It defaults to 10s.
+	Interval time.Duration
+	// Progress receives a report every Interval and a final one, with Done
+	// set, when the backfill ends.
+	Progress func(BackfillProgress)
+}
+
+type BackfillProgress struct {
+	// Read is the number of events taken from the source.
+	Read int
+	// Completed and Failed count the events settled by the flow: acked
+	// after OnSuccess, or nacked after OnError.
+	Completed int
+	Failed    int
+	Total     int
+	Elapsed   time.Duration
+	// Rate is the number of events settled per second so far.
+	Rate float64
+	// ETA is the estimated time left, or zero if Total is unknown.
+	ETA  time.Duration
+	Done bool
+}
+
+// Percent is the share of Total that is settled, or zero if Total is
+// unknown.
+func (p BackfillProgress) Percent() float64 {
+	if p.Total == 0 {
+		return 0
+	}
+	return float64(p.Completed+p.Failed) / float64(p.Total) * 100
+}
+
+type backfillCounts struct {
+	read, completed, failed atomic.Int64
+}
+
+func (c *backfillCounts) report(total int, elapsed time.Duration, done bool) BackfillProgress {
+	p := BackfillProgress{
+		Read:      int(c.read.Load()),
+		Completed: int(c.completed.Load()),
+		Failed:    int(c.failed.Load()),
+		Total:     total,
+		Elapsed:   elapsed,
+		Done:      done,
+	}
+	settled := p.Completed + p.Failed
+	if elapsed > 0 {
+		p.Rate = float64(settled) / elapsed.Seconds()
+	}
+	if total > settled && p.Rate > 0 && !done {
+		p.ETA = time.Duration(float64(total-settled) / p.Rate * float64(time.Second))
+	}
+	return p
+}
+
+// backfillAck counts an event's outcome before settling it with its source.
+type backfillAck struct {
+	ctx    context.Context
+	counts *backfillCounts
+}
+
+func (a backfillAck) Ack() {
+	a.counts.completed.Add(1)
+	Ack(a.ctx)
+}
+
+func (a backfillAck) Nack() {
+	a.counts.failed.Add(1)
+	Nack(a.ctx)
+}
+
+func (a backfillAck) AckIn(ctx context.Context, tx Transaction) error {
+	return ackIn(a.ctx, tx)
+}
+
+type backfillTrigger[T any] struct {
+	t      Trigger[T]
+	rate   float64
+	counts *backfillCounts
+}
+
+func (b backfillTrigger[T]) Stage(ctx context.Context) Stage[T] {
+	return newProducer(ctx, func(ctx context.Context, emit Emit[T]) {
+		clock := ClockFrom(ctx)
+		start := clock.Now()
+
+		NewConsumer(b.t.Stage(ctx),
+			func(c context.Context, v T) error {
+				n := b.counts.read.Add(1) - 1
+				if b.rate > 0 {
+					at := start.Add(time.Duration(float64(n) / b.rate * float64(time.Second)))
+					if d := at.Sub(clock.Now()); d > 0 {
+						t := clock.NewTimer(d)
+						select {
+						case <-t.C():
+						case <-ctx.Done():
+							t.Stop()
+							return nil
+						}
+					}
+				}
+				emit(WithAcker(c, backfillAck{c, b.counts}), v, nil)
+				return nil
+			},
+			func(c context.Context, err error) {
+				var zero T
+				b.counts.read.Add(1)
+				emit(WithAcker(c, backfillAck{c, b.counts}), zero, err)
+			},
+		)
+	})
+}
+
+// Backfill runs f over a historical source, such as trigger.NewScanner over
+// a file, trigger.NewQuery or trigger.NewReplay, with the flow's own
+// pipeline and sink, so reprocessing uses exactly the code of the live
+// flow. It reads at most cfg.Rate events per second, reports progress to
+// cfg.Progress, and returns as RunFlowContext does once the source is
+// exhausted.
+func Backfill[In, Out any](ctx context.Context, t Trigger[In], f Flow[In, Out], cfg BackfillConfig) error {
+	if cfg.Interval == 0 {
+		cfg.Interval = 10 * time.Second
+	}
+
+	counts := new(backfillCounts)
+	clock := ClockFrom(ctx)
+	start := clock.Now()
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	if cfg.Progress != nil {
+		wg.Go(func() {
+			tick := clock.NewTicker(cfg.Interval)
+			defer tick.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-tick.C():
+					cfg.Progress(counts.report(cfg.Total, clock.Now().Sub(start), false))
+				}
+			}
+		})
+	}
+
+	err := RunFlowContext(ctx, backfillTrigger[In]{t, cfg.Rate, counts}, f)
+
+	close(done)
+	wg.Wait()
+	if cfg.Progress != nil {
+		cfg.Progress(counts.report(cfg.Total, clock.Now().Sub(start), true))
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package chord
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+// FatalError stops the run it occurs in. Return Fatal(err) from a sink's
+// OnSuccess to stop the trigger instead of moving on to the next output.
+type FatalError struct {
+	Err error
+}
+
+func (e *FatalError) Error() string { return "chord: fatal: " + e.Err.Error() }
+func (e *FatalError) Unwrap() error { return e.Err }
+
+func Fatal(err error) error {
+	return &FatalError{err}
+}
+
+// TriggerError is the cause of a run whose trigger gave up, with the last
+// error it reported.
+type TriggerError struct {
+	Err error
+}
+
+func (e *TriggerError) Error() string { return "chord: trigger failed: " + e.Err.Error() }
+func (e *TriggerError) Unwrap() error { return e.Err }
+
+// SignalError is the cause of a context canceled by SignalContext.
+type SignalError struct {
+	Signal os.Signal
+}
+
+func (e *SignalError) Error() string { return fmt.Sprintf("chord: received %v", e.Signal) }
+
+// SignalContext is like signal.NotifyContext, but the returned context's
+// cause is a SignalError naming the signal received.
+func SignalContext(ctx context.Context, sigs ...os.Signal) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			cancel(&SignalError{sig})
+		case <-ctx.Done():
+		}
+		signal.Stop(ch)
+	}()
+
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+type stopKey struct{}
+
+// Stop ends the run ctx belongs to with the given cause, as returned by
+// RunFlowContext and context.Cause of every item context. Outside of a
+// run it does nothing.
+func Stop(ctx context.Context, cause error) {
+	if cancel, ok := ctx.Value(stopKey{}).(context.CancelCauseFunc); ok {
+		cancel(cause)
+	}
+}
+
+// RunFlowContext runs f on the trigger's stage until it completes, ctx is
+// done, or the run is stopped by a FatalError or a failing trigger. It
+// returns nil after a clean completion and the cause of the stop
+// otherwise.
+func RunFlowContext[In, Out any](ctx context.Context, t Trigger[In], f Flow[In, Out]) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	ctx = context.WithValue(ctx, stopKey{}, cancel)
+	if err := RunFlow(t.Stage(ctx), f); err != nil {
+		return err
+	}
+	return context.Cause(ctx)
+}
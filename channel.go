@@ -0,0 +1,55 @@
+package chord
+
+import (
+	"context"
+)
+
+// ToChannel runs the stage, sending outputs on the first channel and
+// errors on the second. Both are closed once the stage completes, and both
+// must be drained for it to make progress.
+func ToChannel[T any](s Stage[T]) (<-chan T, <-chan error) {
+	values := make(chan T)
+	errs := make(chan error)
+
+	go func() {
+		defer close(values)
+		defer close(errs)
+
+		NewConsumer(s,
+			func(_ context.Context, v T) error {
+				values <- v
+				return nil
+			},
+			func(_ context.Context, err error) {
+				errs <- err
+			},
+		)
+	}()
+
+	return values, errs
+}
+
+// FromChannels turns values and errs into a stage that completes once both
+// are closed. Either may be nil.
+func FromChannels[T any](values <-chan T, errs <-chan error) Stage[T] {
+	return NewProducer(context.Background(), func(ctx context.Context, emit Emit[T]) {
+		values, errs := values, errs
+		for values != nil || errs != nil {
+			select {
+			case v, ok := <-values:
+				if !ok {
+					values = nil
+					continue
+				}
+				emit(ctx, v, nil)
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				var zero T
+				emit(ctx, zero, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package chord
+
+import "context"
+
+// Checkpointer stores how far a trigger has got, such as an offset, a
+// resume token or a file position, so a restarted flow resumes where it
+// stopped. Load returns nil if nothing has been saved under key. See the
+// checkpoint package for implementations.
+type Checkpointer interface {
+	Load(ctx context.Context, key string) ([]byte, error)
+	Save(ctx context.Context, key string, checkpoint []byte) error
+}
+
+// TxCheckpointer is implemented by Checkpointers that can save a
+// checkpoint within a sink's transaction, such as one kept in the database
+// the flow writes to, so triggers tracking checkpoints are TxAckers with a
+// TwoPhaseSink. SaveIn returns an error matching errors.ErrUnsupported if
+// tx is not one it can write through; the checkpoint is then saved after
+// the commit as usual.
+type TxCheckpointer interface {
+	Checkpointer
+	SaveIn(ctx context.Context, tx Transaction, key string, checkpoint []byte) error
+}
@@ -0,0 +1,57 @@
+// Package checkpoint stores trigger checkpoints; see chord.Checkpointer.
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+type File struct {
+	dir string
+}
+
+// NewFile keeps each checkpoint in its own file in dir, replacing it
+// atomically on every save.
+func NewFile(dir string) File {
+	return File{dir}
+}
+
+func (f File) path(key string) string {
+	return filepath.Join(f.dir, url.PathEscape(key))
+}
+
+func (f File) Load(_ context.Context, key string) ([]byte, error) {
+	b, err := os.ReadFile(f.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return b, err
+}
+
+func (f File) Save(_ context.Context, key string, checkpoint []byte) error {
+	if err := os.MkdirAll(f.dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(f.dir, ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(checkpoint); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path(key))
+}
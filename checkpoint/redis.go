@@ -0,0 +1,30 @@
+package checkpoint
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Redis struct {
+	redis.UniversalClient
+	prefix string
+}
+
+// NewRedis keeps each checkpoint in a string at prefix followed by its key.
+func NewRedis(rdb redis.UniversalClient, prefix string) Redis {
+	return Redis{rdb, prefix}
+}
+
+func (r Redis) Load(ctx context.Context, key string) ([]byte, error) {
+	b, err := r.Get(ctx, r.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	return b, err
+}
+
+func (r Redis) Save(ctx context.Context, key string, checkpoint []byte) error {
+	return r.Set(ctx, r.prefix+key, checkpoint, 0).Err()
+}
@@ -0,0 +1,57 @@
+package checkpoint
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Sql struct {
+	*sql.DB
+	table string
+}
+
+// NewSql keeps checkpoints in table, which is used as written and needs
+// these columns:
+//
+//	key        text primary key
+//	checkpoint bytea (or blob)
+//
+// It upserts with ON CONFLICT and $n parameters, as PostgreSQL, SQLite and
+// CockroachDB accept.
+func NewSql(db *sql.DB, table string) Sql {
+	return Sql{db, table}
+}
+
+func (s Sql) Load(ctx context.Context, key string) ([]byte, error) {
+	var b []byte
+	err := s.QueryRowContext(ctx, "SELECT checkpoint FROM "+s.table+" WHERE key = $1", key).Scan(&b)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return b, err
+}
+
+func (s Sql) Save(ctx context.Context, key string, checkpoint []byte) error {
+	_, err := s.ExecContext(ctx, s.upsert(), key, checkpoint)
+	return err
+}
+
+// SaveIn saves checkpoint within tx if it is a SQL transaction, such as
+// one of sink.SqlTx, which must be on the same database as table.
+func (s Sql) SaveIn(ctx context.Context, tx chord.Transaction, key string, checkpoint []byte) error {
+	st, ok := tx.(interface{ SqlTx() *sql.Tx })
+	if !ok {
+		return fmt.Errorf("checkpoint: %T: %w", tx, errors.ErrUnsupported)
+	}
+	_, err := st.SqlTx().ExecContext(ctx, s.upsert(), key, checkpoint)
+	return err
+}
+
+func (s Sql) upsert() string {
+	return "INSERT INTO " + s.table + " (key, checkpoint) VALUES ($1, $2)" +
+		" ON CONFLICT (key) DO UPDATE SET checkpoint = excluded.checkpoint"
+}
@@ -2,6 +2,7 @@ package chord
 
 import (
 	"context"
+	"errors"
 
 	"github.com/0x180db/go-conduit"
 )
@@ -12,9 +13,59 @@ type Flow[In, Out any] interface {
 	Pipeline(Stage[In]) Stage[Out]
 }
 
+type Sink[T any] interface {
+	OnSuccess(context.Context, T) error
+	OnError(context.Context, error)
+}
+
+// SelfSettling is implemented by sinks that settle the events of their
+// outputs themselves, such as batching sinks that ack an event only once
+// the batch holding its output is written. RunFlow then leaves acking to
+// the sink and only nacks the events of outputs that fail.
+type SelfSettling interface {
+	SettlesOutputs() bool
+}
+
+func settles(s any) bool {
+	ss, ok := s.(SelfSettling)
+	return ok && ss.SettlesOutputs()
+}
+
+// Deliver passes v to s and acks the event of ctx once s has handled it,
+// unless s settles its outputs itself. Sinks wrapping other sinks use it
+// and are SelfSettling.
+func Deliver[T any](ctx context.Context, s Sink[T], v T) error {
+	err := s.OnSuccess(ctx, v)
+	if err == nil && !settles(s) {
+		Ack(ctx)
+	}
+	return err
+}
+
+type flow[In, Out any] struct {
+	Sink[Out]
+	pipeline func(Stage[In]) Stage[Out]
+}
+
+func (f flow[In, Out]) Pipeline(s Stage[In]) Stage[Out] {
+	return f.pipeline(s)
+}
+
+// NewFlow builds a Flow from a pipeline function and a sink handling its
+// outputs.
+func NewFlow[In, Out any](pipeline func(Stage[In]) Stage[Out], s Sink[Out]) Flow[In, Out] {
+	return flow[In, Out]{s, pipeline}
+}
+
 type Stage[T any] conduit.Stage[T]
 
 func NewStage[In, Out any](p Stage[In], fn func(context.Context, In) (Out, error)) Stage[Out] {
+	out := newStage(p, fn)
+	record(Node{Kind: KindStage, Name: funcName(fn), Workers: 1}, []Stage[In]{p}, out)
+	return out
+}
+
+func newStage[In, Out any](p Stage[In], fn func(context.Context, In) (Out, error)) Stage[Out] {
 	return Stage[Out](
 		conduit.NewProducerConsumer(conduit.Stage[In](p), fn),
 	)
@@ -24,10 +75,53 @@ type Trigger[T any] interface {
 	Stage(context.Context) Stage[T]
 }
 
-func RunFlow[In, Out any](s Stage[In], f Flow[In, Out]) {
-	conduit.NewConsumer(
-		conduit.Stage[Out](f.Pipeline(s)),
-		f.OnSuccess,
-		f.OnError,
+// RunFlow consumes the stage until it completes, acking the events of
+// outputs that pass OnSuccess, or are committed by a TwoPhaseSink, and
+// nacking those of failures (see WithAcker). A SelfSettling sink acks
+// events itself. If an output fails with a
+// FatalError, the run is stopped with it as cause (see RunFlowContext) and
+// the error is returned once the stage has drained. Otherwise it returns
+// the cause of the item contexts being done, if they are.
+func RunFlow[In, Out any](s Stage[In], f Flow[In, Out]) error {
+	var (
+		fatal error
+		last  context.Context
 	)
+	tp, isTwoPhase := twoPhase(f)
+	selfSettling := !isTwoPhase && settles(sinkOf(f))
+
+	NewConsumer(f.Pipeline(s),
+		func(ctx context.Context, v Out) error {
+			last = ctx
+
+			var err error
+			if isTwoPhase {
+				err = commitOutput(ctx, tp, v)
+			} else {
+				err = f.OnSuccess(ctx, v)
+			}
+			var fe *FatalError
+			if fatal == nil && errors.As(err, &fe) {
+				fatal = err
+				Stop(ctx, err)
+			}
+			if err == nil && !selfSettling {
+				Ack(ctx)
+			}
+			return err
+		},
+		func(ctx context.Context, err error) {
+			last = ctx
+			f.OnError(ctx, err)
+			Nack(ctx)
+		},
+	)
+
+	if fatal != nil {
+		return fatal
+	}
+	if last != nil && last.Err() != nil {
+		return context.Cause(last)
+	}
+	return nil
 }
@@ -0,0 +1,133 @@
+package chordtest
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// ErrChaos replaces the outputs Chaos fails.
+var ErrChaos = errors.New("chordtest: injected failure")
+
+type ChaosConfig struct {
+	// Latency is the most an output is delayed by, at random.
+	Latency time.Duration
+	// ErrorRate, DuplicateRate and ReorderRate are the fractions of
+	// outputs replaced by ErrChaos, sent twice, and held back until after
+	// the next output.
+	ErrorRate     float64
+	DuplicateRate float64
+	ReorderRate   float64
+	// Seed makes the faults reproducible. Zero picks a random seed.
+	Seed uint64
+}
+
+type chaos struct {
+	cfg ChaosConfig
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newChaos(cfg ChaosConfig) *chaos {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &chaos{cfg: cfg, rnd: rand.New(rand.NewPCG(seed, seed))}
+}
+
+func (c *chaos) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.rnd.Float64() < rate
+}
+
+func (c *chaos) delay(ctx context.Context) {
+	if c.cfg.Latency <= 0 {
+		return
+	}
+	c.mu.Lock()
+	d := time.Duration(c.rnd.Int64N(int64(c.cfg.Latency)))
+	c.mu.Unlock()
+
+	t := chord.ClockFrom(ctx).NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C():
+	case <-ctx.Done():
+	}
+}
+
+type chaosItem[T any] struct {
+	ctx context.Context
+	v   T
+	err error
+}
+
+// Chaos injects faults into what s emits, so retry, deduplication and
+// ordering handling can be tested under failure.
+func Chaos[T any](s chord.Stage[T], cfg ChaosConfig) chord.Stage[T] {
+	c := newChaos(cfg)
+
+	return chord.NewProducer(context.Background(), func(_ context.Context, emit chord.Emit[T]) {
+		var held *chaosItem[T]
+
+		send := func(ctx context.Context, v T, err error) {
+			c.delay(ctx)
+
+			if err == nil && c.roll(cfg.ErrorRate) {
+				var zero T
+				v, err = zero, ErrChaos
+			}
+			if held == nil && c.roll(cfg.ReorderRate) {
+				held = &chaosItem[T]{ctx, v, err}
+				return
+			}
+
+			emit(ctx, v, err)
+			if err == nil && c.roll(cfg.DuplicateRate) {
+				emit(ctx, v, nil)
+			}
+			if held != nil {
+				emit(held.ctx, held.v, held.err)
+				held = nil
+			}
+		}
+
+		chord.NewConsumer(s,
+			func(ctx context.Context, v T) error {
+				send(ctx, v, nil)
+				return nil
+			},
+			func(ctx context.Context, err error) {
+				var zero T
+				send(ctx, zero, err)
+			},
+		)
+
+		if held != nil {
+			emit(held.ctx, held.v, held.err)
+		}
+	})
+}
+
+type chaosTrigger[T any] struct {
+	chord.Trigger[T]
+	cfg ChaosConfig
+}
+
+// ChaosTrigger injects faults into the events of t, as Chaos does.
+func ChaosTrigger[T any](t chord.Trigger[T], cfg ChaosConfig) chord.Trigger[T] {
+	return chaosTrigger[T]{t, cfg}
+}
+
+func (c chaosTrigger[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return Chaos(c.Trigger.Stage(ctx), c.cfg)
+}
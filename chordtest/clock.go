@@ -0,0 +1,158 @@
+package chordtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Clock is a fake chord.Clock whose time only moves on Advance. Run flows
+// with Context so their tickers and retry backoff follow it.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	timers  map[*fakeTimer]bool
+	changed chan struct{}
+}
+
+func NewClock(start time.Time) *Clock {
+	return &Clock{
+		now:     start,
+		timers:  make(map[*fakeTimer]bool),
+		changed: make(chan struct{}),
+	}
+}
+
+// Context returns ctx with c as its clock.
+func (c *Clock) Context(ctx context.Context) context.Context {
+	return chord.WithClock(ctx, c)
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// set arms or disarms t and wakes up WaitForTimers. c.mu must be held.
+func (c *Clock) set(t *fakeTimer, at time.Time, active bool) bool {
+	was := c.timers[t]
+	t.at = at
+	if active {
+		c.timers[t] = true
+	} else {
+		delete(c.timers, t)
+	}
+
+	close(c.changed)
+	c.changed = make(chan struct{})
+	return was
+}
+
+func (c *Clock) NewTimer(d time.Duration) chord.Timer {
+	t := &fakeTimer{c: c, ch: make(chan time.Time, 1)}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(t, c.now.Add(d), true)
+	return t
+}
+
+func (c *Clock) NewTicker(d time.Duration) chord.Ticker {
+	if d <= 0 {
+		panic("chordtest: non-positive ticker interval")
+	}
+	t := &fakeTimer{c: c, ch: make(chan time.Time, 1), period: d}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(t, c.now.Add(d), true)
+	return fakeTicker{t}
+}
+
+// Advance moves time forward by d, firing the timers and ticks that fall
+// due in order. Like real tickers, a tick is dropped if the previous one
+// has not been received.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	end := c.now.Add(d)
+	for {
+		var next *fakeTimer
+		for t := range c.timers {
+			if !t.at.After(end) && (next == nil || t.at.Before(next.at)) {
+				next = t
+			}
+		}
+		if next == nil {
+			break
+		}
+
+		c.now = next.at
+		select {
+		case next.ch <- c.now:
+		default:
+		}
+		if next.period > 0 {
+			c.set(next, next.at.Add(next.period), true)
+		} else {
+			c.set(next, next.at, false)
+		}
+	}
+	c.now = end
+}
+
+// WaitForTimers blocks until n timers and tickers are waiting to fire, so
+// Advance is not called before the code under test has armed them. It
+// fails the test if that takes longer than timeout.
+func (c *Clock) WaitForTimers(t testing.TB, n int, timeout time.Duration) {
+	t.Helper()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		c.mu.Lock()
+		got, changed := len(c.timers), c.changed
+		c.mu.Unlock()
+		if got >= n {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			t.Fatalf("chordtest: %d of %d timers waiting after %v", got, n, timeout)
+		}
+	}
+}
+
+// fakeTimer is both a timer and, with a period, a ticker.
+type fakeTimer struct {
+	c      *Clock
+	ch     chan time.Time
+	at     time.Time
+	period time.Duration
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	return t.c.set(t, t.at, false)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	return t.c.set(t, t.c.now.Add(d), true)
+}
+
+type fakeTicker struct{ *fakeTimer }
+
+func (t fakeTicker) Stop() { t.fakeTimer.Stop() }
@@ -0,0 +1,69 @@
+package chordtest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// fuzzTimeout fails inputs a pipeline hangs on.
+const fuzzTimeout = 10 * time.Second
+
+// AddCorpus seeds f with the content of every file matching glob, e.g.
+// the fixtures of parsing tests.
+func AddCorpus(f *testing.F, glob string) {
+	f.Helper()
+
+	files, err := filepath.Glob(glob)
+	if err != nil {
+		f.Fatalf("chordtest: %v", err)
+	}
+	for _, name := range files {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			f.Fatalf("chordtest: %v", err)
+		}
+		f.Add(b)
+	}
+}
+
+// Fuzz runs each fuzz input through pipeline as a single event. An input
+// fails if it makes the pipeline panic or hang, or if check rejects one of
+// the outputs. Errors are expected from bad input and are not checked.
+func Fuzz[Out any](f *testing.F, pipeline func(chord.Stage[[]byte]) chord.Stage[Out], check func(t *testing.T, in []byte, out Out)) {
+	f.Fuzz(func(t *testing.T, in []byte) {
+		src := chord.FromSeq(func(yield func([]byte, error) bool) {
+			yield(append([]byte(nil), in...), nil)
+		})
+
+		outs := make(chan Out)
+		go func() {
+			defer close(outs)
+			for v, err := range chord.ToSeq(pipeline(src)) {
+				if err == nil {
+					outs <- v
+				}
+			}
+		}()
+
+		deadline := time.NewTimer(fuzzTimeout)
+		defer deadline.Stop()
+
+		for {
+			select {
+			case v, ok := <-outs:
+				if !ok {
+					return
+				}
+				if check != nil {
+					check(t, in, v)
+				}
+			case <-deadline.C:
+				t.Fatalf("chordtest: pipeline did not complete within %v for input %q", fuzzTimeout, in)
+			}
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package chordtest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/0x180db/go-chord"
+)
+
+// The flag is namespaced so it does not clash with a test binary's own
+// -update flag.
+var update = flag.Bool("chordtest.update", false, "rewrite chordtest golden files with current outputs")
+
+func updating() bool {
+	return *update || os.Getenv("CHORDTEST_UPDATE") == "1"
+}
+
+// Golden runs pipeline over the fixture at input, one JSON value per line,
+// and compares its outputs with the golden file: one JSON value per line,
+// errors as {"error": "..."}. Run the test with -chordtest.update, or with
+// CHORDTEST_UPDATE=1 in the environment, to write the golden file from the
+// current outputs. The pipeline must keep outputs in order for the
+// comparison to be stable.
+func Golden[In, Out any](t testing.TB, input, golden string, pipeline func(chord.Stage[In]) chord.Stage[Out]) {
+	t.Helper()
+
+	f, err := os.Open(input)
+	if err != nil {
+		t.Fatalf("chordtest: %v", err)
+	}
+	defer f.Close()
+
+	in := chord.FromSeq(func(yield func(In, error) bool) {
+		sc := bufio.NewScanner(f)
+		sc.Buffer(nil, 16<<20)
+		for line := 1; sc.Scan(); line++ {
+			if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+				continue
+			}
+			var v In
+			if err := json.Unmarshal(sc.Bytes(), &v); err != nil {
+				t.Errorf("chordtest: %s:%d: %v", input, line, err)
+				return
+			}
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if err := sc.Err(); err != nil {
+			t.Errorf("chordtest: %s: %v", input, err)
+		}
+	})
+
+	var got bytes.Buffer
+	for v, err := range chord.ToSeq(pipeline(in)) {
+		var b []byte
+		if err != nil {
+			b, _ = json.Marshal(map[string]string{"error": err.Error()})
+		} else if b, err = json.Marshal(v); err != nil {
+			t.Fatalf("chordtest: encoding output: %v", err)
+		}
+		got.Write(b)
+		got.WriteByte('\n')
+	}
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+			t.Fatalf("chordtest: %v", err)
+		}
+		if err := os.WriteFile(golden, got.Bytes(), 0o644); err != nil {
+			t.Fatalf("chordtest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("chordtest: %v (run with -chordtest.update to create it)", err)
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Errorf("chordtest: outputs differ from %s (run with -chordtest.update to accept them)\n%s", golden, lineDiff(got.String(), string(want)))
+	}
+}
+
+// lineDiff lists the lines of got and want that differ.
+func lineDiff(got, want string) string {
+	g := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+	w := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+
+	var b strings.Builder
+	for i := range max(len(g), len(w)) {
+		var gl, wl string
+		if i < len(g) {
+			gl = g[i]
+		}
+		if i < len(w) {
+			wl = w[i]
+		}
+		if gl != wl {
+			b.WriteString("line " + strconv.Itoa(i+1) + ":\n  got:  " + gl + "\n  want: " + wl + "\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package chordtest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// NewHttpServer serves the handlers HTTP triggers mount on s from a test
+// server, which is closed when the test ends. Triggers may be created
+// before or after it.
+func NewHttpServer(t testing.TB, s *http.Server) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+type HttpResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Request sends a request to the test server and reads the response. The
+// HTTP trigger only answers once the flow calls Done, so when Request
+// returns the pipeline has handled the request.
+func Request(t testing.TB, ts *httptest.Server, method, path string, body []byte, header ...string) HttpResponse {
+	t.Helper()
+
+	if len(header)%2 != 0 {
+		t.Fatalf("chordtest: odd number of header strings")
+	}
+
+	req, err := http.NewRequestWithContext(t.Context(), method, ts.URL+path, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("chordtest: %v", err)
+	}
+	for i := 0; i < len(header); i += 2 {
+		req.Header.Add(header[i], header[i+1])
+	}
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("chordtest: %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("chordtest: %s %s: reading body: %v", method, path, err)
+	}
+	return HttpResponse{resp.StatusCode, resp.Header, b}
+}
+
+// Post sends a JSON body and reads the response.
+func Post(t testing.TB, ts *httptest.Server, path, json string) HttpResponse {
+	t.Helper()
+	return Request(t, ts, http.MethodPost, path, []byte(json), "Content-Type", "application/json")
+}
+
+func (r HttpResponse) String() string {
+	return strings.TrimSpace(string(r.Body))
+}
@@ -0,0 +1,216 @@
+package chordtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger"
+)
+
+type emittedKey struct{}
+
+// schedule paces a generator: event i is due i/rate seconds after the
+// first.
+type schedule struct {
+	rate  float64
+	count int
+	sent  *atomic.Int64
+
+	i     int
+	start time.Time
+}
+
+// next waits until the next event is due and returns its index and when
+// it was due, or trigger.ErrDone once count events are sent or ctx is
+// done.
+func (s *schedule) next(ctx context.Context) (int, time.Time, error) {
+	if s.count > 0 && s.i >= s.count {
+		return 0, time.Time{}, trigger.ErrDone
+	}
+
+	clock := chord.ClockFrom(ctx)
+	now := clock.Now()
+	if s.i == 0 {
+		s.start = now
+	}
+
+	at := now
+	if s.rate > 0 {
+		at = s.start.Add(time.Duration(float64(s.i) / s.rate * float64(time.Second)))
+		if d := at.Sub(now); d > 0 {
+			t := clock.NewTimer(d)
+			select {
+			case <-t.C():
+			case <-ctx.Done():
+				t.Stop()
+				return 0, time.Time{}, trigger.ErrDone
+			}
+		}
+	}
+
+	i := s.i
+	s.i++
+	s.sent.Add(1)
+	return i, at, nil
+}
+
+type generator[T any] struct {
+	rate  float64
+	count int
+	fn    func(i int) T
+	sent  *atomic.Int64
+}
+
+// NewGenerator is a synthetic trigger emitting fn(i) for i from 0, rate
+// times per second, or as fast as the flow takes them if rate is 0. It
+// completes after count events, or never if count is 0.
+func NewGenerator[T any](rate float64, count int, fn func(i int) T) chord.Trigger[T] {
+	return generator[T]{rate, count, fn, new(atomic.Int64)}
+}
+
+func (g generator[T]) Stage(ctx context.Context) chord.Stage[T] {
+	s := &schedule{rate: g.rate, count: g.count, sent: g.sent}
+	return trigger.FromFunc(func(ctx context.Context) (T, error) {
+		i, _, err := s.next(ctx)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		return g.fn(i), nil
+	}).Stage(ctx)
+}
+
+// scheduled is a generator whose events carry when they were due, so
+// latency includes the time an event waited for the flow to take it.
+type scheduled[T any] struct {
+	generator[T]
+}
+
+type due[T any] struct {
+	v  T
+	at time.Time
+}
+
+func (g scheduled[T]) Stage(ctx context.Context) chord.Stage[T] {
+	s := &schedule{rate: g.rate, count: g.count, sent: g.sent}
+	gen := trigger.FromFunc(func(ctx context.Context) (due[T], error) {
+		i, at, err := s.next(ctx)
+		if err != nil {
+			return due[T]{}, err
+		}
+		return due[T]{g.fn(i), at}, nil
+	})
+
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[T]) {
+		chord.NewConsumer(gen.Stage(ctx),
+			func(c context.Context, d due[T]) error {
+				emit(context.WithValue(c, emittedKey{}, d.at), d.v, nil)
+				return nil
+			},
+			func(c context.Context, err error) {
+				var zero T
+				emit(c, zero, err)
+			},
+		)
+	})
+}
+
+type LoadConfig[T any] struct {
+	// Rate is the target of events per second. Zero drives the flow as
+	// fast as it takes events, to find its maximum throughput.
+	Rate     float64
+	Duration time.Duration
+	New      func(i int) T
+}
+
+type LoadReport struct {
+	Sent      int
+	Completed int
+	Failed    int
+	Elapsed   time.Duration
+	// Throughput is the rate at which outputs completed. When it falls
+	// short of the target rate, the flow cannot sustain it.
+	Throughput         float64
+	P50, P90, P99, Max time.Duration
+}
+
+func (r LoadReport) String() string {
+	return fmt.Sprintf("sent %d, completed %d, failed %d in %v (%.1f/s); latency p50 %v p90 %v p99 %v max %v",
+		r.Sent, r.Completed, r.Failed, r.Elapsed.Round(time.Millisecond), r.Throughput, r.P50, r.P90, r.P99, r.Max)
+}
+
+// loadFlow times outputs from when they were due to the sink returning.
+type loadFlow[In, Out any] struct {
+	chord.Flow[In, Out]
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	failed    int
+	last      time.Time
+}
+
+func (l *loadFlow[In, Out]) observe(ctx context.Context, failed bool) {
+	now := chord.ClockFrom(ctx).Now()
+	emitted, ok := ctx.Value(emittedKey{}).(time.Time)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if ok {
+		l.latencies = append(l.latencies, now.Sub(emitted))
+	}
+	if failed {
+		l.failed++
+	}
+	l.last = now
+}
+
+func (l *loadFlow[In, Out]) OnSuccess(ctx context.Context, v Out) error {
+	err := l.Flow.OnSuccess(ctx, v)
+	l.observe(ctx, err != nil)
+	return err
+}
+
+func (l *loadFlow[In, Out]) OnError(ctx context.Context, err error) {
+	l.Flow.OnError(ctx, err)
+	l.observe(ctx, true)
+}
+
+// Load drives f with a generator at cfg.Rate for cfg.Duration and reports
+// end-to-end latency percentiles and throughput, to size buffers and
+// worker pools empirically. Latency is measured from when an event was
+// due, so a flow falling behind the rate shows in it.
+func Load[In, Out any](ctx context.Context, cfg LoadConfig[In], f chord.Flow[In, Out]) (LoadReport, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+
+	gen := scheduled[In]{generator[In]{cfg.Rate, 0, cfg.New, new(atomic.Int64)}}
+	lf := &loadFlow[In, Out]{Flow: f}
+
+	start := chord.ClockFrom(ctx).Now()
+	err := chord.RunFlowContext(ctx, gen, lf)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = nil
+	}
+
+	r := LoadReport{Sent: int(gen.sent.Load()), Failed: lf.failed}
+	r.Completed = len(lf.latencies) - lf.failed
+	if !lf.last.IsZero() {
+		r.Elapsed = lf.last.Sub(start)
+		r.Throughput = float64(r.Completed) / r.Elapsed.Seconds()
+	}
+
+	lat := lf.latencies
+	slices.Sort(lat)
+	if n := len(lat); n > 0 {
+		pct := func(p float64) time.Duration { return lat[int(p*float64(n-1))] }
+		r.P50, r.P90, r.P99, r.Max = pct(.5), pct(.9), pct(.99), lat[n-1]
+	}
+	return r, err
+}
@@ -0,0 +1,121 @@
+package chordtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Captured is an output or error that passed a probe.
+type Captured[T any] struct {
+	Value T
+	Err   error
+	Time  time.Time
+}
+
+// Probe captures what passes a point of a live pipeline into a bounded
+// buffer, so tests can assert on it mid-pipeline. Unlike Recorder it keeps
+// only the latest items, so it can stay in long-running pipelines.
+type Probe[T any] struct {
+	mu      sync.Mutex
+	items   []Captured[T]
+	next    int
+	seq     int
+	changed chan struct{}
+}
+
+// NewProbe keeps the last size items.
+func NewProbe[T any](size int) *Probe[T] {
+	return &Probe[T]{items: make([]Captured[T], 0, max(size, 1)), changed: make(chan struct{})}
+}
+
+func (p *Probe[T]) capture(ctx context.Context, v T, err error) {
+	c := Captured[T]{v, err, chord.ClockFrom(ctx).Now()}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.items) < cap(p.items) {
+		p.items = append(p.items, c)
+	} else {
+		p.items[p.next] = c
+		p.next = (p.next + 1) % len(p.items)
+	}
+	p.seq++
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// Stage passes s through unchanged, capturing its outputs and errors.
+func (p *Probe[T]) Stage(s chord.Stage[T]) chord.Stage[T] {
+	return chord.NewProducer(context.Background(), func(_ context.Context, emit chord.Emit[T]) {
+		chord.NewConsumer(s,
+			func(ctx context.Context, v T) error {
+				p.capture(ctx, v, nil)
+				emit(ctx, v, nil)
+				return nil
+			},
+			func(ctx context.Context, err error) {
+				var zero T
+				p.capture(ctx, zero, err)
+				emit(ctx, zero, err)
+			},
+		)
+	})
+}
+
+// Items returns the captured items, oldest first.
+func (p *Probe[T]) Items() []Captured[T] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.snapshot()
+}
+
+func (p *Probe[T]) snapshot() []Captured[T] {
+	return append(append([]Captured[T](nil), p.items[p.next:]...), p.items[:p.next]...)
+}
+
+// Reset drops the captured items.
+func (p *Probe[T]) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.items, p.next = p.items[:0], 0
+}
+
+// WaitFor returns the first item captured from now on that match accepts,
+// failing the test if none passes within timeout. Items evicted from the
+// buffer before WaitFor sees them are missed.
+func (p *Probe[T]) WaitFor(t testing.TB, match func(Captured[T]) bool, timeout time.Duration) Captured[T] {
+	t.Helper()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	p.mu.Lock()
+	seen := p.seq
+	p.mu.Unlock()
+
+	for {
+		p.mu.Lock()
+		items, seq, changed := p.snapshot(), p.seq, p.changed
+		p.mu.Unlock()
+
+		// The last seq-seen items are new.
+		for _, c := range items[max(len(items)-(seq-seen), 0):] {
+			if match(c) {
+				return c
+			}
+		}
+		seen = seq
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			t.Fatalf("chordtest: no matching item passed the probe within %v", timeout)
+			return Captured[T]{}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package chordtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Recorder is a sink capturing every output and error it receives. Its
+// Tap records what passes through a stage instead.
+type Recorder[T any] struct {
+	// Fail, if set, is returned by OnSuccess for the outputs it fails.
+	Fail func(T) error
+
+	mu      sync.Mutex
+	values  []T
+	errs    []error
+	changed chan struct{}
+}
+
+func NewRecorder[T any]() *Recorder[T] {
+	return &Recorder[T]{changed: make(chan struct{})}
+}
+
+// record runs fn under the lock and wakes up waiters.
+func (r *Recorder[T]) record(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fn()
+	close(r.changed)
+	r.changed = make(chan struct{})
+}
+
+func (r *Recorder[T]) OnSuccess(_ context.Context, v T) error {
+	r.record(func() { r.values = append(r.values, v) })
+	if r.Fail != nil {
+		return r.Fail(v)
+	}
+	return nil
+}
+
+func (r *Recorder[T]) OnError(_ context.Context, err error) {
+	r.record(func() { r.errs = append(r.errs, err) })
+}
+
+// Tap passes s through unchanged, recording its outputs and errors.
+func (r *Recorder[T]) Tap(s chord.Stage[T]) chord.Stage[T] {
+	return chord.NewProducer(context.Background(), func(_ context.Context, emit chord.Emit[T]) {
+		chord.NewConsumer(s,
+			func(ctx context.Context, v T) error {
+				r.record(func() { r.values = append(r.values, v) })
+				emit(ctx, v, nil)
+				return nil
+			},
+			func(ctx context.Context, err error) {
+				r.OnError(ctx, err)
+				var zero T
+				emit(ctx, zero, err)
+			},
+		)
+	})
+}
+
+// Values returns the outputs recorded so far, in order.
+func (r *Recorder[T]) Values() []T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]T(nil), r.values...)
+}
+
+// Errors returns the errors recorded so far, in order.
+func (r *Recorder[T]) Errors() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]error(nil), r.errs...)
+}
+
+// Len is the number of outputs and errors recorded so far.
+func (r *Recorder[T]) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.values) + len(r.errs)
+}
+
+// WaitForN blocks until n outputs and errors are recorded, failing the
+// test if that takes longer than timeout.
+func (r *Recorder[T]) WaitForN(t testing.TB, n int, timeout time.Duration) {
+	t.Helper()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		r.mu.Lock()
+		got, changed := len(r.values)+len(r.errs), r.changed
+		r.mu.Unlock()
+		if got >= n {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-deadline.C:
+			t.Fatalf("chordtest: recorded %d of %d results after %v", got, n, timeout)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package chordtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Start runs f on the trigger in the background until the test ends, then
+// stops it and waits for it to return. Wait returns the result of the run
+// once it completes.
+func Start[In, Out any](t testing.TB, tr chord.Trigger[In], f chord.Flow[In, Out]) (wait func() error) {
+	return StartContext(context.Background(), t, tr, f)
+}
+
+// StartContext is Start with a context, e.g. one from Clock.Context.
+func StartContext[In, Out any](ctx context.Context, t testing.TB, tr chord.Trigger[In], f chord.Flow[In, Out]) (wait func() error) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- chord.RunFlowContext(ctx, tr, f)
+	}()
+
+	wait = sync.OnceValue(func() error { return <-done })
+	t.Cleanup(func() {
+		cancel()
+		wait()
+	})
+	return wait
+}
+
+// Equal fails the test unless got and want hold the same elements in the
+// same order.
+func Equal[T comparable](t testing.TB, got, want []T) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("chordtest: got %d results %v, want %d %v", len(got), got, len(want), want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("chordtest: result %d is %v, want %v", i, got[i], want[i])
+		}
+	}
+}
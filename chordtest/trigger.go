@@ -0,0 +1,100 @@
+package chordtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+)
+
+type pushed[T any] struct {
+	v   T
+	err error
+}
+
+// Trigger is an in-memory trigger: events pushed into it are emitted in
+// order, and its stage completes once it is closed and drained.
+type Trigger[T any] struct {
+	mu     sync.Mutex
+	queue  []pushed[T]
+	closed bool
+	notify chan struct{}
+}
+
+func NewTrigger[T any]() *Trigger[T] {
+	return &Trigger[T]{notify: make(chan struct{}, 1)}
+}
+
+func (tr *Trigger[T]) push(p pushed[T]) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if tr.closed {
+		panic("chordtest: push on closed trigger")
+	}
+	tr.queue = append(tr.queue, p)
+	tr.wake()
+}
+
+func (tr *Trigger[T]) wake() {
+	select {
+	case tr.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Push queues events without waiting for the flow to take them.
+func (tr *Trigger[T]) Push(vs ...T) {
+	for _, v := range vs {
+		tr.push(pushed[T]{v: v})
+	}
+}
+
+// PushError queues an error for the flow's error path.
+func (tr *Trigger[T]) PushError(err error) {
+	tr.push(pushed[T]{err: err})
+}
+
+// Close completes the stage once the queued events are emitted.
+func (tr *Trigger[T]) Close() {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	tr.closed = true
+	tr.wake()
+}
+
+func (tr *Trigger[T]) next() (pushed[T], bool, bool) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if len(tr.queue) == 0 {
+		return pushed[T]{}, false, tr.closed
+	}
+	p := tr.queue[0]
+	tr.queue = tr.queue[1:]
+	return p, true, false
+}
+
+func (tr *Trigger[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[T]) {
+		for {
+			p, ok, closed := tr.next()
+			switch {
+			case ok:
+				if !emit(ctx, p.v, p.err) {
+					return
+				}
+				continue
+			case closed:
+				return
+			}
+
+			select {
+			case <-tr.notify:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
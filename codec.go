@@ -0,0 +1,24 @@
+package chord
+
+import "context"
+
+// Codec converts between events and their wire encoding.
+type Codec[T any] interface {
+	Encode(T) ([]byte, error)
+	Decode([]byte) (T, error)
+}
+
+// Decode turns the payloads of a stage into typed events, routing payloads
+// that do not decode to the error path.
+func Decode[T any](s Stage[[]byte], c Codec[T]) Stage[T] {
+	return NewStage(s, func(_ context.Context, b []byte) (T, error) {
+		return c.Decode(b)
+	})
+}
+
+// Encode turns the outputs of a stage into payloads.
+func Encode[T any](s Stage[T], c Codec[T]) Stage[[]byte] {
+	return NewStage(s, func(_ context.Context, v T) ([]byte, error) {
+		return c.Encode(v)
+	})
+}
@@ -0,0 +1,34 @@
+package codec
+
+import (
+	"github.com/0x180db/go-chord"
+	"github.com/hamba/avro/v2"
+)
+
+type Avro[T any] struct {
+	schema avro.Schema
+	err    error
+}
+
+// NewAvro encodes values with an Avro schema, matching record fields to
+// struct fields tagged `avro:"name"`.
+func NewAvro[T any](schema string) chord.Codec[T] {
+	s, err := avro.Parse(schema)
+	return Avro[T]{s, err}
+}
+
+func (a Avro[T]) Encode(v T) ([]byte, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	return avro.Marshal(a.schema, v)
+}
+
+func (a Avro[T]) Decode(b []byte) (T, error) {
+	var v T
+	if a.err != nil {
+		return v, a.err
+	}
+	err := avro.Unmarshal(a.schema, b, &v)
+	return v, err
+}
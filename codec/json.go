@@ -0,0 +1,23 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Json[T any] struct{}
+
+func NewJson[T any]() chord.Codec[T] {
+	return Json[T]{}
+}
+
+func (Json[T]) Encode(v T) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (Json[T]) Decode(b []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
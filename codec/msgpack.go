@@ -0,0 +1,22 @@
+package codec
+
+import (
+	"github.com/0x180db/go-chord"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type Msgpack[T any] struct{}
+
+func NewMsgpack[T any]() chord.Codec[T] {
+	return Msgpack[T]{}
+}
+
+func (Msgpack[T]) Encode(v T) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+func (Msgpack[T]) Decode(b []byte) (T, error) {
+	var v T
+	err := msgpack.Unmarshal(b, &v)
+	return v, err
+}
@@ -0,0 +1,27 @@
+package codec
+
+import (
+	"github.com/0x180db/go-chord"
+	"google.golang.org/protobuf/proto"
+)
+
+type Protobuf[T proto.Message] struct {
+	new func() T
+}
+
+// NewProtobuf encodes messages in the protobuf binary format. newMsg
+// allocates the message a payload is decoded into, such as
+// func() *pb.Event { return new(pb.Event) }.
+func NewProtobuf[T proto.Message](newMsg func() T) chord.Codec[T] {
+	return Protobuf[T]{newMsg}
+}
+
+func (p Protobuf[T]) Encode(v T) ([]byte, error) {
+	return proto.Marshal(v)
+}
+
+func (p Protobuf[T]) Decode(b []byte) (T, error) {
+	v := p.new()
+	err := proto.Unmarshal(b, v)
+	return v, err
+}
@@ -0,0 +1,277 @@
+package codec
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/hamba/avro/v2"
+	"google.golang.org/protobuf/proto"
+)
+
+var errWireFormat = errors.New("codec: payload is not in schema registry wire format")
+
+type RegistryConfig struct {
+	URL      string
+	Username string
+	Password string
+	Client   *http.Client
+	// Timeout bounds each registry request. It defaults to 10s.
+	Timeout time.Duration
+	// LatestTTL is how long the latest schema of a subject is cached, so
+	// new versions are picked up. It defaults to 5m.
+	LatestTTL time.Duration
+}
+
+// Registry is a Confluent-compatible schema registry client. Schemas are
+// cached, so the registry is only asked once per ID, and once per LatestTTL
+// for the latest schema of a subject.
+type Registry struct {
+	cfg      RegistryConfig
+	mu       sync.Mutex
+	ids      map[int]string
+	subjects map[string]registrySchema
+}
+
+type registrySchema struct {
+	ID     int    `json:"id"`
+	Schema string `json:"schema"`
+
+	fetched time.Time
+}
+
+func NewRegistry(cfg RegistryConfig) *Registry {
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.LatestTTL == 0 {
+		cfg.LatestTTL = 5 * time.Minute
+	}
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	return &Registry{
+		cfg:      cfg,
+		ids:      make(map[int]string),
+		subjects: make(map[string]registrySchema),
+	}
+}
+
+func (r *Registry) get(path string, v any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.cfg.URL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if r.cfg.Username != "" {
+		req.SetBasicAuth(r.cfg.Username, r.cfg.Password)
+	}
+
+	resp, err := r.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("codec: schema registry: %s: %s", resp.Status, msg)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Schema returns the schema registered under id.
+func (r *Registry) Schema(id int) (string, error) {
+	r.mu.Lock()
+	s, ok := r.ids[id]
+	r.mu.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	var res registrySchema
+	if err := r.get(fmt.Sprintf("/schemas/ids/%d", id), &res); err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.ids[id] = res.Schema
+	r.mu.Unlock()
+	return res.Schema, nil
+}
+
+// Latest returns the ID and schema of the latest version of subject.
+func (r *Registry) Latest(subject string) (int, string, error) {
+	r.mu.Lock()
+	s, ok := r.subjects[subject]
+	r.mu.Unlock()
+	if ok && time.Since(s.fetched) < r.cfg.LatestTTL {
+		return s.ID, s.Schema, nil
+	}
+
+	// A registry that cannot be reached keeps the cached schema in use.
+	var latest registrySchema
+	if err := r.get("/subjects/"+url.PathEscape(subject)+"/versions/latest", &latest); err != nil {
+		if ok {
+			return s.ID, s.Schema, nil
+		}
+		return 0, "", err
+	}
+	s = latest
+	s.fetched = time.Now()
+
+	r.mu.Lock()
+	r.subjects[subject] = s
+	r.ids[s.ID] = s.Schema
+	r.mu.Unlock()
+	return s.ID, s.Schema, nil
+}
+
+// frame prefixes a payload with the magic byte and schema ID.
+func frame(id int, payload []byte) []byte {
+	b := make([]byte, 5, 5+len(payload))
+	binary.BigEndian.PutUint32(b[1:], uint32(id))
+	return append(b, payload...)
+}
+
+// unframe splits a payload into its schema ID and body.
+func unframe(b []byte) (int, []byte, error) {
+	if len(b) < 5 || b[0] != 0 {
+		return 0, nil, errWireFormat
+	}
+	return int(binary.BigEndian.Uint32(b[1:5])), b[5:], nil
+}
+
+type RegistryAvro[T any] struct {
+	reg     *Registry
+	subject string
+	mu      *sync.Mutex
+	schemas map[string]avro.Schema
+}
+
+// NewRegistryAvro encodes values with the latest schema of subject and
+// decodes payloads with the schema they were written with, both in the
+// schema registry wire format.
+func NewRegistryAvro[T any](reg *Registry, subject string) chord.Codec[T] {
+	return RegistryAvro[T]{reg, subject, new(sync.Mutex), make(map[string]avro.Schema)}
+}
+
+func (a RegistryAvro[T]) parse(schema string) (avro.Schema, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if s, ok := a.schemas[schema]; ok {
+		return s, nil
+	}
+	s, err := avro.Parse(schema)
+	if err != nil {
+		return nil, err
+	}
+	a.schemas[schema] = s
+	return s, nil
+}
+
+func (a RegistryAvro[T]) Encode(v T) ([]byte, error) {
+	id, schema, err := a.reg.Latest(a.subject)
+	if err != nil {
+		return nil, err
+	}
+	s, err := a.parse(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := avro.Marshal(s, v)
+	if err != nil {
+		return nil, err
+	}
+	return frame(id, b), nil
+}
+
+func (a RegistryAvro[T]) Decode(b []byte) (T, error) {
+	var v T
+
+	id, body, err := unframe(b)
+	if err != nil {
+		return v, err
+	}
+	schema, err := a.reg.Schema(id)
+	if err != nil {
+		return v, err
+	}
+	s, err := a.parse(schema)
+	if err != nil {
+		return v, err
+	}
+
+	err = avro.Unmarshal(s, body, &v)
+	return v, err
+}
+
+type RegistryProtobuf[T proto.Message] struct {
+	reg     *Registry
+	subject string
+	new     func() T
+}
+
+// NewRegistryProtobuf encodes messages in the schema registry wire format
+// under the latest schema ID of subject. Messages must be the first type
+// declared in their schema; payloads naming any message type are decoded
+// into newMsg.
+func NewRegistryProtobuf[T proto.Message](reg *Registry, subject string, newMsg func() T) chord.Codec[T] {
+	return RegistryProtobuf[T]{reg, subject, newMsg}
+}
+
+func (p RegistryProtobuf[T]) Encode(v T) ([]byte, error) {
+	id, _, err := p.reg.Latest(p.subject)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := proto.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// A single zero byte is the message index list [0].
+	return frame(id, append([]byte{0}, b...)), nil
+}
+
+func (p RegistryProtobuf[T]) Decode(b []byte) (T, error) {
+	v := p.new()
+
+	_, body, err := unframe(b)
+	if err != nil {
+		return v, err
+	}
+
+	// Skip the message index list: a zigzag varint count, then as many
+	// indexes, or a lone zero for the first message.
+	n, k := binary.Varint(body)
+	if k <= 0 {
+		return v, errWireFormat
+	}
+	body = body[k:]
+	for range n {
+		if _, k = binary.Varint(body); k <= 0 {
+			return v, errWireFormat
+		}
+		body = body[k:]
+	}
+
+	err = proto.Unmarshal(body, v)
+	return v, err
+}
@@ -0,0 +1,67 @@
+package chord
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Runnable is a flow bound to its input, ready to run until the input
+// completes or ctx is done.
+type Runnable interface {
+	Run(context.Context) error
+}
+
+type boundFlow[In, Out any] struct {
+	t Trigger[In]
+	f Flow[In, Out]
+}
+
+// Bind pairs a trigger with the flow it drives.
+func Bind[In, Out any](t Trigger[In], f Flow[In, Out]) Runnable {
+	return boundFlow[In, Out]{t, f}
+}
+
+func (b boundFlow[In, Out]) Run(ctx context.Context) error {
+	return RunFlowContext(ctx, b.t, b.f)
+}
+
+type boundSink[T any] struct {
+	s    Stage[T]
+	sink Sink[T]
+}
+
+// BindStage pairs an already built stage with the sink consuming it.
+func BindStage[T any](s Stage[T], sink Sink[T]) Runnable {
+	return boundSink[T]{s, sink}
+}
+
+func (b boundSink[T]) Run(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- RunFlow(b.s, NewFlow(func(s Stage[T]) Stage[T] { return s }, b.sink))
+	}()
+
+	// The stage was built with a context of its own and may outlive ctx;
+	// it then drains in the background.
+	select {
+	case err := <-done:
+		if err != nil {
+			return err
+		}
+	case <-ctx.Done():
+	}
+	return context.Cause(ctx)
+}
+
+// AttachToGroup runs each component as a member of g. A member returns
+// nil when its input completes, or why it stopped otherwise, so with
+// errgroup.WithContext the first failing member stops the rest and g.Wait
+// reports why.
+func AttachToGroup(ctx context.Context, g *errgroup.Group, rs ...Runnable) {
+	for _, r := range rs {
+		g.Go(func() error {
+			return r.Run(ctx)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Package idempotency stores the keys of processed events for
+// stage.Idempotent.
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Memory struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+	swept   int
+}
+
+// NewMemory keeps keys in memory, dropping expired ones as it goes.
+func NewMemory() *Memory {
+	return &Memory{expires: make(map[string]time.Time)}
+}
+
+// set records key until at, first dropping expired keys if the map has
+// doubled since they were last dropped. m.mu must be held.
+func (m *Memory) set(key string, at, now time.Time) {
+	if len(m.expires) >= 2*max(m.swept, 1024) {
+		for k, exp := range m.expires {
+			if !now.Before(exp) {
+				delete(m.expires, k)
+			}
+		}
+		m.swept = len(m.expires)
+	}
+	m.expires[key] = at
+}
+
+func (m *Memory) Claim(ctx context.Context, key string, lease time.Duration) (bool, error) {
+	now := chord.ClockFrom(ctx).Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if at, ok := m.expires[key]; ok && now.Before(at) {
+		return false, nil
+	}
+	m.set(key, now.Add(lease), now)
+	return true, nil
+}
+
+func (m *Memory) Complete(ctx context.Context, key string, ttl time.Duration) error {
+	now := chord.ClockFrom(ctx).Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.set(key, now.Add(ttl), now)
+	return nil
+}
+
+func (m *Memory) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.expires, key)
+	return nil
+}
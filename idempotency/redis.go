@@ -0,0 +1,31 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type Redis struct {
+	redis.UniversalClient
+	prefix string
+}
+
+// NewRedis keeps each key in a string at prefix followed by the key,
+// expiring with it, so processes sharing the Redis server share keys.
+func NewRedis(rdb redis.UniversalClient, prefix string) Redis {
+	return Redis{rdb, prefix}
+}
+
+func (r Redis) Claim(ctx context.Context, key string, lease time.Duration) (bool, error) {
+	return r.SetNX(ctx, r.prefix+key, "claimed", lease).Result()
+}
+
+func (r Redis) Complete(ctx context.Context, key string, ttl time.Duration) error {
+	return r.Set(ctx, r.prefix+key, "done", ttl).Err()
+}
+
+func (r Redis) Release(ctx context.Context, key string) error {
+	return r.Del(ctx, r.prefix+key).Err()
+}
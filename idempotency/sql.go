@@ -0,0 +1,49 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Sql struct {
+	*sql.DB
+	table string
+}
+
+// NewSql keeps keys in table, which is used as written and needs these
+// columns:
+//
+//	key     text primary key
+//	expires timestamptz (or timestamp)
+//
+// Expired rows are reclaimed rather than deleted. It uses ON CONFLICT and
+// $n parameters, as PostgreSQL, SQLite and CockroachDB accept.
+func NewSql(db *sql.DB, table string) Sql {
+	return Sql{db, table}
+}
+
+func (s Sql) Claim(ctx context.Context, key string, lease time.Duration) (bool, error) {
+	now := chord.ClockFrom(ctx).Now()
+	res, err := s.ExecContext(ctx, "INSERT INTO "+s.table+" (key, expires) VALUES ($1, $2)"+
+		" ON CONFLICT (key) DO UPDATE SET expires = excluded.expires WHERE "+s.table+".expires <= $3",
+		key, now.Add(lease), now)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s Sql) Complete(ctx context.Context, key string, ttl time.Duration) error {
+	_, err := s.ExecContext(ctx, "UPDATE "+s.table+" SET expires = $2 WHERE key = $1",
+		key, chord.ClockFrom(ctx).Now().Add(ttl))
+	return err
+}
+
+func (s Sql) Release(ctx context.Context, key string) error {
+	_, err := s.ExecContext(ctx, "DELETE FROM "+s.table+" WHERE key = $1", key)
+	return err
+}
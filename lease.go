@@ -0,0 +1,61 @@
+package chord
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrLeaseLost is the cause of a lease's context once the lease is lost.
+var ErrLeaseLost = errors.New("chord: lease lost")
+
+// Lease is a lock that at most one instance holds at a time. See the lease
+// package for implementations.
+type Lease interface {
+	// Acquire blocks until the lease is held or ctx is done. The returned
+	// context is canceled with ErrLeaseLost if the lease is lost, and when
+	// it is released.
+	Acquire(ctx context.Context) (context.Context, error)
+	Release(ctx context.Context) error
+}
+
+type leaderRunnable struct {
+	l Lease
+	r Runnable
+}
+
+// Leader runs r only on the instance holding l, so a flow such as a cron
+// or a poller runs once across replicas. When the lease is lost r is
+// stopped and the lease is contended for again. r is stopped before the
+// lease is released, but a lease that expires, say while its holder is cut
+// off from the backend, can be taken over before r notices: keep the
+// lease's TTL well above the time r takes to stop, and fence writes that
+// must not overlap, such as with the holder's generation or idempotency
+// keys.
+func Leader(l Lease, r Runnable) Runnable {
+	return leaderRunnable{l, r}
+}
+
+func (lr leaderRunnable) Run(ctx context.Context) error {
+	for {
+		held, err := lr.l.Acquire(ctx)
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		if err != nil {
+			return err
+		}
+
+		err = lr.r.Run(held)
+		lost := errors.Is(context.Cause(held), ErrLeaseLost)
+		if rerr := lr.l.Release(context.WithoutCancel(ctx)); rerr != nil && !lost && err == nil {
+			err = rerr
+		}
+
+		switch {
+		case ctx.Err() != nil:
+			return context.Cause(ctx)
+		case !lost:
+			return err
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package lease
+
+import (
+	"context"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+	"github.com/hashicorp/consul/api"
+)
+
+type Consul struct {
+	lock *api.Lock
+
+	mu     sync.Mutex
+	cancel context.CancelCauseFunc
+}
+
+// NewConsul contends for a Consul KV lock configured by opts, whose
+// session health checks and TTL decide when the lease is lost.
+func NewConsul(c *api.Client, opts *api.LockOptions) (*Consul, error) {
+	lock, err := c.LockOpts(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Consul{lock: lock}, nil
+}
+
+func (c *Consul) Acquire(ctx context.Context) (context.Context, error) {
+	stop := make(chan struct{})
+	defer context.AfterFunc(ctx, func() { close(stop) })()
+
+	lost, err := c.lock.Lock(stop)
+	if err != nil {
+		return nil, err
+	}
+	if lost == nil {
+		return nil, context.Cause(ctx)
+	}
+
+	held, cancel := context.WithCancelCause(ctx)
+	go func() {
+		select {
+		case <-lost:
+			cancel(chord.ErrLeaseLost)
+		case <-held.Done():
+		}
+	}()
+
+	c.mu.Lock()
+	c.cancel = cancel
+	c.mu.Unlock()
+	return held, nil
+}
+
+func (c *Consul) Release(context.Context) error {
+	c.mu.Lock()
+	cancel := c.cancel
+	c.cancel = nil
+	c.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel(context.Canceled)
+	return c.lock.Unlock()
+}
@@ -0,0 +1,80 @@
+// Package lease implements chord.Lease on top of common coordination
+// services. A lease value is held by one Acquire at a time.
+package lease
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+type Etcd struct {
+	c   *clientv3.Client
+	key string
+	ttl time.Duration
+
+	mu      sync.Mutex
+	session *concurrency.Session
+	mutex   *concurrency.Mutex
+	cancel  context.CancelCauseFunc
+}
+
+// NewEtcd contends for a mutex under key, kept alive by a session lease
+// with the given TTL (60s if zero). The lease is lost once the session
+// cannot be renewed within the TTL.
+func NewEtcd(c *clientv3.Client, key string, ttl time.Duration) *Etcd {
+	if ttl == 0 {
+		ttl = 60 * time.Second
+	}
+	return &Etcd{c: c, key: key, ttl: ttl}
+}
+
+func (e *Etcd) Acquire(ctx context.Context) (context.Context, error) {
+	s, err := concurrency.NewSession(e.c,
+		concurrency.WithTTL(int(e.ttl/time.Second)),
+		concurrency.WithContext(context.WithoutCancel(ctx)))
+	if err != nil {
+		return nil, err
+	}
+
+	m := concurrency.NewMutex(s, e.key)
+	if err := m.Lock(ctx); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	held, cancel := context.WithCancelCause(ctx)
+	go func() {
+		select {
+		case <-s.Done():
+			cancel(chord.ErrLeaseLost)
+		case <-held.Done():
+		}
+	}()
+
+	e.mu.Lock()
+	e.session, e.mutex, e.cancel = s, m, cancel
+	e.mu.Unlock()
+	return held, nil
+}
+
+func (e *Etcd) Release(ctx context.Context) error {
+	e.mu.Lock()
+	s, m, cancel := e.session, e.mutex, e.cancel
+	e.session, e.mutex, e.cancel = nil, nil, nil
+	e.mu.Unlock()
+
+	if s == nil {
+		return nil
+	}
+	cancel(context.Canceled)
+	err := m.Unlock(ctx)
+	if cerr := s.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
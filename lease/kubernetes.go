@@ -0,0 +1,107 @@
+package lease
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+type KubernetesConfig struct {
+	Namespace string
+	Name      string
+	// Identity names this instance in the Lease object. It defaults to the
+	// host name, which is the pod name in a cluster.
+	Identity string
+	// LeaseDuration is how long followers wait before taking over from a
+	// holder that stopped renewing. It defaults to 15s.
+	LeaseDuration time.Duration
+}
+
+type Kubernetes struct {
+	client coordinationv1.LeasesGetter
+	cfg    KubernetesConfig
+
+	mu     sync.Mutex
+	stop   context.CancelFunc
+	done   chan struct{}
+	cancel context.CancelCauseFunc
+}
+
+// NewKubernetes contends for a coordination.k8s.io Lease object, as
+// controllers do. Pass clientset.CoordinationV1().
+func NewKubernetes(client coordinationv1.LeasesGetter, cfg KubernetesConfig) *Kubernetes {
+	if cfg.Identity == "" {
+		cfg.Identity, _ = os.Hostname()
+	}
+	if cfg.LeaseDuration == 0 {
+		cfg.LeaseDuration = 15 * time.Second
+	}
+	return &Kubernetes{client: client, cfg: cfg}
+}
+
+func (k *Kubernetes) Acquire(ctx context.Context) (context.Context, error) {
+	held, cancel := context.WithCancelCause(ctx)
+	started := make(chan struct{})
+
+	le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Namespace: k.cfg.Namespace, Name: k.cfg.Name},
+			Client:     k.client,
+			LockConfig: resourcelock.ResourceLockConfig{Identity: k.cfg.Identity},
+		},
+		LeaseDuration:   k.cfg.LeaseDuration,
+		RenewDeadline:   k.cfg.LeaseDuration * 2 / 3,
+		RetryPeriod:     k.cfg.LeaseDuration / 7,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) { close(started) },
+			OnStoppedLeading: func() { cancel(chord.ErrLeaseLost) },
+		},
+	})
+	if err != nil {
+		cancel(err)
+		return nil, err
+	}
+
+	runCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		le.Run(runCtx)
+	}()
+
+	select {
+	case <-started:
+	case <-ctx.Done():
+		stop()
+		<-done
+		return nil, context.Cause(ctx)
+	}
+
+	k.mu.Lock()
+	k.stop, k.done, k.cancel = stop, done, cancel
+	k.mu.Unlock()
+	return held, nil
+}
+
+func (k *Kubernetes) Release(context.Context) error {
+	k.mu.Lock()
+	stop, done, cancel := k.stop, k.done, k.cancel
+	k.stop, k.done, k.cancel = nil, nil, nil
+	k.mu.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+	cancel(context.Canceled)
+	stop()
+	<-done
+	return nil
+}
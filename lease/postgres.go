@@ -0,0 +1,99 @@
+package lease
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Postgres struct {
+	db   *sql.DB
+	name string
+	poll time.Duration
+
+	mu     sync.Mutex
+	conn   *sql.Conn
+	cancel context.CancelCauseFunc
+}
+
+// NewPostgres contends for a session-level advisory lock keyed by name,
+// trying again every poll (5s if zero). The lock lives on a connection of
+// its own, which is pinged at the same interval; the lease is lost if the
+// connection breaks.
+func NewPostgres(db *sql.DB, name string, poll time.Duration) *Postgres {
+	if poll == 0 {
+		poll = 5 * time.Second
+	}
+	return &Postgres{db: db, name: name, poll: poll}
+}
+
+func (p *Postgres) Acquire(ctx context.Context) (context.Context, error) {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	t := chord.ClockFrom(ctx).NewTicker(p.poll)
+	defer t.Stop()
+
+	for {
+		var ok bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock(hashtext($1))", p.name).Scan(&ok); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if ok {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, context.Cause(ctx)
+		case <-t.C():
+		}
+	}
+
+	held, cancel := context.WithCancelCause(ctx)
+	go func() {
+		t := chord.ClockFrom(ctx).NewTicker(p.poll)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-held.Done():
+				return
+			case <-t.C():
+				if err := conn.PingContext(held); err != nil && held.Err() == nil {
+					cancel(chord.ErrLeaseLost)
+					return
+				}
+			}
+		}
+	}()
+
+	p.mu.Lock()
+	p.conn, p.cancel = conn, cancel
+	p.mu.Unlock()
+	return held, nil
+}
+
+func (p *Postgres) Release(ctx context.Context) error {
+	p.mu.Lock()
+	conn, cancel := p.conn, p.cancel
+	p.conn, p.cancel = nil, nil
+	p.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+	cancel(context.Canceled)
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock(hashtext($1))", p.name)
+	if cerr := conn.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
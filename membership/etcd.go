@@ -0,0 +1,80 @@
+package membership
+
+import (
+	"context"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+type Etcd struct {
+	c      *clientv3.Client
+	prefix string
+	ttl    time.Duration
+}
+
+// NewEtcd registers each member as a key under prefix, attached to a
+// session lease with the given TTL (60s if zero) so members that die are
+// dropped once it expires.
+func NewEtcd(c *clientv3.Client, prefix string, ttl time.Duration) Etcd {
+	if ttl == 0 {
+		ttl = 60 * time.Second
+	}
+	return Etcd{c, prefix, ttl}
+}
+
+func (e Etcd) members(ctx context.Context) ([]string, error) {
+	resp, err := e.c.Get(ctx, e.prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly(), clientv3.WithSort(clientv3.SortByKey, clientv3.SortAscend))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(resp.Kvs))
+	for i, kv := range resp.Kvs {
+		ids[i] = string(kv.Key[len(e.prefix):])
+	}
+	return ids, nil
+}
+
+// Join closes the channel early if the session is lost.
+func (e Etcd) Join(ctx context.Context, id string) (<-chan []string, error) {
+	s, err := concurrency.NewSession(e.c, concurrency.WithTTL(int(e.ttl/time.Second)), concurrency.WithContext(context.WithoutCancel(ctx)))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := e.c.Put(ctx, e.prefix+id, "", clientv3.WithLease(s.Lease())); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	watch := e.c.Watch(ctx, e.prefix, clientv3.WithPrefix())
+
+	ch := make(chan []string, 1)
+	go func() {
+		defer close(ch)
+		defer cancel()
+		defer s.Close()
+
+		for {
+			ids, err := e.members(ctx)
+			if err != nil {
+				return
+			}
+			offer(ch, ids)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.Done():
+				return
+			case _, ok := <-watch:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,68 @@
+// Package membership tracks the live instances of a flow for
+// chord.Partitioned.
+package membership
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// offer replaces the update waiting in ch, if any, with ids, so a slow
+// reader only ever sees the latest members. ch must have a buffer of one
+// and a single sender.
+func offer(ch chan []string, ids []string) {
+	select {
+	case <-ch:
+	default:
+	}
+	ch <- ids
+}
+
+type Memory struct {
+	mu      sync.Mutex
+	members map[string]int
+	subs    map[chan []string]bool
+}
+
+// NewMemory tracks members within the process, for tests and for
+// partitioning work across goroutines.
+func NewMemory() *Memory {
+	return &Memory{members: make(map[string]int), subs: make(map[chan []string]bool)}
+}
+
+// publish sends the members to every subscriber. m.mu must be held.
+func (m *Memory) publish() {
+	ids := make([]string, 0, len(m.members))
+	for id := range m.members {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	for ch := range m.subs {
+		offer(ch, ids)
+	}
+}
+
+func (m *Memory) Join(ctx context.Context, id string) (<-chan []string, error) {
+	ch := make(chan []string, 1)
+
+	m.mu.Lock()
+	m.members[id]++
+	m.subs[ch] = true
+	m.publish()
+	m.mu.Unlock()
+
+	context.AfterFunc(ctx, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if m.members[id]--; m.members[id] == 0 {
+			delete(m.members, id)
+		}
+		delete(m.subs, ch)
+		close(ch)
+		m.publish()
+	})
+	return ch, nil
+}
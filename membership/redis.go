@@ -0,0 +1,83 @@
+package membership
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/redis/go-redis/v9"
+)
+
+type Redis struct {
+	redis.UniversalClient
+	key string
+	ttl time.Duration
+}
+
+// NewRedis keeps members in a sorted set at key, scored by when their
+// heartbeat expires. Members heartbeat every third of ttl (30s if zero)
+// and are dropped once it passes without one.
+func NewRedis(rdb redis.UniversalClient, key string, ttl time.Duration) Redis {
+	if ttl == 0 {
+		ttl = 30 * time.Second
+	}
+	return Redis{rdb, key, ttl}
+}
+
+// heartbeat renews id and returns the live members.
+func (r Redis) heartbeat(ctx context.Context, id string) ([]string, error) {
+	now := chord.ClockFrom(ctx).Now()
+
+	var ids *redis.StringSliceCmd
+	_, err := r.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZAdd(ctx, r.key, redis.Z{Score: float64(now.Add(r.ttl).UnixMilli()), Member: id})
+		p.ZRemRangeByScore(ctx, r.key, "-inf", strconv.FormatInt(now.UnixMilli(), 10))
+		ids = p.ZRange(ctx, r.key, 0, -1)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	members := ids.Val()
+	slices.Sort(members)
+	return members, nil
+}
+
+// Join keeps the last known members after a failed heartbeat, until one
+// succeeds again.
+func (r Redis) Join(ctx context.Context, id string) (<-chan []string, error) {
+	ids, err := r.heartbeat(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan []string, 1)
+	offer(ch, ids)
+
+	go func() {
+		defer close(ch)
+		defer r.ZRem(context.WithoutCancel(ctx), r.key, id)
+
+		t := chord.ClockFrom(ctx).NewTicker(r.ttl / 3)
+		defer t.Stop()
+
+		last := ids
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C():
+			}
+
+			ids, err := r.heartbeat(ctx, id)
+			if err == nil && !slices.Equal(ids, last) {
+				offer(ch, ids)
+				last = ids
+			}
+		}
+	}()
+	return ch, nil
+}
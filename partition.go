@@ -0,0 +1,152 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+)
+
+var errMembershipEnded = errors.New("chord: membership ended")
+
+// Membership tracks the live instances of a flow. See the membership
+// package for implementations.
+type Membership interface {
+	// Join registers the instance id until ctx is done and sends the IDs
+	// of all live members, itself included, whenever they change.
+	Join(ctx context.Context, id string) (<-chan []string, error)
+}
+
+type PartitionConfig struct {
+	// ID names this instance among the members. It defaults to the host
+	// name and process ID.
+	ID string
+	// Partitions is the number of parts the key space is split into. It
+	// defaults to 16.
+	Partitions int
+}
+
+// PartitionOf maps a key, such as a file name or customer ID, to one of n
+// partitions. It panics if n is not positive.
+func PartitionOf(key string, n int) int {
+	if n <= 0 {
+		panic("chord: PartitionOf needs a positive number of partitions")
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
+
+// owner picks the member owning partition p by rendezvous hashing, so only
+// the partitions of members joining or leaving move.
+func owner(members []string, p int) string {
+	var best string
+	var top uint64
+	for _, m := range members {
+		h := fnv.New64a()
+		fmt.Fprintf(h, "%s/%d", m, p)
+		// FNV alone scores keys differing in one byte alike, so its
+		// bits are mixed as in splitmix64.
+		s := h.Sum64()
+		s = (s ^ s>>30) * 0xbf58476d1ce4e5b9
+		s = (s ^ s>>27) * 0x94d049bb133111eb
+		s ^= s >> 31
+		if best == "" || s > top {
+			best, top = m, s
+		}
+	}
+	return best
+}
+
+type partitionedRunnable struct {
+	m   Membership
+	cfg PartitionConfig
+	run func(partition int) Runnable
+}
+
+// Partitioned splits a key space across the running instances of a flow:
+// it runs run(p) for each partition p assigned to this instance, and
+// stops and starts partitions as members join and leave. Triggers are
+// built to cover their partition only, such as the files whose names
+// PartitionOf maps to it or a range of IDs. A moved partition may briefly
+// run on both instances, so pair it with idempotent processing. It panics
+// if Partitions is negative.
+func Partitioned(m Membership, cfg PartitionConfig, run func(partition int) Runnable) Runnable {
+	if cfg.Partitions < 0 {
+		panic("chord: negative number of partitions")
+	}
+	if cfg.Partitions == 0 {
+		cfg.Partitions = 16
+	}
+	if cfg.ID == "" {
+		host, _ := os.Hostname()
+		cfg.ID = fmt.Sprintf("%s-%d", host, os.Getpid())
+	}
+	return partitionedRunnable{m, cfg, run}
+}
+
+type partitionRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (pr partitionedRunnable) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	members, err := pr.m.Join(ctx, pr.cfg.ID)
+	if err != nil {
+		return err
+	}
+
+	owned := make(map[int]*partitionRun)
+	stop := func(p int) {
+		r := owned[p]
+		r.cancel()
+		<-r.done
+		delete(owned, p)
+	}
+	defer func() {
+		for p := range owned {
+			stop(p)
+		}
+	}()
+
+	for {
+		var ids []string
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case ids, ok = <-members:
+		}
+		if !ok {
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+			return errMembershipEnded
+		}
+
+		for p := range owned {
+			if owner(ids, p) != pr.cfg.ID {
+				stop(p)
+			}
+		}
+		for p := range pr.cfg.Partitions {
+			if owned[p] != nil || owner(ids, p) != pr.cfg.ID {
+				continue
+			}
+
+			pctx, pcancel := context.WithCancel(ctx)
+			r := &partitionRun{pcancel, make(chan struct{})}
+			owned[p] = r
+			go func() {
+				defer close(r.done)
+				if err := pr.run(p).Run(pctx); err != nil && pctx.Err() == nil {
+					cancel(err)
+				}
+			}()
+		}
+	}
+}
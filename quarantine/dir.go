@@ -0,0 +1,106 @@
+package quarantine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var errInvalidID = errors.New("quarantine: invalid item id")
+
+type Dir struct {
+	dir string
+}
+
+// NewDir keeps each item as a JSON file in dir.
+func NewDir(dir string) Dir {
+	return Dir{dir}
+}
+
+// path is where the item is kept. IDs come from requests too, so they
+// must not name files elsewhere.
+func (d Dir) path(id string) (string, bool) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", false
+	}
+	return filepath.Join(d.dir, id+".json"), true
+}
+
+func (d Dir) Put(_ context.Context, it Item) error {
+	if err := os.MkdirAll(d.dir, 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(it)
+	if err != nil {
+		return err
+	}
+
+	path, ok := d.path(it.ID)
+	if !ok {
+		return errInvalidID
+	}
+	if err := os.WriteFile(path+".tmp", b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (d Dir) Get(_ context.Context, id string) (Item, error) {
+	var it Item
+	path, ok := d.path(id)
+	if !ok {
+		return it, ErrNotFound
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return it, ErrNotFound
+	}
+	if err != nil {
+		return it, err
+	}
+	return it, json.Unmarshal(b, &it)
+}
+
+func (d Dir) List(ctx context.Context, f Filter) ([]Item, error) {
+	entries, err := os.ReadDir(d.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var items []Item
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		it, err := d.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if f.Match(it) {
+			items = append(items, it)
+		}
+	}
+	return sortItems(items, f), nil
+}
+
+func (d Dir) Delete(_ context.Context, id string) error {
+	path, ok := d.path(id)
+	if !ok {
+		return nil
+	}
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
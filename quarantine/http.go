@@ -0,0 +1,124 @@
+package quarantine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func writeJson(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, errBadFilter):
+		status = http.StatusBadRequest
+	case errors.Is(err, ErrNotRunning), errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusServiceUnavailable
+	}
+	writeJson(w, status, map[string]string{"error": err.Error()})
+}
+
+var errBadFilter = errors.New("quarantine: bad filter")
+
+// filter reads a Filter from the query parameters id (repeatable), stage,
+// error, since, until (RFC 3339) and limit.
+func filter(r *http.Request) (Filter, error) {
+	q := r.URL.Query()
+	f := Filter{IDs: q["id"], Stage: q.Get("stage"), ErrorContains: q.Get("error")}
+
+	var err error
+	if s := q.Get("since"); s != "" {
+		if f.Since, err = time.Parse(time.RFC3339, s); err != nil {
+			return f, errors.Join(errBadFilter, err)
+		}
+	}
+	if s := q.Get("until"); s != "" {
+		if f.Until, err = time.Parse(time.RFC3339, s); err != nil {
+			return f, errors.Join(errBadFilter, err)
+		}
+	}
+	if s := q.Get("limit"); s != "" {
+		if f.Limit, err = strconv.Atoi(s); err != nil {
+			return f, errors.Join(errBadFilter, err)
+		}
+	}
+	return f, nil
+}
+
+// Handler serves an inspection API, to mount under a prefix with
+// http.StripPrefix:
+//
+//	GET    /items             list items, filtered by query parameters
+//	GET    /items/{id}        one item
+//	DELETE /items/{id}        discard an item
+//	POST   /reprocess         reprocess the items matching the query
+//
+// Lists and reprocessing take the filter parameters id (repeatable),
+// stage, error (a substring), since, until and limit. Reprocessing
+// answers 503 when Stage is not running or does not take the items within
+// 10s.
+func (q *Quarantine[T]) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /items", func(w http.ResponseWriter, r *http.Request) {
+		f, err := filter(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		items, err := q.store.List(r.Context(), f)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if items == nil {
+			items = []Item{}
+		}
+		writeJson(w, http.StatusOK, items)
+	})
+
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		it, err := q.store.Get(r.Context(), r.PathValue("id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJson(w, http.StatusOK, it)
+	})
+
+	mux.HandleFunc("DELETE /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := q.store.Delete(r.Context(), r.PathValue("id")); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /reprocess", func(w http.ResponseWriter, r *http.Request) {
+		f, err := filter(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+		n, err := q.Reprocess(ctx, f)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJson(w, http.StatusOK, map[string]int{"reprocessed": n})
+	})
+
+	return mux
+}
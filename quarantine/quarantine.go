@@ -0,0 +1,262 @@
+package quarantine
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Config struct {
+	// Stage names the guarded step in the items it quarantines.
+	Stage string
+	// Attempts is how many times an event is tried before it is
+	// quarantined. It defaults to 1.
+	Attempts int
+	// Backoff is the delay before the second attempt, doubling after
+	// that. It defaults to 100ms.
+	Backoff time.Duration
+}
+
+// ErrNotRunning fails Reprocess while Stage is not running to take the
+// items.
+var ErrNotRunning = errors.New("quarantine: stage not running")
+
+type reprocessKey struct{}
+
+// Quarantine stores the events that keep failing a step instead of
+// letting them go round a redelivering source forever. Stage emits the
+// items picked with Reprocess, so merge it into the flow's trigger to
+// retry them once the cause is fixed.
+type Quarantine[T any] struct {
+	store Store
+	codec chord.Codec[T]
+	queue chan Item
+
+	mu       sync.Mutex
+	inflight map[string]bool
+	// running is closed when the running Stage stops, nil without one.
+	running chan struct{}
+}
+
+func New[T any](store Store, codec chord.Codec[T]) *Quarantine[T] {
+	return &Quarantine[T]{
+		store:    store,
+		codec:    codec,
+		queue:    make(chan Item),
+		inflight: make(map[string]bool),
+	}
+}
+
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// chain lists the messages of err and the errors it wraps.
+func chain(err error) []string {
+	var msgs []string
+	errs := []error{err}
+	for len(errs) > 0 {
+		e := errs[0]
+		errs = errs[1:]
+		msgs = append(msgs, e.Error())
+
+		switch u := e.(type) {
+		case interface{ Unwrap() error }:
+			if next := u.Unwrap(); next != nil {
+				errs = append(errs, next)
+			}
+		case interface{ Unwrap() []error }:
+			errs = append(errs, u.Unwrap()...)
+		}
+	}
+	return msgs
+}
+
+// quarantine stores v, replacing the item it was reprocessed from.
+func (q *Quarantine[T]) quarantine(ctx context.Context, cfg Config, v T, err error) error {
+	b, eerr := q.codec.Encode(v)
+	if eerr != nil {
+		return errors.Join(err, eerr)
+	}
+
+	it := Item{
+		ID:       newID(),
+		Payload:  b,
+		Stage:    cfg.Stage,
+		Error:    err.Error(),
+		Chain:    chain(err)[1:],
+		Attempts: cfg.Attempts,
+		Time:     chord.ClockFrom(ctx).Now(),
+	}
+	if prev, ok := ctx.Value(reprocessKey{}).(Item); ok {
+		it.ID = prev.ID
+		it.Attempts += prev.Attempts
+	}
+	if perr := q.store.Put(context.WithoutCancel(ctx), it); perr != nil {
+		return errors.Join(err, perr)
+	}
+	return nil
+}
+
+// Guard runs fn for each event, trying it up to Attempts times, and
+// quarantines the events it keeps failing on. They are acked and dropped
+// from the flow; if they cannot be stored, the failure goes to the error
+// path as usual.
+func Guard[T, Out any](q *Quarantine[T], s chord.Stage[T], cfg Config, fn func(context.Context, T) (Out, error)) chord.Stage[Out] {
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = 1
+	}
+	if cfg.Backoff == 0 {
+		cfg.Backoff = 100 * time.Millisecond
+	}
+
+	return chord.NewProducer(context.Background(), func(_ context.Context, emit chord.Emit[Out]) {
+		chord.NewConsumer(s,
+			func(ctx context.Context, v T) error {
+				var out Out
+				var err error
+				for attempt := range cfg.Attempts {
+					if attempt > 0 && !sleep(ctx, cfg.Backoff<<(attempt-1)) {
+						return err
+					}
+					if out, err = fn(ctx, v); err == nil {
+						emit(ctx, out, nil)
+						return nil
+					}
+					if ctx.Err() != nil {
+						return err
+					}
+				}
+
+				if err := q.quarantine(ctx, cfg, v, err); err != nil {
+					return err
+				}
+				// A reprocessed item that failed again stays quarantined.
+				if _, ok := ctx.Value(reprocessKey{}).(Item); ok {
+					chord.Nack(ctx)
+				} else {
+					chord.Ack(ctx)
+				}
+				return nil
+			},
+			func(ctx context.Context, err error) {
+				var zero Out
+				emit(ctx, zero, err)
+			},
+		)
+	})
+}
+
+// sleep waits for d on the clock of ctx, reporting false if ctx is done
+// first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := chord.ClockFrom(ctx).NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// reprocessAck deletes an item once it has been processed.
+type reprocessAck[T any] struct {
+	q  *Quarantine[T]
+	it Item
+}
+
+func (a reprocessAck[T]) Ack() {
+	a.q.store.Delete(context.Background(), a.it.ID)
+	a.q.done(a.it.ID)
+}
+
+func (a reprocessAck[T]) Nack() {
+	a.q.done(a.it.ID)
+}
+
+func (q *Quarantine[T]) done(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.inflight, id)
+}
+
+// Reprocess hands the items matching f to Stage, skipping those already
+// being reprocessed, and returns how many it handed over. Items are
+// deleted once their events are acked. It fails with ErrNotRunning if
+// Stage is not running or stops meanwhile.
+func (q *Quarantine[T]) Reprocess(ctx context.Context, f Filter) (int, error) {
+	q.mu.Lock()
+	running := q.running
+	q.mu.Unlock()
+	if running == nil {
+		return 0, ErrNotRunning
+	}
+
+	items, err := q.store.List(ctx, f)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, it := range items {
+		q.mu.Lock()
+		busy := q.inflight[it.ID]
+		q.inflight[it.ID] = true
+		q.mu.Unlock()
+		if busy {
+			continue
+		}
+
+		select {
+		case q.queue <- it:
+			n++
+		case <-ctx.Done():
+			q.done(it.ID)
+			return n, ctx.Err()
+		case <-running:
+			q.done(it.ID)
+			return n, ErrNotRunning
+		}
+	}
+	return n, nil
+}
+
+// Stage emits the events picked with Reprocess.
+func (q *Quarantine[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[T]) {
+		running := make(chan struct{})
+		q.mu.Lock()
+		q.running = running
+		q.mu.Unlock()
+		defer func() {
+			q.mu.Lock()
+			if q.running == running {
+				q.running = nil
+			}
+			q.mu.Unlock()
+			close(running)
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case it := <-q.queue:
+				v, err := q.codec.Decode(it.Payload)
+				ictx := context.WithValue(chord.WithAcker(ctx, reprocessAck[T]{q, it}), reprocessKey{}, it)
+				if !emit(ictx, v, err) {
+					q.done(it.ID)
+					return
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,205 @@
+package quarantine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/codec"
+)
+
+type testAcker struct {
+	mu          sync.Mutex
+	acks, nacks int
+}
+
+func (a *testAcker) Ack() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acks++
+}
+
+func (a *testAcker) Nack() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nacks++
+}
+
+var errBroken = errors.New("broken")
+
+// half fails on odd numbers while broken is set.
+func half(broken *atomic.Bool, calls *atomic.Int32) func(context.Context, int) (int, error) {
+	return func(_ context.Context, v int) (int, error) {
+		calls.Add(1)
+		if v%2 == 1 && broken.Load() {
+			return 0, fmt.Errorf("half %d: %w", v, errBroken)
+		}
+		return v / 2, nil
+	}
+}
+
+func TestGuardQuarantinesAfterAttempts(t *testing.T) {
+	store := NewMemory()
+	q := New(store, codec.NewJson[int]())
+	ackers := []*testAcker{new(testAcker), new(testAcker)}
+	src := chord.NewProducer(context.Background(), func(ctx context.Context, emit chord.Emit[int]) {
+		for i, v := range []int{4, 3} {
+			emit(chord.WithAcker(ctx, ackers[i]), v, nil)
+		}
+	})
+
+	var broken atomic.Bool
+	var calls atomic.Int32
+	broken.Store(true)
+	cfg := Config{Stage: "half", Attempts: 3, Backoff: time.Millisecond}
+
+	var got []int
+	chord.NewConsumer(Guard(q, src, cfg, half(&broken, &calls)),
+		func(ctx context.Context, v int) error {
+			got = append(got, v)
+			chord.Ack(ctx)
+			return nil
+		},
+		func(_ context.Context, err error) { t.Errorf("unexpected error: %v", err) },
+	)
+
+	if !slices.Equal(got, []int{2}) {
+		t.Errorf("emitted %v, want [2]", got)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("fn called %d times, want 4", n)
+	}
+	for i, a := range ackers {
+		if a.acks != 1 || a.nacks != 0 {
+			t.Errorf("event %d: %d acks, %d nacks", i, a.acks, a.nacks)
+		}
+	}
+
+	items, _ := store.List(context.Background(), Filter{})
+	if len(items) != 1 {
+		t.Fatalf("quarantined %d items, want 1", len(items))
+	}
+	it := items[0]
+	if it.Stage != "half" || it.Attempts != 3 || string(it.Payload) != "3" {
+		t.Errorf("quarantined %+v", it)
+	}
+	if want := []string{"broken"}; !slices.Equal(it.Chain, want) {
+		t.Errorf("chain %q, want %q", it.Chain, want)
+	}
+}
+
+func TestReprocess(t *testing.T) {
+	store := NewMemory()
+	q := New(store, codec.NewJson[int]())
+	store.Put(context.Background(), Item{ID: "a", Payload: []byte("3"), Stage: "half", Attempts: 1})
+
+	if _, err := q.Reprocess(context.Background(), Filter{}); !errors.Is(err, ErrNotRunning) {
+		t.Fatalf("Reprocess without a running Stage: %v, want ErrNotRunning", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var broken atomic.Bool
+	var calls atomic.Int32
+	broken.Store(true)
+	out := Guard(q, q.Stage(ctx), Config{Stage: "half"}, half(&broken, &calls))
+
+	got := make(chan int)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		chord.NewConsumer(out,
+			func(ctx context.Context, v int) error {
+				chord.Ack(ctx)
+				got <- v
+				return nil
+			},
+			func(_ context.Context, err error) { t.Errorf("unexpected error: %v", err) },
+		)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// Reprocess hands over items once Stage runs.
+	var n int
+	var err error
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if n, err = q.Reprocess(ctx, Filter{}); !errors.Is(err, ErrNotRunning) {
+			break
+		}
+	}
+	if err != nil || n != 1 {
+		t.Fatalf("Reprocess = %d, %v, want 1 item", n, err)
+	}
+
+	// Failing again keeps the item, with its attempts added up.
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		if it, _ := store.Get(ctx, "a"); it.Attempts == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("item not quarantined again")
+		}
+	}
+
+	broken.Store(false)
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		// The item is in flight until the failed attempt is nacked.
+		if n, err = q.Reprocess(ctx, Filter{IDs: []string{"a"}}); err != nil || n == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("item still in flight")
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := <-got; v != 1 {
+		t.Errorf("emitted %d, want 1", v)
+	}
+	if _, err := store.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("item kept after its event was acked: %v", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	store := NewMemory()
+	q := New(store, codec.NewJson[int]())
+	store.Put(context.Background(), Item{ID: "a", Payload: []byte("1"), Stage: "s", Time: time.Unix(10, 0)})
+	srv := httptest.NewServer(q.Handler())
+	defer srv.Close()
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{"GET", "/items", http.StatusOK},
+		{"GET", "/items?since=yesterday", http.StatusBadRequest},
+		{"GET", "/items/a", http.StatusOK},
+		{"GET", "/items/b", http.StatusNotFound},
+		{"POST", "/reprocess", http.StatusServiceUnavailable},
+		{"DELETE", "/items/a", http.StatusNoContent},
+		{"GET", "/items/a", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s %s: %d, want %d", tt.method, tt.path, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Package quarantine keeps poison events out of a flow for inspection and
+// selective reprocessing.
+package quarantine
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNotFound = errors.New("quarantine: item not found")
+
+// Item is a quarantined event with what is known about its failure.
+type Item struct {
+	ID      string `json:"id"`
+	Payload []byte `json:"payload"`
+	Stage   string `json:"stage"`
+	Error   string `json:"error"`
+	// Chain lists the messages of the errors the failure wraps, outermost
+	// first.
+	Chain    []string  `json:"chain,omitempty"`
+	Attempts int       `json:"attempts"`
+	Time     time.Time `json:"time"`
+}
+
+// Filter selects items. Zero fields match everything.
+type Filter struct {
+	IDs           []string
+	Stage         string
+	ErrorContains string
+	Since, Until  time.Time
+	Limit         int
+}
+
+func (f Filter) Match(it Item) bool {
+	switch {
+	case len(f.IDs) > 0 && !slices.Contains(f.IDs, it.ID):
+		return false
+	case f.Stage != "" && it.Stage != f.Stage:
+		return false
+	case f.ErrorContains != "" && !strings.Contains(it.Error, f.ErrorContains):
+		return false
+	case !f.Since.IsZero() && it.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !it.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// Store keeps quarantined items. Put replaces an item with the same ID.
+// List returns matching items, oldest first.
+type Store interface {
+	Put(ctx context.Context, it Item) error
+	Get(ctx context.Context, id string) (Item, error)
+	List(ctx context.Context, f Filter) ([]Item, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// sortItems orders items oldest first and applies the filter's limit.
+func sortItems(items []Item, f Filter) []Item {
+	slices.SortFunc(items, func(a, b Item) int {
+		if c := a.Time.Compare(b.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if f.Limit > 0 && len(items) > f.Limit {
+		items = items[:f.Limit]
+	}
+	return items
+}
+
+type Memory struct {
+	mu    sync.Mutex
+	items map[string]Item
+}
+
+func NewMemory() *Memory {
+	return &Memory{items: make(map[string]Item)}
+}
+
+func (m *Memory) Put(_ context.Context, it Item) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[it.ID] = it
+	return nil
+}
+
+func (m *Memory) Get(_ context.Context, id string) (Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	it, ok := m.items[id]
+	if !ok {
+		return Item{}, ErrNotFound
+	}
+	return it, nil
+}
+
+func (m *Memory) List(_ context.Context, f Filter) ([]Item, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var items []Item
+	for _, it := range m.items {
+		if f.Match(it) {
+			items = append(items, it)
+		}
+	}
+	return sortItems(items, f), nil
+}
+
+func (m *Memory) Delete(_ context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, id)
+	return nil
+}
@@ -0,0 +1,52 @@
+package chord
+
+import (
+	"io"
+	"iter"
+)
+
+type stageReader struct {
+	next func() ([]byte, error, bool)
+	stop func()
+	buf  []byte
+	err  error
+}
+
+// NewReader exposes a stage's outputs as one byte stream, in order. An
+// error in the stage is returned by Read and ends the stream. Close stops
+// reading; the rest of the stage is drained in the background.
+func NewReader(s Stage[[]byte]) io.ReadCloser {
+	next, stop := iter.Pull2(ToSeq(s))
+	return &stageReader{next: next, stop: stop}
+}
+
+func (r *stageReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		b, err, ok := r.next()
+		switch {
+		case !ok:
+			r.err = io.EOF
+		case err != nil:
+			r.err = err
+			r.stop()
+		default:
+			r.buf = b
+		}
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *stageReader) Close() error {
+	r.stop()
+	if r.err == nil {
+		r.err = io.ErrClosedPipe
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package chord
+
+import (
+	"context"
+	"iter"
+)
+
+// ToSeq runs the stage and yields each output, or each error with a zero
+// value. Breaking out of the loop stops yielding; the rest of the stage is
+// drained in the background so upstream is not blocked.
+func ToSeq[T any](s Stage[T]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		ch := make(chan item[T])
+		done := make(chan struct{})
+		defer close(done)
+
+		send := func(it item[T]) {
+			select {
+			case ch <- it:
+			case <-done:
+			}
+		}
+
+		go func() {
+			defer close(ch)
+
+			NewConsumer(s,
+				func(_ context.Context, v T) error {
+					send(item[T]{val: v})
+					return nil
+				},
+				func(_ context.Context, err error) {
+					send(item[T]{err: err})
+				},
+			)
+		}()
+
+		for it := range ch {
+			if !yield(it.val, it.err) {
+				return
+			}
+		}
+	}
+}
+
+// FromSeq turns seq into a stage, routing the non-nil errors it yields to
+// the error path.
+func FromSeq[T any](seq iter.Seq2[T, error]) Stage[T] {
+	return NewProducer(context.Background(), func(ctx context.Context, emit Emit[T]) {
+		for v, err := range seq {
+			emit(ctx, v, err)
+		}
+	})
+}
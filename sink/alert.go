@@ -0,0 +1,148 @@
+package sink
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type AlertConfig[T any] struct {
+	// Summary and DedupKey are templates executed on each output. Outputs
+	// with the same dedup key update or resolve the same incident.
+	Summary  string
+	DedupKey string
+	Source   string
+	// Severity is "critical", "error", "warning" or "info". It defaults to
+	// "error".
+	Severity func(T) string
+	// Resolve reports whether an output clears its incident rather than
+	// raising it.
+	Resolve func(T) bool
+	// Details attaches custom fields. It defaults to the output itself.
+	Details func(T) any
+	// URL overrides the service's API endpoint.
+	URL string
+}
+
+type alertService int
+
+const (
+	alertPagerDuty alertService = iota
+	alertOpsgenie
+)
+
+type Alert[T any] struct {
+	cfg      AlertConfig[T]
+	service  alertService
+	key      string
+	summary  *template.Template
+	dedupKey *template.Template
+	err      error
+}
+
+func newAlert[T any](service alertService, key, endpoint string, cfg AlertConfig[T]) chord.Sink[T] {
+	if cfg.URL == "" {
+		cfg.URL = endpoint
+	}
+	if cfg.Severity == nil {
+		cfg.Severity = func(T) string { return "error" }
+	}
+	if cfg.Details == nil {
+		cfg.Details = func(v T) any { return v }
+	}
+
+	a := Alert[T]{cfg: cfg, service: service, key: key}
+	a.summary, a.err = template.New("summary").Parse(cfg.Summary)
+	if a.err == nil {
+		a.dedupKey, a.err = template.New("dedup").Parse(cfg.DedupKey)
+	}
+	return a
+}
+
+// NewPagerDuty triggers and resolves incidents through the PagerDuty
+// Events API v2 with an integration routing key.
+func NewPagerDuty[T any](routingKey string, cfg AlertConfig[T]) chord.Sink[T] {
+	return newAlert(alertPagerDuty, routingKey, "https://events.pagerduty.com/v2/enqueue", cfg)
+}
+
+// NewOpsgenie creates and closes Opsgenie alerts, using the dedup key as
+// the alert alias.
+func NewOpsgenie[T any](apiKey string, cfg AlertConfig[T]) chord.Sink[T] {
+	return newAlert(alertOpsgenie, apiKey, "https://api.opsgenie.com/v2/alerts", cfg)
+}
+
+func opsgeniePriority(severity string) string {
+	switch severity {
+	case "critical":
+		return "P1"
+	case "error":
+		return "P2"
+	case "warning":
+		return "P3"
+	default:
+		return "P5"
+	}
+}
+
+func (a Alert[T]) OnSuccess(ctx context.Context, v T) error {
+	if a.err != nil {
+		return a.err
+	}
+
+	var summary, dedup strings.Builder
+	if err := a.summary.Execute(&summary, v); err != nil {
+		return err
+	}
+	if err := a.dedupKey.Execute(&dedup, v); err != nil {
+		return err
+	}
+	resolve := a.cfg.Resolve != nil && a.cfg.Resolve(v)
+
+	cfg := HttpConfig{URL: a.cfg.URL, MaxRetries: 5, Backoff: time.Second}
+	var payload any
+
+	switch a.service {
+	case alertOpsgenie:
+		cfg.Header = http.Header{"Authorization": {"GenieKey " + a.key}}
+		if resolve {
+			cfg.URL += "/" + url.PathEscape(dedup.String()) + "/close?identifierType=alias"
+			payload = map[string]any{"source": a.cfg.Source}
+			break
+		}
+		payload = map[string]any{
+			"message":  summary.String(),
+			"alias":    dedup.String(),
+			"source":   a.cfg.Source,
+			"priority": opsgeniePriority(a.cfg.Severity(v)),
+			"details":  a.cfg.Details(v),
+		}
+	default:
+		action := "trigger"
+		if resolve {
+			action = "resolve"
+		}
+		payload = map[string]any{
+			"routing_key":  a.key,
+			"event_action": action,
+			"dedup_key":    dedup.String(),
+			"payload": map[string]any{
+				"summary":        summary.String(),
+				"source":         a.cfg.Source,
+				"severity":       a.cfg.Severity(v),
+				"timestamp":      time.Now().Format(time.RFC3339),
+				"custom_details": a.cfg.Details(v),
+			},
+		}
+	}
+
+	return NewHttp[any](cfg).OnSuccess(ctx, payload)
+}
+
+func (a Alert[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
@@ -0,0 +1,173 @@
+package aws
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord/sink"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go-v2/service/cloudwatchlogs/types"
+)
+
+// PutLogEvents limits: events and bytes per call, and the per-event
+// overhead counted against the byte limit.
+const (
+	cloudwatchMaxEvents   = 10000
+	cloudwatchMaxBytes    = 1 << 20
+	cloudwatchEventBytes  = 26
+	cloudwatchMaxAttempts = 8
+)
+
+type CloudwatchLogsConfig[T any] struct {
+	Group  string
+	Stream string
+	// CreateStream creates the log stream if it does not exist yet.
+	CreateStream bool
+	// Message formats an output. It defaults to JSON.
+	Message func(T) (string, error)
+	// Time defaults to when the output reaches the sink.
+	Time func(T) time.Time
+	// BatchSize sends once this many events are buffered. It defaults to
+	// the API maximum of 10000.
+	BatchSize int
+	// Linger is how long a partial batch waits for more outputs. It
+	// defaults to 5s.
+	Linger time.Duration
+}
+
+type cloudwatchState struct {
+	mu      sync.Mutex
+	token   *string
+	created bool
+}
+
+type CloudwatchLogs[T any] struct {
+	*cloudwatchlogs.Client
+	cfg   CloudwatchLogsConfig[T]
+	state *cloudwatchState
+	batch *delivery.Batcher[types.InputLogEvent]
+}
+
+// NewCloudwatchLogs sends outputs to a CloudWatch Logs stream with
+// PutLogEvents, retrying throttled calls with backoff. Failures of batches
+// sent after Linger are logged. Call Flush before shutting down.
+func NewCloudwatchLogs[T any](c *cloudwatchlogs.Client, cfg CloudwatchLogsConfig[T]) CloudwatchLogs[T] {
+	if cfg.Message == nil {
+		cfg.Message = func(v T) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		}
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = cloudwatchMaxEvents
+	}
+	if cfg.Linger == 0 {
+		cfg.Linger = 5 * time.Second
+	}
+
+	cw := CloudwatchLogs[T]{Client: c, cfg: cfg, state: new(cloudwatchState)}
+	cw.batch = delivery.New(min(cfg.BatchSize, cloudwatchMaxEvents), cloudwatchMaxBytes, cfg.Linger, cw.put, sink.LogError)
+	return cw
+}
+
+func (cw CloudwatchLogs[T]) createStream(ctx context.Context) error {
+	_, err := cw.CreateLogStream(ctx, &cloudwatchlogs.CreateLogStreamInput{
+		LogGroupName:  &cw.cfg.Group,
+		LogStreamName: &cw.cfg.Stream,
+	})
+	var exists *types.ResourceAlreadyExistsException
+	if err != nil && !errors.As(err, &exists) {
+		return err
+	}
+	return nil
+}
+
+func (cw CloudwatchLogs[T]) put(ctx context.Context, events []types.InputLogEvent) error {
+	cw.state.mu.Lock()
+	defer cw.state.mu.Unlock()
+
+	if cw.cfg.CreateStream && !cw.state.created {
+		if err := cw.createStream(ctx); err != nil {
+			return err
+		}
+		cw.state.created = true
+	}
+
+	// Events of a call must be in chronological order.
+	slices.SortStableFunc(events, func(a, b types.InputLogEvent) int {
+		return cmp.Compare(aws.ToInt64(a.Timestamp), aws.ToInt64(b.Timestamp))
+	})
+
+	for attempt := 0; ; attempt++ {
+		out, err := cw.PutLogEvents(ctx, &cloudwatchlogs.PutLogEventsInput{
+			LogGroupName:  &cw.cfg.Group,
+			LogStreamName: &cw.cfg.Stream,
+			LogEvents:     events,
+			SequenceToken: cw.state.token,
+		})
+		if err == nil {
+			cw.state.token = out.NextSequenceToken
+			if out.RejectedLogEventsInfo != nil {
+				return errors.New("cloudwatch: some log events were rejected as too old, too new or expired")
+			}
+			return nil
+		}
+
+		var (
+			badToken  *types.InvalidSequenceTokenException
+			throttled *types.ThrottlingException
+			missing   *types.ResourceNotFoundException
+		)
+		switch {
+		case errors.As(err, &badToken):
+			// Streams that still use sequence tokens say which one is next.
+			cw.state.token = badToken.ExpectedSequenceToken
+		case errors.As(err, &missing) && cw.cfg.CreateStream && attempt == 0:
+			if err := cw.createStream(ctx); err != nil {
+				return err
+			}
+		case errors.As(err, &throttled):
+		default:
+			return err
+		}
+
+		if attempt+1 >= cloudwatchMaxAttempts || !delivery.Backoff(ctx, 200*time.Millisecond, attempt) {
+			return err
+		}
+	}
+}
+
+func (cw CloudwatchLogs[T]) OnSuccess(ctx context.Context, v T) error {
+	msg, err := cw.cfg.Message(v)
+	if err != nil {
+		return err
+	}
+
+	t := time.Now()
+	if cw.cfg.Time != nil {
+		t = cw.cfg.Time(v)
+	}
+
+	return cw.batch.Add(ctx, types.InputLogEvent{
+		Message:   &msg,
+		Timestamp: aws.Int64(t.UnixMilli()),
+	}, len(msg)+cloudwatchEventBytes)
+}
+
+func (cw CloudwatchLogs[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
+
+func (cw CloudwatchLogs[T]) SettlesOutputs() bool { return true }
+
+// Flush sends whatever is buffered.
+func (cw CloudwatchLogs[T]) Flush(ctx context.Context) error {
+	return cw.batch.Flush(ctx)
+}
@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/0x180db/go-chord/sink"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type S3Config[T any] struct {
+	Bucket string
+	Prefix string
+	// Layout formats the time partition of object keys. It defaults to
+	// "2006/01/02/15", one partition per hour.
+	Layout string
+	// Time places an output in a partition. It defaults to the time the
+	// output reaches the sink.
+	Time func(T) time.Time
+	// MaxBytes uploads an object once this much uncompressed JSON is
+	// buffered. It defaults to 64MiB.
+	MaxBytes int
+	// Interval uploads a partial object this long after its first output.
+	// It defaults to 5m.
+	Interval time.Duration
+	// PartSize is the multipart upload part size. Objects larger than one
+	// part are uploaded in parts. It defaults to 8MiB.
+	PartSize int64
+}
+
+type s3Entry struct {
+	t time.Time
+	b []byte
+}
+
+type S3[T any] struct {
+	cfg      S3Config[T]
+	client   *s3.Client
+	uploader *manager.Uploader
+	batch    *delivery.Batcher[s3Entry]
+	// id and seq keep the keys of objects from different sinks, and from
+	// one sink, apart.
+	id  string
+	seq *atomic.Uint64
+}
+
+// NewS3 buffers outputs as JSON lines and uploads them as gzipped objects
+// keyed Prefix/<partition>/part-<nanos>-<id>-<seq>.json.gz, where nanos is
+// the time of the object's first output, id is random per sink and seq
+// counts the sink's uploads, so sinks in several processes never overwrite
+// each other's objects. Objects never span partitions. Failures of uploads
+// after Interval are logged. Call Flush before shutting down.
+func NewS3[T any](c *s3.Client, cfg S3Config[T]) S3[T] {
+	if cfg.Layout == "" {
+		cfg.Layout = "2006/01/02/15"
+	}
+	if cfg.MaxBytes == 0 {
+		cfg.MaxBytes = 64 << 20
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = 5 * time.Minute
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = 8 << 20
+	}
+
+	var id [6]byte
+	rand.Read(id[:])
+
+	s := S3[T]{
+		cfg:    cfg,
+		client: c,
+		id:     hex.EncodeToString(id[:]),
+		seq:    new(atomic.Uint64),
+		uploader: manager.NewUploader(c, func(u *manager.Uploader) {
+			u.PartSize = cfg.PartSize
+		}),
+	}
+	s.batch = delivery.New(math.MaxInt, cfg.MaxBytes, cfg.Interval, s.upload, sink.LogError)
+	s.batch.Boundary = func(first, e s3Entry) bool {
+		return first.t.Format(cfg.Layout) != e.t.Format(cfg.Layout)
+	}
+	return s
+}
+
+func (s S3[T]) upload(ctx context.Context, entries []s3Entry) error {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, e := range entries {
+		if _, err := zw.Write(e.b); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	first := entries[0].t
+	key := path.Join(s.cfg.Prefix, first.Format(s.cfg.Layout), fmt.Sprintf("part-%d-%s-%d.json.gz", first.UnixNano(), s.id, s.seq.Add(1)))
+
+	_, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+		Bucket:          &s.cfg.Bucket,
+		Key:             &key,
+		Body:            &buf,
+		ContentType:     aws.String("application/x-ndjson"),
+		ContentEncoding: aws.String("gzip"),
+	})
+	return err
+}
+
+func (s S3[T]) OnSuccess(ctx context.Context, v T) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	t := time.Now()
+	if s.cfg.Time != nil {
+		t = s.cfg.Time(v)
+	}
+	return s.batch.Add(ctx, s3Entry{t.UTC(), append(b, '\n')}, len(b)+1)
+}
+
+func (s S3[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
+
+func (s S3[T]) SettlesOutputs() bool { return true }
+
+// Ping checks that the bucket exists and is accessible.
+func (s S3[T]) Ping(ctx context.Context) error {
+	_, err := s.client.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: &s.cfg.Bucket})
+	return err
+}
+
+// Flush uploads whatever is buffered.
+func (s S3[T]) Flush(ctx context.Context) error {
+	return s.batch.Flush(ctx)
+}
@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/0x180db/go-chord/sink"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/aws-sdk-go-v2/service/sns/types"
+)
+
+type SnsConfig[T any] struct {
+	TopicArn string
+	Subject  func(T) string
+	// Attributes sets string message attributes, typically from event
+	// metadata carried in the context.
+	Attributes func(context.Context, T) map[string]string
+	// GroupID and DeduplicationID are required for FIFO topics, unless
+	// content-based deduplication is enabled.
+	GroupID         func(T) string
+	DeduplicationID func(T) string
+	// Marshal encodes the message. It defaults to JSON.
+	Marshal func(T) ([]byte, error)
+	// BatchSize publishes up to this many messages per request, at most
+	// 10. It defaults to 1, publishing each output on its own.
+	BatchSize int
+	// Linger is how long a partial batch waits for more outputs. It
+	// defaults to 1s.
+	Linger time.Duration
+}
+
+type Sns[T any] struct {
+	*sns.Client
+	cfg   SnsConfig[T]
+	batch *delivery.Batcher[types.PublishBatchRequestEntry]
+}
+
+// NewSns publishes each output to an SNS topic, batching like NewSqs. Call
+// Flush before shutting down.
+func NewSns[T any](c *sns.Client, cfg SnsConfig[T]) Sns[T] {
+	if cfg.Marshal == nil {
+		cfg.Marshal = func(v T) ([]byte, error) { return json.Marshal(v) }
+	}
+	cfg.BatchSize, cfg.Linger = normalizeBatch(cfg.BatchSize, cfg.Linger)
+
+	s := Sns[T]{Client: c, cfg: cfg}
+	s.batch = delivery.New(cfg.BatchSize, awsMaxBatchBytes, cfg.Linger, s.publish, sink.LogError)
+	return s
+}
+
+func (s Sns[T]) publish(ctx context.Context, entries []types.PublishBatchRequestEntry) error {
+	for i := range entries {
+		entries[i].Id = aws.String(strconv.Itoa(i))
+	}
+
+	out, err := s.PublishBatch(ctx, &sns.PublishBatchInput{
+		TopicArn:                   &s.cfg.TopicArn,
+		PublishBatchRequestEntries: entries,
+	})
+	if err != nil {
+		return err
+	}
+	if len(out.Failed) == 0 {
+		return nil
+	}
+	return batchFailure("sns", len(out.Failed),
+		func(i int) string { return aws.ToString(out.Failed[i].Id) },
+		func(i int) string { return aws.ToString(out.Failed[i].Code) },
+		func(i int) string { return aws.ToString(out.Failed[i].Message) },
+	)
+}
+
+func (s Sns[T]) OnSuccess(ctx context.Context, v T) error {
+	b, err := s.cfg.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	e := types.PublishBatchRequestEntry{Message: aws.String(string(b))}
+	size := len(b)
+
+	if s.cfg.Subject != nil {
+		e.Subject = aws.String(s.cfg.Subject(v))
+	}
+	if s.cfg.Attributes != nil {
+		e.MessageAttributes = make(map[string]types.MessageAttributeValue)
+		for k, val := range s.cfg.Attributes(ctx, v) {
+			e.MessageAttributes[k] = types.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(val),
+			}
+			size += len(k) + len(val) + len("String")
+		}
+	}
+	if s.cfg.GroupID != nil {
+		e.MessageGroupId = aws.String(s.cfg.GroupID(v))
+	}
+	if s.cfg.DeduplicationID != nil {
+		e.MessageDeduplicationId = aws.String(s.cfg.DeduplicationID(v))
+	}
+
+	return s.batch.Add(ctx, e, size)
+}
+
+func (s Sns[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
+
+func (s Sns[T]) SettlesOutputs() bool { return true }
+
+// Flush publishes any partial batch.
+func (s Sns[T]) Flush(ctx context.Context) error {
+	return s.batch.Flush(ctx)
+}
@@ -0,0 +1,144 @@
+// Package aws holds sinks for AWS services.
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/0x180db/go-chord/sink"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// awsMaxBatch and awsMaxBatchBytes are the SQS and SNS batch API limits.
+const (
+	awsMaxBatch      = 10
+	awsMaxBatchBytes = 256 << 10
+)
+
+type SqsConfig[T any] struct {
+	QueueURL string
+	// Attributes sets string message attributes, typically from event
+	// metadata carried in the context.
+	Attributes func(context.Context, T) map[string]string
+	// GroupID and DeduplicationID are required for FIFO queues, unless
+	// content-based deduplication is enabled.
+	GroupID         func(T) string
+	DeduplicationID func(T) string
+	// Marshal encodes the message body. It defaults to JSON.
+	Marshal func(T) ([]byte, error)
+	// BatchSize sends up to this many messages per request, at most 10. It
+	// defaults to 1, sending each output on its own.
+	BatchSize int
+	// Linger is how long a partial batch waits for more outputs. It
+	// defaults to 1s.
+	Linger time.Duration
+}
+
+// batchFailure joins the failed entries of a batch request into one error.
+func batchFailure(service string, failed int, id, code, msg func(i int) string) error {
+	errs := make([]error, failed)
+	for i := range errs {
+		errs[i] = fmt.Errorf("%s: entry %s: %s: %s", service, id(i), code(i), msg(i))
+	}
+	return errors.Join(errs...)
+}
+
+func normalizeBatch(size int, linger time.Duration) (int, time.Duration) {
+	if size <= 0 {
+		size = 1
+	}
+	if linger == 0 {
+		linger = time.Second
+	}
+	return min(size, awsMaxBatch), linger
+}
+
+type Sqs[T any] struct {
+	*sqs.Client
+	cfg   SqsConfig[T]
+	batch *delivery.Batcher[types.SendMessageBatchRequestEntry]
+}
+
+// NewSqs sends each output as a message to an SQS queue. With batching,
+// the output that fills a batch reports the whole batch's failure, and
+// failures of batches sent after Linger are logged. Call Flush before
+// shutting down.
+func NewSqs[T any](c *sqs.Client, cfg SqsConfig[T]) Sqs[T] {
+	if cfg.Marshal == nil {
+		cfg.Marshal = func(v T) ([]byte, error) { return json.Marshal(v) }
+	}
+	cfg.BatchSize, cfg.Linger = normalizeBatch(cfg.BatchSize, cfg.Linger)
+
+	s := Sqs[T]{Client: c, cfg: cfg}
+	s.batch = delivery.New(cfg.BatchSize, awsMaxBatchBytes, cfg.Linger, s.send, sink.LogError)
+	return s
+}
+
+func (s Sqs[T]) send(ctx context.Context, entries []types.SendMessageBatchRequestEntry) error {
+	for i := range entries {
+		entries[i].Id = aws.String(strconv.Itoa(i))
+	}
+
+	out, err := s.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+		QueueUrl: &s.cfg.QueueURL,
+		Entries:  entries,
+	})
+	if err != nil {
+		return err
+	}
+	if len(out.Failed) == 0 {
+		return nil
+	}
+	return batchFailure("sqs", len(out.Failed),
+		func(i int) string { return aws.ToString(out.Failed[i].Id) },
+		func(i int) string { return aws.ToString(out.Failed[i].Code) },
+		func(i int) string { return aws.ToString(out.Failed[i].Message) },
+	)
+}
+
+func (s Sqs[T]) OnSuccess(ctx context.Context, v T) error {
+	b, err := s.cfg.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	e := types.SendMessageBatchRequestEntry{MessageBody: aws.String(string(b))}
+	size := len(b)
+
+	if s.cfg.Attributes != nil {
+		e.MessageAttributes = make(map[string]types.MessageAttributeValue)
+		for k, val := range s.cfg.Attributes(ctx, v) {
+			e.MessageAttributes[k] = types.MessageAttributeValue{
+				DataType:    aws.String("String"),
+				StringValue: aws.String(val),
+			}
+			size += len(k) + len(val) + len("String")
+		}
+	}
+	if s.cfg.GroupID != nil {
+		e.MessageGroupId = aws.String(s.cfg.GroupID(v))
+	}
+	if s.cfg.DeduplicationID != nil {
+		e.MessageDeduplicationId = aws.String(s.cfg.DeduplicationID(v))
+	}
+
+	return s.batch.Add(ctx, e, size)
+}
+
+func (s Sqs[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
+
+func (s Sqs[T]) SettlesOutputs() bool { return true }
+
+// Flush sends any partial batch.
+func (s Sqs[T]) Flush(ctx context.Context) error {
+	return s.batch.Flush(ctx)
+}
@@ -0,0 +1,43 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Channel[T any] struct {
+	values chan<- T
+	errs   chan<- error
+}
+
+// NewChannel sends each output on values and each error on errs. Either
+// may be nil to drop what would be sent on it. Sends block until received
+// or the result context is done.
+func NewChannel[T any](values chan<- T, errs chan<- error) chord.Sink[T] {
+	return Channel[T]{values, errs}
+}
+
+func (c Channel[T]) OnSuccess(ctx context.Context, v T) error {
+	if c.values == nil {
+		return nil
+	}
+
+	select {
+	case c.values <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c Channel[T]) OnError(ctx context.Context, err error) {
+	if c.errs == nil {
+		return
+	}
+
+	select {
+	case c.errs <- err:
+	case <-ctx.Done():
+	}
+}
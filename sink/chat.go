@@ -0,0 +1,180 @@
+package sink
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// ChatRoute directs a message, typically from event metadata carried in
+// the context. Zero fields keep the configured webhook's defaults.
+type ChatRoute struct {
+	// URL replaces the configured webhook URL.
+	URL string
+	// Channel overrides the channel of a Slack webhook that allows it.
+	Channel string
+	// Thread replies in a thread: a Slack thread_ts or a Discord thread ID.
+	Thread string
+}
+
+type ChatConfig[T any] struct {
+	URL string
+	// Text is a text/template executed on each output.
+	Text  string
+	Route func(context.Context, T) ChatRoute
+	// Every spaces messages to one webhook URL at least this far apart. It
+	// defaults to what the service allows: 1s for Slack, 400ms for Discord
+	// and 250ms for Teams.
+	Every time.Duration
+	// MaxRetries is how often a rate-limited or failed post is retried. It
+	// defaults to 5.
+	MaxRetries int
+}
+
+type chatService int
+
+const (
+	chatSlack chatService = iota
+	chatDiscord
+	chatTeams
+)
+
+type chatPacers struct {
+	mu     sync.Mutex
+	pacers map[string]*pacer
+}
+
+type Chat[T any] struct {
+	cfg     ChatConfig[T]
+	service chatService
+	text    *template.Template
+	err     error
+	pacers  *chatPacers
+}
+
+func newChat[T any](service chatService, every time.Duration, cfg ChatConfig[T]) chord.Sink[T] {
+	if cfg.Every == 0 {
+		cfg.Every = every
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 5
+	}
+
+	text, err := template.New("text").Parse(cfg.Text)
+	return Chat[T]{cfg, service, text, err, &chatPacers{pacers: make(map[string]*pacer)}}
+}
+
+// NewSlack posts each output to a Slack incoming webhook.
+func NewSlack[T any](cfg ChatConfig[T]) chord.Sink[T] {
+	return newChat(chatSlack, time.Second, cfg)
+}
+
+// NewDiscord posts each output to a Discord webhook. Text longer than
+// Discord's 2000 character limit is truncated.
+func NewDiscord[T any](cfg ChatConfig[T]) chord.Sink[T] {
+	return newChat(chatDiscord, 400*time.Millisecond, cfg)
+}
+
+// NewTeams posts each output as an Adaptive Card to a Microsoft Teams
+// workflow webhook.
+func NewTeams[T any](cfg ChatConfig[T]) chord.Sink[T] {
+	return newChat(chatTeams, 250*time.Millisecond, cfg)
+}
+
+func (c Chat[T]) pacer(url string) *pacer {
+	c.pacers.mu.Lock()
+	defer c.pacers.mu.Unlock()
+
+	p, ok := c.pacers.pacers[url]
+	if !ok {
+		p = &pacer{every: c.cfg.Every}
+		c.pacers.pacers[url] = p
+	}
+	return p
+}
+
+// payload builds the webhook body and URL for the service.
+func (c Chat[T]) payload(text string, route ChatRoute) (string, any, error) {
+	target := c.cfg.URL
+	if route.URL != "" {
+		target = route.URL
+	}
+
+	switch c.service {
+	case chatDiscord:
+		if route.Thread != "" {
+			u, err := url.Parse(target)
+			if err != nil {
+				return "", nil, err
+			}
+			q := u.Query()
+			q.Set("thread_id", route.Thread)
+			u.RawQuery = q.Encode()
+			target = u.String()
+		}
+		if r := []rune(text); len(r) > 2000 {
+			text = string(r[:1999]) + "…"
+		}
+		return target, map[string]any{"content": text}, nil
+	case chatTeams:
+		return target, map[string]any{
+			"type": "message",
+			"attachments": []any{map[string]any{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content": map[string]any{
+					"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+					"type":    "AdaptiveCard",
+					"version": "1.4",
+					"body": []any{map[string]any{
+						"type": "TextBlock",
+						"text": text,
+						"wrap": true,
+					}},
+				},
+			}},
+		}, nil
+	default:
+		p := map[string]any{"text": text}
+		if route.Channel != "" {
+			p["channel"] = route.Channel
+		}
+		if route.Thread != "" {
+			p["thread_ts"] = route.Thread
+		}
+		return target, p, nil
+	}
+}
+
+func (c Chat[T]) OnSuccess(ctx context.Context, v T) error {
+	if c.err != nil {
+		return c.err
+	}
+
+	var text strings.Builder
+	if err := c.text.Execute(&text, v); err != nil {
+		return err
+	}
+
+	var route ChatRoute
+	if c.cfg.Route != nil {
+		route = c.cfg.Route(ctx, v)
+	}
+	target, payload, err := c.payload(text.String(), route)
+	if err != nil {
+		return err
+	}
+
+	if !c.pacer(target).wait(ctx) {
+		return ctx.Err()
+	}
+	return NewHttp[any](HttpConfig{URL: target, MaxRetries: c.cfg.MaxRetries, Backoff: time.Second}).OnSuccess(ctx, payload)
+}
+
+func (c Chat[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
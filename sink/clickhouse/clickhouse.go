@@ -0,0 +1,97 @@
+// Package clickhouse is a sink inserting into ClickHouse tables.
+package clickhouse
+
+import (
+	"context"
+	"time"
+
+	"github.com/0x180db/go-chord/sink"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+type Config[T any] struct {
+	Table string
+	// Row maps an output to column values in table order. Without it,
+	// outputs must be structs whose fields are tagged with `ch:"column"`.
+	Row func(T) []any
+	// BatchSize inserts once this many outputs are buffered. It defaults to
+	// 10000.
+	BatchSize int
+	// Interval inserts a partial batch this long after its first output.
+	// It defaults to 1s.
+	Interval time.Duration
+	// AsyncInsert lets the server buffer inserts, which suits many small
+	// batches. WaitForAsyncInsert waits until the server has flushed them.
+	AsyncInsert        bool
+	WaitForAsyncInsert bool
+}
+
+type Sink[T any] struct {
+	driver.Conn
+	cfg   Config[T]
+	batch *delivery.Batcher[T]
+}
+
+// New inserts outputs into cfg.Table in batches. The output that
+// fills a batch reports its failure; failures of batches inserted after
+// Interval are logged. Call Flush before shutting down.
+func New[T any](conn driver.Conn, cfg Config[T]) Sink[T] {
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 10000
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Second
+	}
+
+	c := Sink[T]{Conn: conn, cfg: cfg}
+	c.batch = delivery.New(cfg.BatchSize, 0, cfg.Interval, c.insert, sink.LogError)
+	return c
+}
+
+func (c Sink[T]) insert(ctx context.Context, rows []T) error {
+	if c.cfg.AsyncInsert {
+		wait := 0
+		if c.cfg.WaitForAsyncInsert {
+			wait = 1
+		}
+		ctx = clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+			"async_insert":          1,
+			"wait_for_async_insert": wait,
+		}))
+	}
+
+	b, err := c.PrepareBatch(ctx, "INSERT INTO "+c.cfg.Table)
+	if err != nil {
+		return err
+	}
+	defer b.Abort()
+
+	for i := range rows {
+		if c.cfg.Row != nil {
+			err = b.Append(c.cfg.Row(rows[i])...)
+		} else {
+			err = b.AppendStruct(&rows[i])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return b.Send()
+}
+
+func (c Sink[T]) OnSuccess(ctx context.Context, v T) error {
+	return c.batch.Add(ctx, v, 0)
+}
+
+func (c Sink[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
+
+func (c Sink[T]) SettlesOutputs() bool { return true }
+
+// Flush inserts whatever is buffered.
+func (c Sink[T]) Flush(ctx context.Context) error {
+	return c.batch.Flush(ctx)
+}
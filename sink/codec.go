@@ -0,0 +1,39 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/0x180db/go-chord"
+)
+
+type encoded[T any] struct {
+	s chord.Sink[[]byte]
+	c chord.Codec[T]
+}
+
+// Encode hands each output to s encoded with c, such as to a Writer on a
+// net.Conn.
+func Encode[T any](s chord.Sink[[]byte], c chord.Codec[T]) chord.Sink[T] {
+	return encoded[T]{s, c}
+}
+
+func (e encoded[T]) OnSuccess(ctx context.Context, v T) error {
+	b, err := e.c.Encode(v)
+	if err != nil {
+		return err
+	}
+	return chord.Deliver(ctx, e.s, b)
+}
+
+func (e encoded[T]) SettlesOutputs() bool { return true }
+
+func (e encoded[T]) OnError(ctx context.Context, err error) {
+	e.s.OnError(ctx, err)
+}
+
+func (e encoded[T]) Ping(ctx context.Context) error {
+	if p, ok := e.s.(chord.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+)
+
+type ElasticsearchConfig[T any] struct {
+	// URL is the cluster address, such as "https://localhost:9200". The
+	// bulk API is shared by Elasticsearch and OpenSearch.
+	URL string
+	// Index is a text/template executed on each output, such as
+	// `logs-{{.Service}}-{{.Time.Format "2006.01.02"}}`.
+	Index string
+	// IndexFunc overrides Index, for names taken from event metadata
+	// carried in the context.
+	IndexFunc func(context.Context, T) string
+	// ID sets document IDs, making retried writes idempotent.
+	ID       func(T) string
+	Username string
+	Password string
+	APIKey   string
+	// BatchSize and MaxBytes bound a bulk request. They default to 500
+	// documents and 5MiB.
+	BatchSize int
+	MaxBytes  int
+	// Linger is how long a partial batch waits for more outputs. It
+	// defaults to 1s.
+	Linger time.Duration
+	// MaxRetries is how often a rejected (429) request or document is
+	// retried with backoff. It defaults to 5.
+	MaxRetries int
+	Client     *http.Client
+}
+
+// BulkItemError is a document the cluster refused.
+type BulkItemError struct {
+	Index  string
+	ID     string
+	Status int
+	Type   string
+	Reason string
+}
+
+func (e *BulkItemError) Error() string {
+	return fmt.Sprintf("elasticsearch: %s/%s: %d %s: %s", e.Index, e.ID, e.Status, e.Type, e.Reason)
+}
+
+type esEntry struct {
+	action []byte
+	doc    []byte
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Index  string `json:"_index"`
+		ID     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+type Elasticsearch[T any] struct {
+	cfg   ElasticsearchConfig[T]
+	index *template.Template
+	err   error
+	batch *delivery.Batcher[esEntry]
+}
+
+// NewElasticsearch indexes outputs with _bulk requests. Documents refused
+// with 429 are retried with backoff; other refused documents are reported
+// as BulkItemErrors, joined into the error of the batch. Failures of
+// batches sent after Linger are logged. Call Flush before shutting down.
+func NewElasticsearch[T any](cfg ElasticsearchConfig[T]) Elasticsearch[T] {
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.MaxBytes == 0 {
+		cfg.MaxBytes = 5 << 20
+	}
+	if cfg.Linger == 0 {
+		cfg.Linger = time.Second
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	index, err := template.New("index").Option("missingkey=error").Parse(cfg.Index)
+	e := Elasticsearch[T]{cfg: cfg, index: index, err: err}
+	e.batch = delivery.New(cfg.BatchSize, cfg.MaxBytes, cfg.Linger, e.bulk, LogError)
+	return e
+}
+
+// post sends one bulk request, returning the entries to retry.
+func (e Elasticsearch[T]) post(ctx context.Context, entries []esEntry) ([]esEntry, error) {
+	var body bytes.Buffer
+	for _, en := range entries {
+		body.Write(en.action)
+		body.WriteByte('\n')
+		body.Write(en.doc)
+		body.WriteByte('\n')
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.cfg.URL+"/_bulk", &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	switch {
+	case e.cfg.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+e.cfg.APIKey)
+	case e.cfg.Username != "":
+		req.SetBasicAuth(e.cfg.Username, e.cfg.Password)
+	}
+
+	resp, err := e.cfg.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		io.Copy(io.Discard, resp.Body)
+		return entries, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return nil, fmt.Errorf("elasticsearch: bulk: %s: %s", resp.Status, msg)
+	}
+
+	var br bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&br); err != nil {
+		return nil, err
+	}
+	if !br.Errors {
+		return nil, nil
+	}
+
+	var retry []esEntry
+	var errs []error
+	for i, item := range br.Items {
+		for _, r := range item {
+			switch {
+			case r.Error == nil:
+			case r.Status == http.StatusTooManyRequests && i < len(entries):
+				retry = append(retry, entries[i])
+			default:
+				errs = append(errs, &BulkItemError{r.Index, r.ID, r.Status, r.Error.Type, r.Error.Reason})
+			}
+		}
+	}
+	return retry, errors.Join(errs...)
+}
+
+func (e Elasticsearch[T]) bulk(ctx context.Context, entries []esEntry) error {
+	var errs []error
+	for attempt := 0; len(entries) > 0; attempt++ {
+		retry, err := e.post(ctx, entries)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if len(retry) == 0 {
+			break
+		}
+		if attempt >= e.cfg.MaxRetries || !delivery.Backoff(ctx, time.Second, attempt) {
+			errs = append(errs, fmt.Errorf("elasticsearch: %d documents rejected after %d attempts", len(retry), attempt+1))
+			break
+		}
+		entries = retry
+	}
+	return errors.Join(errs...)
+}
+
+func (e Elasticsearch[T]) OnSuccess(ctx context.Context, v T) error {
+	if e.err != nil {
+		return e.err
+	}
+
+	var index string
+	if e.cfg.IndexFunc != nil {
+		index = e.cfg.IndexFunc(ctx, v)
+	} else {
+		var b strings.Builder
+		if err := e.index.Execute(&b, v); err != nil {
+			return err
+		}
+		index = b.String()
+	}
+
+	meta := map[string]string{"_index": index}
+	if e.cfg.ID != nil {
+		meta["_id"] = e.cfg.ID(v)
+	}
+	action, err := json.Marshal(map[string]any{"index": meta})
+	if err != nil {
+		return err
+	}
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	return e.batch.Add(ctx, esEntry{action, doc}, len(action)+len(doc)+2)
+}
+
+func (e Elasticsearch[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
+
+func (e Elasticsearch[T]) SettlesOutputs() bool { return true }
+
+// Flush sends whatever is buffered.
+func (e Elasticsearch[T]) Flush(ctx context.Context) error {
+	return e.batch.Flush(ctx)
+}
@@ -0,0 +1,71 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+)
+
+type fileState struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+type File[T any] struct {
+	path   string
+	encode func(T) ([]byte, error)
+	state  *fileState
+}
+
+// NewFile appends each output to the file at path as a line of JSON,
+// creating the file on the first write.
+func NewFile[T any](path string) File[T] {
+	return File[T]{path, func(v T) ([]byte, error) { return json.Marshal(v) }, new(fileState)}
+}
+
+// NewFileCodec is NewFile with each line encoded by c, which must not
+// produce newlines.
+func NewFileCodec[T any](path string, c chord.Codec[T]) File[T] {
+	return File[T]{path, c.Encode, new(fileState)}
+}
+
+func (f File[T]) OnSuccess(_ context.Context, v T) error {
+	b, err := f.encode(v)
+	if err != nil {
+		return err
+	}
+
+	f.state.mu.Lock()
+	defer f.state.mu.Unlock()
+
+	if f.state.f == nil {
+		file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		f.state.f = file
+	}
+
+	_, err = f.state.f.Write(append(b, '\n'))
+	return err
+}
+
+func (f File[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
+
+// Close closes the underlying file, if it was opened.
+func (f File[T]) Close() error {
+	f.state.mu.Lock()
+	defer f.state.mu.Unlock()
+
+	if f.state.f == nil {
+		return nil
+	}
+	err := f.state.f.Close()
+	f.state.f = nil
+	return err
+}
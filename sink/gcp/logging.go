@@ -0,0 +1,76 @@
+// Package gcp holds sinks for Google Cloud services.
+package gcp
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/logging"
+	"github.com/0x180db/go-chord/sink"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+)
+
+type LoggingConfig[T any] struct {
+	LogID string
+	// Severity defaults to logging.Default.
+	Severity func(T) logging.Severity
+	// Labels attaches entry labels, typically from event metadata carried
+	// in the context.
+	Labels func(context.Context, T) map[string]string
+	// Payload is the entry payload. It defaults to the output itself,
+	// which is logged as structured JSON.
+	Payload func(T) any
+	// Linger is how long entries stay buffered before the logger is
+	// flushed and their events acked. It defaults to 5s.
+	Linger time.Duration
+}
+
+type Logging[T any] struct {
+	*logging.Logger
+	cfg     LoggingConfig[T]
+	pending *delivery.Batcher[struct{}]
+}
+
+// NewLogging writes outputs as Cloud Logging entries. The client
+// batches entries and handles quota retries in the background, reporting
+// failures to its OnError. The events of outputs are acked once a flush
+// of the logger after Linger, or after 1000 entries, succeeds, and nacked
+// if it reports errors; call Flush before shutting down.
+func NewLogging[T any](c *logging.Client, cfg LoggingConfig[T]) Logging[T] {
+	if cfg.Payload == nil {
+		cfg.Payload = func(v T) any { return v }
+	}
+	if cfg.Linger == 0 {
+		cfg.Linger = 5 * time.Second
+	}
+
+	g := Logging[T]{Logger: c.Logger(cfg.LogID), cfg: cfg}
+	g.pending = delivery.New(1000, 0, cfg.Linger, func(context.Context, []struct{}) error {
+		return g.Logger.Flush()
+	}, sink.LogError)
+	return g
+}
+
+func (g Logging[T]) OnSuccess(ctx context.Context, v T) error {
+	e := logging.Entry{Payload: g.cfg.Payload(v)}
+	if g.cfg.Severity != nil {
+		e.Severity = g.cfg.Severity(v)
+	}
+	if g.cfg.Labels != nil {
+		e.Labels = g.cfg.Labels(ctx, v)
+	}
+
+	g.Log(e)
+	return g.pending.Add(ctx, struct{}{}, 0)
+}
+
+func (g Logging[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
+
+func (g Logging[T]) SettlesOutputs() bool { return true }
+
+// Flush sends whatever the client has buffered.
+func (g Logging[T]) Flush(ctx context.Context) error {
+	return g.pending.Flush(ctx)
+}
@@ -0,0 +1,156 @@
+// Package grpc is a sink calling gRPC methods.
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord/sink"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type Config[T any] struct {
+	// Method is the full method name, such as "/pkg.Service/Method".
+	Method string
+	// Request maps an output to the request message. It defaults to the
+	// output itself, which must then be a message.
+	Request func(T) any
+	// Reply allocates the response message. It defaults to emptypb.Empty,
+	// which discards the response.
+	Reply func() any
+	// Stream sends outputs over one client stream instead of a call each.
+	Stream bool
+	// Timeout bounds each call. It defaults to 10s and does not apply to
+	// streams.
+	Timeout time.Duration
+	// MaxRetries is how often a call or send failing with UNAVAILABLE is
+	// retried with backoff. It defaults to 3.
+	MaxRetries int
+}
+
+type grpcStream struct {
+	mu     sync.Mutex
+	s      grpc.ClientStream
+	cancel context.CancelFunc
+}
+
+type Sink[T any] struct {
+	grpc.ClientConnInterface
+	cfg    Config[T]
+	stream *grpcStream
+}
+
+// New calls cfg.Method for each output over cc, which handles
+// connecting and reconnecting. In stream mode a failed stream is reopened
+// on the next output; outputs sent on it before the failure are not
+// resent. Call Close to finish the stream and receive its reply.
+func New[T any](cc grpc.ClientConnInterface, cfg Config[T]) Sink[T] {
+	if cfg.Request == nil {
+		cfg.Request = func(v T) any { return v }
+	}
+	if cfg.Reply == nil {
+		cfg.Reply = func() any { return new(emptypb.Empty) }
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	return Sink[T]{cc, cfg, new(grpcStream)}
+}
+
+func (g Sink[T]) invoke(ctx context.Context, req any) error {
+	ctx, cancel := context.WithTimeout(ctx, g.cfg.Timeout)
+	defer cancel()
+
+	return g.Invoke(ctx, g.cfg.Method, req, g.cfg.Reply())
+}
+
+// send sends req on the stream, opening it if needed. A failed stream is
+// dropped and its status returned. The stream carries the values of ctx,
+// such as outgoing metadata, but outlives it so Close can end it after the
+// run.
+func (g Sink[T]) send(ctx context.Context, req any) error {
+	g.stream.mu.Lock()
+	defer g.stream.mu.Unlock()
+
+	if g.stream.s == nil {
+		ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		s, err := g.NewStream(ctx, &grpc.StreamDesc{ClientStreams: true}, g.cfg.Method)
+		if err != nil {
+			cancel()
+			return err
+		}
+		g.stream.s, g.stream.cancel = s, cancel
+	}
+
+	err := g.stream.s.SendMsg(req)
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, io.EOF) {
+		// The stream was closed by the server; its status says why.
+		if rerr := g.stream.s.RecvMsg(g.cfg.Reply()); rerr != nil {
+			err = rerr
+		}
+	}
+	g.stream.cancel()
+	g.stream.s = nil
+	return err
+}
+
+func (g Sink[T]) OnSuccess(ctx context.Context, v T) error {
+	req := g.cfg.Request(v)
+
+	for attempt := 0; ; attempt++ {
+		var err error
+		if g.cfg.Stream {
+			err = g.send(ctx, req)
+		} else {
+			err = g.invoke(ctx, req)
+		}
+
+		if err == nil || status.Code(err) != codes.Unavailable || attempt >= g.cfg.MaxRetries {
+			return err
+		}
+		if !delivery.Backoff(ctx, 100*time.Millisecond, attempt) {
+			return err
+		}
+	}
+}
+
+func (g Sink[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
+
+// Close finishes the client stream, if one is open, and returns its
+// reply.
+func (g Sink[T]) Close() (any, error) {
+	g.stream.mu.Lock()
+	defer g.stream.mu.Unlock()
+
+	if g.stream.s == nil {
+		return nil, nil
+	}
+	defer func() {
+		g.stream.cancel()
+		g.stream.s = nil
+	}()
+
+	if err := g.stream.s.CloseSend(); err != nil {
+		return nil, err
+	}
+	reply := g.cfg.Reply()
+	if err := g.stream.s.RecvMsg(reply); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
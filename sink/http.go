@@ -0,0 +1,176 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+)
+
+type HttpConfig struct {
+	URL    string
+	Header http.Header
+	// BearerToken, or Username and Password, authenticate each request.
+	BearerToken string
+	Username    string
+	Password    string
+	// Timeout bounds each attempt. It defaults to 10s.
+	Timeout time.Duration
+	// MaxRetries is how often 5xx, 429 and network failures are retried.
+	MaxRetries int
+	// Backoff is the delay before the first retry, doubling after that.
+	// It defaults to 200ms.
+	Backoff time.Duration
+	Client  *http.Client
+}
+
+// HttpError is returned for a response that will not succeed on retry,
+// or the last response once retries are exhausted.
+type HttpError struct {
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *HttpError) Error() string {
+	return fmt.Sprintf("sink: http: %s: %s", e.Status, e.Body)
+}
+
+type Http[T any] struct {
+	cfg HttpConfig
+}
+
+// NewHttp POSTs each output as a JSON body. Server errors, rate limiting
+// and network failures are retried with backoff; other non-2xx responses
+// fail the output immediately.
+func NewHttp[T any](cfg HttpConfig) chord.Sink[T] {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Backoff == 0 {
+		cfg.Backoff = 200 * time.Millisecond
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return Http[T]{cfg}
+}
+
+func httpRetryable(err error) bool {
+	var he *HttpError
+	if errors.As(err, &he) {
+		return he.StatusCode >= 500 || he.StatusCode == http.StatusTooManyRequests
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryAfter reads a Retry-After header given in seconds.
+func retryAfter(resp *http.Response) time.Duration {
+	s, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || s < 0 {
+		return 0
+	}
+	return time.Duration(s) * time.Second
+}
+
+func (h Http[T]) request(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, h.cfg.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range h.cfg.Header {
+		req.Header[k] = vs
+	}
+
+	switch {
+	case h.cfg.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+h.cfg.BearerToken)
+	case h.cfg.Username != "":
+		req.SetBasicAuth(h.cfg.Username, h.cfg.Password)
+	}
+	return req, nil
+}
+
+func (h Http[T]) post(ctx context.Context, body []byte) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+	defer cancel()
+
+	req, err := h.request(ctx, http.MethodPost, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := h.cfg.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return retryAfter(resp), &HttpError{resp.StatusCode, resp.Status, string(msg)}
+	}
+	return 0, nil
+}
+
+func (h Http[T]) OnSuccess(ctx context.Context, v T) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; ; attempt++ {
+		wait, err := h.post(ctx, b)
+		if err == nil || attempt >= h.cfg.MaxRetries || !httpRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		if wait > 0 {
+			if !delivery.Sleep(ctx, wait) {
+				return err
+			}
+		} else if !delivery.Backoff(ctx, h.cfg.Backoff, attempt) {
+			return err
+		}
+	}
+}
+
+// Ping sends a HEAD request to URL. Any response but 401 and 403 counts,
+// since the endpoint need not accept HEAD.
+func (h Http[T]) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+	defer cancel()
+
+	req, err := h.request(ctx, http.MethodHead, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return &HttpError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
+
+func (h Http[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
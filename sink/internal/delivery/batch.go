@@ -0,0 +1,116 @@
+package delivery
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Batcher collects entries until maxItems or maxBytes would be exceeded,
+// linger passes after the first entry or an entry starts a new batch by
+// Boundary. A flush triggered by Add runs on the caller, and Add returns
+// its error only if the batch holds the added entry; the errors of earlier
+// batches, flushed before the entry is added or after linger, go to
+// onError. Once a batch is flushed the events of its entries are acked, or
+// nacked if the flush failed, so sinks using it are chord.SelfSettling.
+type Batcher[E any] struct {
+	mu       sync.Mutex
+	entries  []E
+	ctxs     []context.Context
+	bytes    int
+	maxItems int
+	maxBytes int
+	linger   time.Duration
+	// Boundary, when set, flushes before an entry for which
+	// Boundary(first, e) is true.
+	Boundary func(first, e E) bool
+	// stop ends the wait for linger, nil when no entries wait.
+	stop    chan struct{}
+	flush   func(context.Context, []E) error
+	onError func(context.Context, error)
+}
+
+func New[E any](maxItems, maxBytes int, linger time.Duration, flush func(context.Context, []E) error, onError func(context.Context, error)) *Batcher[E] {
+	return &Batcher[E]{
+		maxItems: maxItems,
+		maxBytes: maxBytes,
+		linger:   linger,
+		flush:    flush,
+		onError:  onError,
+	}
+}
+
+// Add buffers e, of the given size, with the event of ctx.
+func (b *Batcher[E]) Add(ctx context.Context, e E, size int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	full := b.maxBytes > 0 && b.bytes+size > b.maxBytes
+	if len(b.entries) > 0 && (full || b.Boundary != nil && b.Boundary(b.entries[0], e)) {
+		// The failed batch is nacked already; e is not part of it.
+		if err := b.flushLocked(ctx); err != nil {
+			b.onError(ctx, err)
+		}
+	}
+
+	b.entries = append(b.entries, e)
+	b.ctxs = append(b.ctxs, ctx)
+	b.bytes += size
+
+	if len(b.entries) >= b.maxItems {
+		return b.flushLocked(ctx)
+	}
+
+	if b.stop == nil {
+		lctx := context.WithoutCancel(ctx)
+		timer := chord.ClockFrom(ctx).NewTimer(b.linger)
+		stop := make(chan struct{})
+		b.stop = stop
+
+		go func() {
+			defer timer.Stop()
+			select {
+			case <-timer.C():
+			case <-stop:
+				return
+			}
+			if err := b.Flush(lctx); err != nil {
+				b.onError(lctx, err)
+			}
+		}()
+	}
+	return nil
+}
+
+func (b *Batcher[E]) flushLocked(ctx context.Context) error {
+	if b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+	if len(b.entries) == 0 {
+		return nil
+	}
+
+	entries, ctxs := b.entries, b.ctxs
+	b.entries, b.ctxs, b.bytes = nil, nil, 0
+
+	err := b.flush(ctx, entries)
+	for _, c := range ctxs {
+		if err == nil {
+			chord.Ack(c)
+		} else {
+			chord.Nack(c)
+		}
+	}
+	return err
+}
+
+// Flush sends whatever is buffered.
+func (b *Batcher[E]) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.flushLocked(ctx)
+}
@@ -0,0 +1,34 @@
+// Package delivery holds what the sinks in package sink and its
+// subpackages share: batching and retry backoff.
+package delivery
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Backoff waits before the given retry attempt, doubling from base with
+// jitter up to 30s. It reports false if ctx is done first.
+func Backoff(ctx context.Context, base time.Duration, attempt int) bool {
+	d := min(base<<min(attempt, 16), 30*time.Second)
+	d = d/2 + rand.N(d/2+1)
+
+	return Sleep(ctx, d)
+}
+
+// Sleep waits for d on the clock of ctx, reporting false if ctx is done
+// first.
+func Sleep(ctx context.Context, d time.Duration) bool {
+	t := chord.ClockFrom(ctx).NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C():
+		return true
+	}
+}
@@ -0,0 +1,43 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Json[T any] struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+// NewJson writes each output to w as a line of JSON.
+func NewJson[T any](w io.Writer) chord.Sink[T] {
+	return Json[T]{new(sync.Mutex), w}
+}
+
+// NewStdout writes each output to standard output as a line of JSON.
+func NewStdout[T any]() chord.Sink[T] {
+	return NewJson[T](os.Stdout)
+}
+
+func (j Json[T]) OnSuccess(_ context.Context, v T) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	_, err = j.w.Write(append(b, '\n'))
+	return err
+}
+
+func (j Json[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
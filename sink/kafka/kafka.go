@@ -0,0 +1,112 @@
+// Package kafka is a sink producing to Kafka topics with franz-go.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/sink"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+type Config[T any] struct {
+	Topic string
+	// Key selects the record key, which the client's partitioner uses to
+	// pick a partition.
+	Key func(T) []byte
+	// Partition pins a record to a partition. It only takes effect when the
+	// client is built with kgo.RecordPartitioner(kgo.ManualPartitioner()).
+	Partition func(T) int32
+	// Headers adds record headers, typically from event metadata carried in
+	// the context.
+	Headers func(context.Context, T) map[string]string
+	// Marshal encodes the record value. It defaults to Codec's Encode,
+	// then to JSON.
+	Marshal func(T) ([]byte, error)
+	Codec   chord.Codec[T]
+	// Async hands records to the client's batching producer without waiting
+	// for their delivery report. An output's event is acked or nacked once
+	// its report arrives. Reports are passed to OnDelivery; without it
+	// failed deliveries are logged. Call Flush before shutting down.
+	Async      bool
+	OnDelivery func(context.Context, *kgo.Record, error)
+}
+
+type Sink[T any] struct {
+	*kgo.Client
+	cfg Config[T]
+}
+
+// New produces each output as a record on cfg.Topic. By default
+// OnSuccess waits for the broker to acknowledge the record, so delivery
+// failures go to the flow's error path.
+func New[T any](c *kgo.Client, cfg Config[T]) Sink[T] {
+	if cfg.Marshal == nil && cfg.Codec != nil {
+		cfg.Marshal = cfg.Codec.Encode
+	}
+	if cfg.Marshal == nil {
+		cfg.Marshal = func(v T) ([]byte, error) { return json.Marshal(v) }
+	}
+	return Sink[T]{c, cfg}
+}
+
+func (k Sink[T]) record(ctx context.Context, v T) (*kgo.Record, error) {
+	b, err := k.cfg.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &kgo.Record{Topic: k.cfg.Topic, Value: b}
+	if k.cfg.Key != nil {
+		r.Key = k.cfg.Key(v)
+	}
+	if k.cfg.Partition != nil {
+		r.Partition = k.cfg.Partition(v)
+	}
+	if k.cfg.Headers != nil {
+		for key, val := range k.cfg.Headers(ctx, v) {
+			r.Headers = append(r.Headers, kgo.RecordHeader{Key: key, Value: []byte(val)})
+		}
+	}
+	return r, nil
+}
+
+func (k Sink[T]) OnSuccess(ctx context.Context, v T) error {
+	r, err := k.record(ctx, v)
+	if err != nil {
+		return err
+	}
+
+	if !k.cfg.Async {
+		return k.ProduceSync(ctx, r).FirstErr()
+	}
+
+	// The record outlives the result, so it must not be canceled with it.
+	pctx := context.WithoutCancel(ctx)
+	k.Produce(pctx, r, func(r *kgo.Record, err error) {
+		switch {
+		case k.cfg.OnDelivery != nil:
+			k.cfg.OnDelivery(pctx, r, err)
+		case err != nil:
+			sink.LogError(pctx, err)
+		}
+		if err != nil {
+			chord.Nack(pctx)
+		} else {
+			chord.Ack(pctx)
+		}
+	})
+	return nil
+}
+
+func (k Sink[T]) SettlesOutputs() bool { return k.cfg.Async }
+
+func (k Sink[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
+
+// Flush waits until all buffered records are delivered or ctx is done.
+func (k Sink[T]) Flush(ctx context.Context) error {
+	return k.Client.Flush(ctx)
+}
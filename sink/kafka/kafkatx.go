@@ -0,0 +1,77 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+var errTxEnded = errors.New("kafka: transaction already ended")
+
+// Transaction is the chord.Transaction of TxSink. A chord.TxAcker
+// can add consumer offsets to it through Client.
+type Transaction struct {
+	Client *kgo.Client
+	end    func(context.Context, kgo.TransactionEndTry) error
+}
+
+func (t Transaction) KafkaClient() *kgo.Client { return t.Client }
+
+func (t Transaction) Commit(ctx context.Context) error { return t.end(ctx, kgo.TryCommit) }
+func (t Transaction) Abort(ctx context.Context) error  { return t.end(ctx, kgo.TryAbort) }
+
+type TxSink[T any] struct {
+	Sink[T]
+	mu *sync.Mutex
+}
+
+// NewTx produces each output in a Kafka transaction of its own, which
+// RunFlow commits together with the event's acknowledgment (see
+// chord.TwoPhaseSink), so consumers reading committed records see every
+// output once. The client must be built with kgo.TransactionalID; its
+// transactions run one at a time. Async is ignored.
+func NewTx[T any](c *kgo.Client, cfg Config[T]) TxSink[T] {
+	cfg.Async = false
+	return TxSink[T]{New(c, cfg), new(sync.Mutex)}
+}
+
+func (k TxSink[T]) Prepare(ctx context.Context, v T) (chord.Transaction, error) {
+	r, err := k.record(ctx, v)
+	if err != nil {
+		return nil, err
+	}
+
+	k.mu.Lock()
+	var once sync.Once
+	end := func(ctx context.Context, try kgo.TransactionEndTry) error {
+		err := errTxEnded
+		once.Do(func() {
+			err = k.EndTransaction(ctx, try)
+			k.mu.Unlock()
+		})
+		return err
+	}
+
+	if err := k.BeginTransaction(); err != nil {
+		once.Do(k.mu.Unlock)
+		return nil, err
+	}
+	if err := k.ProduceSync(ctx, r).FirstErr(); err != nil {
+		end(context.WithoutCancel(ctx), kgo.TryAbort)
+		return nil, err
+	}
+	return Transaction{k.Client, end}, nil
+}
+
+// OnSuccess produces v in a transaction committed right away, for use
+// outside RunFlow.
+func (k TxSink[T]) OnSuccess(ctx context.Context, v T) error {
+	tx, err := k.Prepare(ctx, v)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,74 @@
+// Package mqtt is a sink publishing to MQTT topics with paho.
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"text/template"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/sink"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+type Config[T any] struct {
+	// Topic is a text/template executed on each output, such as
+	// "devices/{{.DeviceID}}/state".
+	Topic    string
+	QoS      byte
+	Retained bool
+	// Marshal encodes the payload. It defaults to Codec's Encode, then to
+	// JSON.
+	Marshal func(T) ([]byte, error)
+	Codec   chord.Codec[T]
+}
+
+type Sink[T any] struct {
+	mqtt.Client
+	cfg   Config[T]
+	topic *template.Template
+	err   error
+}
+
+// New publishes each output with a connected client. OnSuccess waits
+// for the publish to complete, which for QoS 1 and 2 means the broker has
+// acknowledged it.
+func New[T any](c mqtt.Client, cfg Config[T]) chord.Sink[T] {
+	if cfg.Marshal == nil && cfg.Codec != nil {
+		cfg.Marshal = cfg.Codec.Encode
+	}
+	if cfg.Marshal == nil {
+		cfg.Marshal = func(v T) ([]byte, error) { return json.Marshal(v) }
+	}
+
+	topic, err := template.New("topic").Option("missingkey=error").Parse(cfg.Topic)
+	return Sink[T]{c, cfg, topic, err}
+}
+
+func (m Sink[T]) OnSuccess(ctx context.Context, v T) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	var topic strings.Builder
+	if err := m.topic.Execute(&topic, v); err != nil {
+		return err
+	}
+	b, err := m.cfg.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tok := m.Publish(topic.String(), m.cfg.QoS, m.cfg.Retained, b)
+	select {
+	case <-tok.Done():
+		return tok.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m Sink[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
@@ -0,0 +1,139 @@
+package sink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+)
+
+// ErrCircuitOpen is reported for a sink skipped after repeated failures.
+var ErrCircuitOpen = errors.New("sink: circuit open")
+
+type MultiConfig struct {
+	// Timeout bounds each delivery attempt to one sink. It defaults to 30s.
+	Timeout time.Duration
+	// MaxRetries is how often a failed delivery to one sink is retried. It
+	// defaults to 2.
+	MaxRetries int
+	// Threshold consecutive failures open a sink's circuit for Cooldown,
+	// during which it is skipped. They default to 5 and 30s.
+	Threshold int
+	Cooldown  time.Duration
+}
+
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !time.Now().Before(b.openUntil)
+}
+
+func (b *breaker) record(err error, threshold int, cooldown time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		return
+	}
+	if b.failures++; b.failures >= threshold {
+		b.openUntil = time.Now().Add(cooldown)
+	}
+}
+
+type multi[T any] struct {
+	cfg      MultiConfig
+	sinks    []chord.Sink[T]
+	breakers []*breaker
+}
+
+// NewMulti writes each output to all sinks concurrently. Each sink is
+// retried and circuit-broken on its own, so a failing or slow sink only
+// delays its own delivery. OnSuccess returns the failures of all sinks
+// joined; errors are passed to every sink's OnError. An output is nacked if
+// any sink fails, and its redelivery goes to every sink again, so the sinks
+// must be idempotent.
+func NewMulti[T any](cfg MultiConfig, sinks ...chord.Sink[T]) chord.Sink[T] {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+	if cfg.Threshold == 0 {
+		cfg.Threshold = 5
+	}
+	if cfg.Cooldown == 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+
+	breakers := make([]*breaker, len(sinks))
+	for i := range breakers {
+		breakers[i] = new(breaker)
+	}
+	return multi[T]{cfg, sinks, breakers}
+}
+
+// Multi is NewMulti with the default MultiConfig.
+func Multi[T any](sinks ...chord.Sink[T]) chord.Sink[T] {
+	return NewMulti(MultiConfig{}, sinks...)
+}
+
+func (m multi[T]) deliver(ctx context.Context, i int, v T) error {
+	b := m.breakers[i]
+	if !b.allow() {
+		return ErrCircuitOpen
+	}
+
+	for attempt := 0; ; attempt++ {
+		actx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+		err := chord.Deliver(actx, m.sinks[i], v)
+		cancel()
+
+		b.record(err, m.cfg.Threshold, m.cfg.Cooldown)
+		if err == nil || attempt >= m.cfg.MaxRetries || !b.allow() || !delivery.Backoff(ctx, 100*time.Millisecond, attempt) {
+			return err
+		}
+	}
+}
+
+func (m multi[T]) OnSuccess(ctx context.Context, v T) error {
+	if len(m.sinks) == 0 {
+		chord.Ack(ctx)
+		return nil
+	}
+
+	errs := make([]error, len(m.sinks))
+	ctxs := chord.ForkAcks(ctx, len(m.sinks))
+
+	var wg sync.WaitGroup
+	for i := range m.sinks {
+		wg.Go(func() {
+			if err := m.deliver(ctxs[i], i, v); err != nil {
+				errs[i] = fmt.Errorf("sink %d: %w", i, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func (m multi[T]) SettlesOutputs() bool { return true }
+
+func (m multi[T]) OnError(ctx context.Context, err error) {
+	for _, s := range m.sinks {
+		s.OnError(ctx, err)
+	}
+}
@@ -0,0 +1,88 @@
+// Package nats is a sink publishing to NATS subjects.
+package nats
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/sink"
+	"github.com/nats-io/nats.go"
+)
+
+type natsReplyKey struct{}
+
+// WithReply records the reply subject of the event a result came from,
+// so a Nats sink answers the request.
+func WithReply(ctx context.Context, reply string) context.Context {
+	return context.WithValue(ctx, natsReplyKey{}, reply)
+}
+
+func natsReply(ctx context.Context) string {
+	reply, _ := ctx.Value(natsReplyKey{}).(string)
+	return reply
+}
+
+type Config[T any] struct {
+	// Subject is published to for every output. It may be empty when the
+	// sink only answers requests.
+	Subject string
+	// Headers adds message headers, typically from event metadata carried
+	// in the context.
+	Headers func(context.Context, T) nats.Header
+	// Marshal encodes the message data. It defaults to JSON.
+	Marshal func(T) ([]byte, error)
+}
+
+type Sink[T any] struct {
+	*nats.Conn
+	cfg Config[T]
+}
+
+// New publishes each output to cfg.Subject and, when the event it came
+// from carries a reply subject, responds on it. Errors are sent to the
+// requester with a Nats-Service-Error header, so it does not wait for a
+// response that never comes.
+func New[T any](nc *nats.Conn, cfg Config[T]) chord.Sink[T] {
+	if cfg.Marshal == nil {
+		cfg.Marshal = func(v T) ([]byte, error) { return json.Marshal(v) }
+	}
+	return Sink[T]{nc, cfg}
+}
+
+func (n Sink[T]) OnSuccess(ctx context.Context, v T) error {
+	b, err := n.cfg.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var h nats.Header
+	if n.cfg.Headers != nil {
+		h = n.cfg.Headers(ctx, v)
+	}
+
+	if n.cfg.Subject != "" {
+		if err := n.PublishMsg(&nats.Msg{Subject: n.cfg.Subject, Data: b, Header: h}); err != nil {
+			return err
+		}
+	}
+	if reply := natsReply(ctx); reply != "" {
+		return n.PublishMsg(&nats.Msg{Subject: reply, Data: b, Header: h})
+	}
+	return nil
+}
+
+func (n Sink[T]) OnError(ctx context.Context, err error) {
+	reply := natsReply(ctx)
+	if reply == "" {
+		sink.LogError(ctx, err)
+		return
+	}
+
+	msg := nats.NewMsg(reply)
+	msg.Header.Set("Nats-Service-Error", err.Error())
+	msg.Header.Set("Nats-Service-Error-Code", "500")
+	if err := n.PublishMsg(msg); err != nil {
+		sink.LogError(ctx, err)
+	}
+}
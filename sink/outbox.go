@@ -0,0 +1,88 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+type OutboxConfig[T any] struct {
+	// Table is used as written and needs these columns, which
+	// trigger.NewOutbox reads back:
+	//
+	//	id         bigserial primary key
+	//	topic      text
+	//	key        text
+	//	payload    bytea
+	//	created_at timestamptz default now()
+	Table string
+	// Topic and Key are stored with each row for the relay to route by.
+	Topic func(T) string
+	Key   func(T) string
+	// Marshal encodes the payload. It defaults to JSON.
+	Marshal func(T) ([]byte, error)
+}
+
+type Outbox[T any] struct {
+	*sql.DB
+	cfg OutboxConfig[T]
+}
+
+// NewOutbox records messages in an outbox table for trigger.NewOutbox to
+// publish, so a message is sent if and only if the transaction that
+// produced it commits. Call Write with that transaction, such as the one a
+// stage.InTx function gets from chord.TxFrom:
+//
+//	stage.InTx(s, db, nil, func(ctx context.Context, o Order) (Order, error) {
+//		tx, _ := chord.TxFrom(ctx)
+//		if _, err := tx.ExecContext(ctx, "UPDATE orders ...", o.ID); err != nil {
+//			return o, err
+//		}
+//		return o, outbox.Write(ctx, tx, o)
+//	})
+//
+// Used as a sink, OnSuccess inserts each output on its own, after the
+// stages' transactions have committed.
+func NewOutbox[T any](db *sql.DB, cfg OutboxConfig[T]) Outbox[T] {
+	if cfg.Marshal == nil {
+		cfg.Marshal = func(v T) ([]byte, error) { return json.Marshal(v) }
+	}
+	return Outbox[T]{db, cfg}
+}
+
+type execer interface {
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+}
+
+func (o Outbox[T]) insert(ctx context.Context, db execer, v T) error {
+	b, err := o.cfg.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var topic, key string
+	if o.cfg.Topic != nil {
+		topic = o.cfg.Topic(v)
+	}
+	if o.cfg.Key != nil {
+		key = o.cfg.Key(v)
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO "+o.cfg.Table+" (topic, key, payload) VALUES ($1, $2, $3)", topic, key, b)
+	return err
+}
+
+// Write records v within tx, alongside the changes it describes.
+func (o Outbox[T]) Write(ctx context.Context, tx *sql.Tx, v T) error {
+	return o.insert(ctx, tx, v)
+}
+
+func (o Outbox[T]) OnSuccess(ctx context.Context, v T) error {
+	return o.insert(ctx, o.DB, v)
+}
+
+func (o Outbox[T]) Ping(ctx context.Context) error { return o.PingContext(ctx) }
+
+func (o Outbox[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
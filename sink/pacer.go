@@ -0,0 +1,34 @@
+package sink
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+)
+
+// pacer spaces calls to wait at least every apart.
+type pacer struct {
+	mu    sync.Mutex
+	next  time.Time
+	every time.Duration
+}
+
+func (p *pacer) wait(ctx context.Context) bool {
+	if p.every <= 0 {
+		return true
+	}
+
+	p.mu.Lock()
+	now := chord.ClockFrom(ctx).Now()
+	at := now
+	if p.next.After(now) {
+		at = p.next
+	}
+	p.next = at.Add(p.every)
+	p.mu.Unlock()
+
+	return delivery.Sleep(ctx, at.Sub(now))
+}
@@ -0,0 +1,149 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+)
+
+// postgresMaxParams is the number of bind parameters a statement may have.
+const postgresMaxParams = 65535
+
+type PostgresConfig[T any] struct {
+	// Table is used as written, so it may be schema-qualified.
+	Table   string
+	Columns []string
+	// Row maps an output to values for Columns.
+	Row func(T) []any
+	// Conflict is the conflict target of an upsert. Update lists the
+	// columns then set from the new row; without it conflicting rows are
+	// skipped.
+	Conflict []string
+	Update   []string
+	// BatchSize inserts once this many outputs are buffered. It defaults to
+	// 500.
+	BatchSize int
+	// Interval inserts a partial batch this long after its first output.
+	// It defaults to 1s.
+	Interval time.Duration
+}
+
+type Postgres[T any] struct {
+	*sql.DB
+	cfg   PostgresConfig[T]
+	batch *delivery.Batcher[[]any]
+}
+
+// NewPostgres inserts outputs into cfg.Table with multi-row INSERT
+// statements, one transaction per batch. The output that fills a batch
+// reports its failure; failures of batches inserted after Interval are
+// logged. Call Flush before shutting down.
+func NewPostgres[T any](db *sql.DB, cfg PostgresConfig[T]) Postgres[T] {
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Second
+	}
+
+	p := Postgres[T]{DB: db, cfg: cfg}
+	p.batch = delivery.New(cfg.BatchSize, 0, cfg.Interval, p.insert, LogError)
+	return p
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+func quoteIdents(ss []string) string {
+	q := make([]string, len(ss))
+	for i, s := range ss {
+		q[i] = quoteIdent(s)
+	}
+	return strings.Join(q, ", ")
+}
+
+// statement builds an INSERT of n rows.
+func (p Postgres[T]) statement(n int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT INTO %s (%s) VALUES ", p.cfg.Table, quoteIdents(p.cfg.Columns))
+
+	param := 1
+	for i := range n {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j := range p.cfg.Columns {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString("$" + strconv.Itoa(param))
+			param++
+		}
+		b.WriteByte(')')
+	}
+
+	if len(p.cfg.Conflict) > 0 {
+		fmt.Fprintf(&b, " ON CONFLICT (%s) ", quoteIdents(p.cfg.Conflict))
+		if len(p.cfg.Update) == 0 {
+			b.WriteString("DO NOTHING")
+		} else {
+			set := make([]string, len(p.cfg.Update))
+			for i, c := range p.cfg.Update {
+				set[i] = quoteIdent(c) + " = EXCLUDED." + quoteIdent(c)
+			}
+			b.WriteString("DO UPDATE SET " + strings.Join(set, ", "))
+		}
+	}
+	return b.String()
+}
+
+func (p Postgres[T]) insert(ctx context.Context, rows [][]any) error {
+	tx, err := p.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	per := max(postgresMaxParams/max(len(p.cfg.Columns), 1), 1)
+	for len(rows) > 0 {
+		n := min(len(rows), per)
+
+		args := make([]any, 0, n*len(p.cfg.Columns))
+		for _, row := range rows[:n] {
+			args = append(args, row...)
+		}
+		if _, err := tx.ExecContext(ctx, p.statement(n), args...); err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return tx.Commit()
+}
+
+func (p Postgres[T]) OnSuccess(ctx context.Context, v T) error {
+	row := p.cfg.Row(v)
+	if len(row) != len(p.cfg.Columns) {
+		return fmt.Errorf("postgres: row has %d values for %d columns", len(row), len(p.cfg.Columns))
+	}
+	return p.batch.Add(ctx, row, 0)
+}
+
+func (p Postgres[T]) Ping(ctx context.Context) error { return p.PingContext(ctx) }
+
+func (p Postgres[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
+
+func (p Postgres[T]) SettlesOutputs() bool { return true }
+
+// Flush inserts whatever is buffered.
+func (p Postgres[T]) Flush(ctx context.Context) error {
+	return p.batch.Flush(ctx)
+}
@@ -0,0 +1,239 @@
+// Package prometheus holds sinks sending metric samples to Prometheus.
+package prometheus
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/sink"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+)
+
+// Sample is one metric value derived from an output.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+	// Time defaults to when the sample reaches the sink. Pushgateway
+	// ignores it.
+	Time time.Time
+}
+
+type Config[T any] struct {
+	URL string
+	// Samples derives the samples of an output. It is required.
+	Samples func(T) []Sample
+	Header  http.Header
+	// BatchSize sends once this many samples are buffered. It defaults to
+	// 500.
+	BatchSize int
+	// Linger is how long a partial batch waits for more samples. It
+	// defaults to 1s.
+	Linger time.Duration
+}
+
+type prometheusMode int
+
+const (
+	prometheusRemoteWrite prometheusMode = iota
+	prometheusPushgateway
+)
+
+type Sink[T any] struct {
+	cfg   Config[T]
+	mode  prometheusMode
+	batch *delivery.Batcher[Sample]
+}
+
+func newPrometheus[T any](mode prometheusMode, cfg Config[T]) Sink[T] {
+	if cfg.Samples == nil {
+		panic("prometheus: Samples must be set")
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 500
+	}
+	if cfg.Linger == 0 {
+		cfg.Linger = time.Second
+	}
+
+	p := Sink[T]{cfg: cfg, mode: mode}
+	p.batch = delivery.New(cfg.BatchSize, 0, cfg.Linger, p.send, sink.LogError)
+	return p
+}
+
+// NewRemoteWrite sends the samples of outputs to a Prometheus remote-write
+// endpoint. Failures of batches sent after Linger are logged. Call Flush
+// before shutting down. It panics if Samples is nil.
+func NewRemoteWrite[T any](cfg Config[T]) Sink[T] {
+	return newPrometheus(prometheusRemoteWrite, cfg)
+}
+
+// NewPushgateway pushes the samples of outputs as untyped metrics to a
+// Pushgateway group, replacing metrics of the same name in the group.
+// Within a batch the last sample of a series wins. It panics if Samples is
+// nil.
+func NewPushgateway[T any](gatewayURL, job string, cfg Config[T]) Sink[T] {
+	cfg.URL = strings.TrimSuffix(gatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+	return newPrometheus(prometheusPushgateway, cfg)
+}
+
+func (p Sink[T]) post(ctx context.Context, body io.Reader, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, body)
+	if err != nil {
+		return err
+	}
+	for k, vs := range p.cfg.Header {
+		req.Header[k] = vs
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &sink.HttpError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(msg)}
+	}
+	return nil
+}
+
+func (p Sink[T]) send(ctx context.Context, samples []Sample) error {
+	if p.mode == prometheusPushgateway {
+		return p.post(ctx, strings.NewReader(exposition(samples)), http.Header{
+			"Content-Type": {"text/plain; version=0.0.4"},
+		})
+	}
+
+	b, err := writeRequest(samples).Marshal()
+	if err != nil {
+		return err
+	}
+	return p.post(ctx, bytes.NewReader(snappy.Encode(nil, b)), http.Header{
+		"Content-Type":                      {"application/x-protobuf"},
+		"Content-Encoding":                  {"snappy"},
+		"X-Prometheus-Remote-Write-Version": {"0.1.0"},
+	})
+}
+
+// seriesKey identifies a series by its name and sorted labels.
+func seriesKey(s Sample) string {
+	var b strings.Builder
+	b.WriteString(s.Name)
+	for _, k := range slices.Sorted(maps.Keys(s.Labels)) {
+		b.WriteString("\xff" + k + "\xff" + s.Labels[k])
+	}
+	return b.String()
+}
+
+func writeRequest(samples []Sample) *prompb.WriteRequest {
+	series := make(map[string]*prompb.TimeSeries)
+	var order []string
+
+	for _, s := range samples {
+		key := seriesKey(s)
+		ts, ok := series[key]
+		if !ok {
+			ts = &prompb.TimeSeries{Labels: []prompb.Label{{Name: "__name__", Value: s.Name}}}
+			for _, k := range slices.Sorted(maps.Keys(s.Labels)) {
+				ts.Labels = append(ts.Labels, prompb.Label{Name: k, Value: s.Labels[k]})
+			}
+			series[key] = ts
+			order = append(order, key)
+		}
+		ts.Samples = append(ts.Samples, prompb.Sample{Value: s.Value, Timestamp: s.Time.UnixMilli()})
+	}
+
+	req := &prompb.WriteRequest{Timeseries: make([]prompb.TimeSeries, len(order))}
+	for i, key := range order {
+		req.Timeseries[i] = *series[key]
+	}
+	return req
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func exposition(samples []Sample) string {
+	last := make(map[string]Sample)
+	var order []string
+	for _, s := range samples {
+		key := seriesKey(s)
+		if _, ok := last[key]; !ok {
+			order = append(order, key)
+		}
+		last[key] = s
+	}
+	// Metrics of one name must be grouped together, so series are ordered
+	// by name first.
+	slices.SortFunc(order, func(a, b string) int {
+		return cmp.Or(strings.Compare(last[a].Name, last[b].Name), strings.Compare(a, b))
+	})
+
+	var b strings.Builder
+	for _, key := range order {
+		s := last[key]
+		b.WriteString(s.Name)
+		if len(s.Labels) > 0 {
+			b.WriteByte('{')
+			for i, k := range slices.Sorted(maps.Keys(s.Labels)) {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(k + `="` + labelEscaper.Replace(s.Labels[k]) + `"`)
+			}
+			b.WriteByte('}')
+		}
+		b.WriteString(" " + strconv.FormatFloat(s.Value, 'g', -1, 64) + "\n")
+	}
+	return b.String()
+}
+
+func (p Sink[T]) OnSuccess(ctx context.Context, v T) error {
+	now := time.Now()
+
+	samples := p.cfg.Samples(v)
+	if len(samples) == 0 {
+		chord.Ack(ctx)
+		return nil
+	}
+
+	// The event is acked once every batch holding one of its samples is.
+	ctxs := chord.ForkAcks(ctx, len(samples))
+	var err error
+	for i, s := range samples {
+		if s.Time.IsZero() {
+			s.Time = now
+		}
+		if aerr := p.batch.Add(ctxs[i], s, 0); err == nil {
+			err = aerr
+		}
+	}
+	return err
+}
+
+func (p Sink[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
+
+func (p Sink[T]) SettlesOutputs() bool { return true }
+
+// Flush sends whatever is buffered.
+func (p Sink[T]) Flush(ctx context.Context) error {
+	return p.batch.Flush(ctx)
+}
@@ -0,0 +1,124 @@
+// Package redis is a sink writing to Redis streams, lists and channels.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/0x180db/go-chord/sink"
+	"github.com/0x180db/go-chord/sink/internal/delivery"
+	"github.com/redis/go-redis/v9"
+)
+
+type Mode int
+
+const (
+	// Stream appends each output to a stream with XADD.
+	Stream Mode = iota
+	// List appends each output to a list with RPUSH.
+	List
+	// Publish sends each output to a channel with PUBLISH.
+	Publish
+)
+
+type Config[T any] struct {
+	Mode Mode
+	// Key is the stream, list or channel written to.
+	Key string
+	// KeyFunc overrides Key per output.
+	KeyFunc func(T) string
+	// MaxLen approximately caps streams with MAXLEN ~ when set.
+	MaxLen int64
+	// Field is the stream entry field holding the output. It defaults to
+	// "data".
+	Field string
+	// Marshal encodes each output. It defaults to JSON.
+	Marshal func(T) ([]byte, error)
+	// BatchSize pipelines up to this many writes per round trip. It
+	// defaults to 1, writing each output on its own.
+	BatchSize int
+	// Linger is how long a partial batch waits for more outputs. It
+	// defaults to 100ms.
+	Linger time.Duration
+}
+
+type Sink[T any] struct {
+	redis.UniversalClient
+	cfg   Config[T]
+	batch *delivery.Batcher[func(redis.Pipeliner)]
+}
+
+// New writes each output to Redis as configured by cfg.Mode. With
+// batching, failures of batches sent after Linger are logged. Call Flush
+// before shutting down.
+func New[T any](rdb redis.UniversalClient, cfg Config[T]) Sink[T] {
+	if cfg.Marshal == nil {
+		cfg.Marshal = func(v T) ([]byte, error) { return json.Marshal(v) }
+	}
+	if cfg.Field == "" {
+		cfg.Field = "data"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1
+	}
+	if cfg.Linger == 0 {
+		cfg.Linger = 100 * time.Millisecond
+	}
+
+	r := Sink[T]{UniversalClient: rdb, cfg: cfg}
+	r.batch = delivery.New(cfg.BatchSize, 0, cfg.Linger, r.write, sink.LogError)
+	return r
+}
+
+func (r Sink[T]) write(ctx context.Context, cmds []func(redis.Pipeliner)) error {
+	_, err := r.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for _, cmd := range cmds {
+			cmd(p)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r Sink[T]) OnSuccess(ctx context.Context, v T) error {
+	b, err := r.cfg.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	key := r.cfg.Key
+	if r.cfg.KeyFunc != nil {
+		key = r.cfg.KeyFunc(v)
+	}
+
+	var cmd func(redis.Pipeliner)
+	switch r.cfg.Mode {
+	case List:
+		cmd = func(p redis.Pipeliner) { p.RPush(ctx, key, b) }
+	case Publish:
+		cmd = func(p redis.Pipeliner) { p.Publish(ctx, key, b) }
+	default:
+		cmd = func(p redis.Pipeliner) {
+			p.XAdd(ctx, &redis.XAddArgs{
+				Stream: key,
+				MaxLen: r.cfg.MaxLen,
+				Approx: r.cfg.MaxLen > 0,
+				Values: []any{r.cfg.Field, b},
+			})
+		}
+	}
+
+	return r.batch.Add(ctx, cmd, len(b))
+}
+
+func (r Sink[T]) OnError(ctx context.Context, err error) {
+	sink.LogError(ctx, err)
+}
+
+func (r Sink[T]) SettlesOutputs() bool { return true }
+
+// Flush writes any partial batch.
+func (r Sink[T]) Flush(ctx context.Context) error {
+	return r.batch.Flush(ctx)
+}
@@ -0,0 +1,261 @@
+package sink
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// rotatedLayout is the timestamp suffix of rotated files.
+const rotatedLayout = "20060102T150405.000000"
+
+type FileSync int
+
+const (
+	// SyncNone leaves flushing to the operating system.
+	SyncNone FileSync = iota
+	// SyncWrite fsyncs after every output.
+	SyncWrite
+	// SyncInterval fsyncs at most once per RotatingFileConfig.SyncEvery.
+	SyncInterval
+)
+
+type RotatingFileConfig struct {
+	Path string
+	// MaxSize rotates the file before a write would grow it past this many
+	// bytes. Zero disables size-based rotation.
+	MaxSize int64
+	// Interval rotates the file at each multiple of the interval, such as
+	// every hour on the hour. Zero disables time-based rotation.
+	Interval time.Duration
+	// MaxBackups removes the oldest rotated files beyond this many. Zero
+	// keeps them all.
+	MaxBackups int
+	// Compress gzips rotated files in the background.
+	Compress  bool
+	Sync      FileSync
+	SyncEvery time.Duration
+}
+
+type rotatingState struct {
+	mu       sync.Mutex
+	f        *os.File
+	size     int64
+	rotateAt time.Time
+	syncedAt time.Time
+	// last is closed when the most recent rotation's background work is
+	// done; each rotation waits for the previous one.
+	last chan struct{}
+	wg   sync.WaitGroup
+}
+
+type RotatingFile[T any] struct {
+	cfg   RotatingFileConfig
+	state *rotatingState
+}
+
+// NewRotatingFile appends each output to cfg.Path as a line of JSON,
+// moving the file aside to Path.<timestamp> when it is rotated.
+func NewRotatingFile[T any](cfg RotatingFileConfig) RotatingFile[T] {
+	if cfg.SyncEvery == 0 {
+		cfg.SyncEvery = time.Second
+	}
+	return RotatingFile[T]{cfg, new(rotatingState)}
+}
+
+func (r RotatingFile[T]) open(now time.Time) error {
+	f, err := os.OpenFile(r.cfg.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r.state.f, r.state.size = f, fi.Size()
+	if r.cfg.Interval > 0 {
+		r.state.rotateAt = now.Truncate(r.cfg.Interval).Add(r.cfg.Interval)
+	}
+	return nil
+}
+
+func (r RotatingFile[T]) rotate(ctx context.Context, now time.Time) error {
+	if err := r.state.f.Close(); err != nil {
+		return err
+	}
+	r.state.f = nil
+
+	name := r.cfg.Path + "." + now.Format(rotatedLayout)
+	if err := os.Rename(r.cfg.Path, name); err != nil {
+		return err
+	}
+
+	prev, done := r.state.last, make(chan struct{})
+	r.state.last = done
+
+	r.state.wg.Go(func() {
+		defer close(done)
+		if prev != nil {
+			<-prev
+		}
+
+		if r.cfg.Compress {
+			if err := compressFile(name); err != nil {
+				LogError(ctx, err)
+			}
+		}
+		if r.cfg.MaxBackups > 0 {
+			if err := r.prune(name); err != nil {
+				LogError(ctx, err)
+			}
+		}
+	})
+	return nil
+}
+
+func compressFile(name string) error {
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
+
+// rotated returns the rotated files of Path, compressed or not, leaving
+// out other files sharing its prefix.
+func (r RotatingFile[T]) rotated() ([]string, error) {
+	dir, base := filepath.Split(r.cfg.Path)
+	entries, err := os.ReadDir(filepath.Clean(dir))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		stamp, ok := strings.CutPrefix(e.Name(), base+".")
+		if !ok {
+			continue
+		}
+		if _, err := time.Parse(rotatedLayout, strings.TrimSuffix(stamp, ".gz")); err == nil {
+			names = append(names, dir+e.Name())
+		}
+	}
+	return names, nil
+}
+
+// prune removes the oldest rotated files beyond MaxBackups, up to and
+// including name. Later files are left to their own rotation's work.
+// Rotated names sort by time, compressed or not.
+func (r RotatingFile[T]) prune(name string) error {
+	names, err := r.rotated()
+	if err != nil {
+		return err
+	}
+
+	slices.SortFunc(names, func(a, b string) int {
+		return strings.Compare(strings.TrimSuffix(a, ".gz"), strings.TrimSuffix(b, ".gz"))
+	})
+
+	for len(names) > r.cfg.MaxBackups && strings.TrimSuffix(names[0], ".gz") <= name {
+		if err := os.Remove(names[0]); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+func (r RotatingFile[T]) OnSuccess(ctx context.Context, v T) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+
+	now := chord.ClockFrom(ctx).Now()
+	if r.state.f != nil {
+		full := r.cfg.MaxSize > 0 && r.state.size > 0 && r.state.size+int64(len(b)) > r.cfg.MaxSize
+		due := r.cfg.Interval > 0 && !now.Before(r.state.rotateAt)
+		if full || due {
+			if err := r.rotate(context.WithoutCancel(ctx), now); err != nil {
+				return err
+			}
+		}
+	}
+	if r.state.f == nil {
+		if err := r.open(now); err != nil {
+			return err
+		}
+	}
+
+	n, err := r.state.f.Write(b)
+	r.state.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	switch r.cfg.Sync {
+	case SyncWrite:
+		return r.state.f.Sync()
+	case SyncInterval:
+		if now.Sub(r.state.syncedAt) >= r.cfg.SyncEvery {
+			r.state.syncedAt = now
+			return r.state.f.Sync()
+		}
+	}
+	return nil
+}
+
+func (r RotatingFile[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
+
+// Close syncs and closes the current file and waits for rotated files to
+// be compressed.
+func (r RotatingFile[T]) Close() error {
+	r.state.mu.Lock()
+	defer r.state.mu.Unlock()
+
+	var err error
+	if r.state.f != nil {
+		if err = r.state.f.Sync(); err == nil {
+			err = r.state.f.Close()
+		}
+		r.state.f = nil
+	}
+	r.state.wg.Wait()
+	return err
+}
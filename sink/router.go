@@ -0,0 +1,79 @@
+package sink
+
+import (
+	"context"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Route[T any] struct {
+	// When selects the outputs for Sink. A nil When matches every output.
+	When func(T) bool
+	Sink chord.Sink[T]
+	// Errors also sends the flow's errors to Sink.
+	Errors bool
+}
+
+type Router[T any] struct {
+	routes []Route[T]
+}
+
+// NewRouter sends each output to the sink of the first route it matches,
+// dropping and acking outputs no route matches. Errors go to every route with Errors
+// set, or are logged if there is none.
+func NewRouter[T any](routes ...Route[T]) chord.Sink[T] {
+	return Router[T]{routes}
+}
+
+// NewKeyRouter sends each output to the sink registered for its key, or to
+// fallback, which may be nil to drop the output. Errors go to fallback.
+func NewKeyRouter[T any, K comparable](key func(T) K, sinks map[K]chord.Sink[T], fallback chord.Sink[T]) chord.Sink[T] {
+	if fallback == nil {
+		fallback = Router[T]{}
+	}
+	return keyRouter[T, K]{key, sinks, fallback}
+}
+
+type keyRouter[T any, K comparable] struct {
+	key      func(T) K
+	sinks    map[K]chord.Sink[T]
+	fallback chord.Sink[T]
+}
+
+func (r keyRouter[T, K]) OnSuccess(ctx context.Context, v T) error {
+	if s, ok := r.sinks[r.key(v)]; ok {
+		return chord.Deliver(ctx, s, v)
+	}
+	return chord.Deliver(ctx, r.fallback, v)
+}
+
+func (r keyRouter[T, K]) SettlesOutputs() bool { return true }
+
+func (r keyRouter[T, K]) OnError(ctx context.Context, err error) {
+	r.fallback.OnError(ctx, err)
+}
+
+func (r Router[T]) OnSuccess(ctx context.Context, v T) error {
+	for _, route := range r.routes {
+		if route.When == nil || route.When(v) {
+			return chord.Deliver(ctx, route.Sink, v)
+		}
+	}
+	chord.Ack(ctx)
+	return nil
+}
+
+func (r Router[T]) SettlesOutputs() bool { return true }
+
+func (r Router[T]) OnError(ctx context.Context, err error) {
+	handled := false
+	for _, route := range r.routes {
+		if route.Errors {
+			route.Sink.OnError(ctx, err)
+			handled = true
+		}
+	}
+	if !handled {
+		LogError(ctx, err)
+	}
+}
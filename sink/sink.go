@@ -0,0 +1,11 @@
+package sink
+
+import (
+	"context"
+	"log"
+)
+
+// LogError is the OnError of sinks that have nowhere better to report to.
+func LogError(_ context.Context, err error) {
+	log.Printf("chord: %v", err)
+}
@@ -0,0 +1,194 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type SmtpConfig[T any] struct {
+	// Addr is the host:port of the server. Port 465 uses implicit TLS;
+	// other ports upgrade with STARTTLS when the server offers it.
+	Addr     string
+	Username string
+	Password string
+	From     string
+	To       []string
+	// Recipients overrides To per output.
+	Recipients func(T) []string
+	// Subject and Body are templates executed on each output. Body is an
+	// html/template when HTML is set.
+	Subject string
+	Body    string
+	HTML    bool
+	// PerMinute caps how many messages are sent per minute. Zero means no
+	// limit.
+	PerMinute int
+	TLS       *tls.Config
+}
+
+type bodyTemplate interface {
+	Execute(w io.Writer, data any) error
+}
+
+type Smtp[T any] struct {
+	cfg     SmtpConfig[T]
+	subject *template.Template
+	body    bodyTemplate
+	err     error
+	pace    *pacer
+}
+
+// NewSmtp sends an email per output, rendering its subject and body from
+// templates.
+func NewSmtp[T any](cfg SmtpConfig[T]) chord.Sink[T] {
+	if cfg.TLS == nil {
+		host, _, _ := net.SplitHostPort(cfg.Addr)
+		cfg.TLS = &tls.Config{ServerName: host}
+	}
+
+	s := Smtp[T]{cfg: cfg, pace: new(pacer)}
+	if cfg.PerMinute > 0 {
+		s.pace.every = time.Minute / time.Duration(cfg.PerMinute)
+	}
+	s.subject, s.err = template.New("subject").Parse(cfg.Subject)
+	if s.err == nil {
+		if cfg.HTML {
+			s.body, s.err = htmltemplate.New("body").Parse(cfg.Body)
+		} else {
+			s.body, s.err = template.New("body").Parse(cfg.Body)
+		}
+	}
+	return s
+}
+
+func (s Smtp[T]) message(v T, to []string) ([]byte, error) {
+	var subject strings.Builder
+	if err := s.subject.Execute(&subject, v); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	ctype := "text/plain"
+	if s.cfg.HTML {
+		ctype = "text/html"
+	}
+	fmt.Fprintf(&msg, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject.String()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: %s; charset=utf-8\r\n", ctype)
+	fmt.Fprintf(&msg, "Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	qp := quotedprintable.NewWriter(&msg)
+	if err := s.body.Execute(qp, v); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return msg.Bytes(), nil
+}
+
+func (s Smtp[T]) dial(ctx context.Context) (*smtp.Client, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.cfg.Addr)
+	if err != nil {
+		return nil, err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	conn.SetDeadline(deadline)
+
+	_, port, _ := net.SplitHostPort(s.cfg.Addr)
+	if port == "465" {
+		conn = tls.Client(conn, s.cfg.TLS)
+	}
+
+	c, err := smtp.NewClient(conn, s.cfg.TLS.ServerName)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok && port != "465" {
+		if err := c.StartTLS(s.cfg.TLS); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.TLS.ServerName)); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (s Smtp[T]) OnSuccess(ctx context.Context, v T) error {
+	if s.err != nil {
+		return s.err
+	}
+
+	to := s.cfg.To
+	if s.cfg.Recipients != nil {
+		to = s.cfg.Recipients(v)
+	}
+	if len(to) == 0 {
+		return nil
+	}
+
+	msg, err := s.message(v, to)
+	if err != nil {
+		return err
+	}
+	if !s.pace.wait(ctx) {
+		return ctx.Err()
+	}
+
+	c, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.Mail(s.cfg.From); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (s Smtp[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
@@ -0,0 +1,60 @@
+package sink
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/0x180db/go-chord"
+)
+
+// SqlTransaction is the chord.Transaction of SqlTx. A chord.TxAcker can
+// write its acknowledgment through Tx, as checkpoint.Sql does.
+type SqlTransaction struct {
+	Tx *sql.Tx
+}
+
+func (t SqlTransaction) SqlTx() *sql.Tx { return t.Tx }
+
+func (t SqlTransaction) Commit(context.Context) error { return t.Tx.Commit() }
+func (t SqlTransaction) Abort(context.Context) error  { return t.Tx.Rollback() }
+
+type SqlTx[T any] struct {
+	db    *sql.DB
+	opts  *sql.TxOptions
+	write func(context.Context, *sql.Tx, T) error
+}
+
+// NewSqlTx writes each output with write in a transaction of its own,
+// which RunFlow commits together with the event's acknowledgment (see
+// chord.TwoPhaseSink).
+func NewSqlTx[T any](db *sql.DB, opts *sql.TxOptions, write func(context.Context, *sql.Tx, T) error) SqlTx[T] {
+	return SqlTx[T]{db, opts, write}
+}
+
+func (s SqlTx[T]) Prepare(ctx context.Context, v T) (chord.Transaction, error) {
+	tx, err := s.db.BeginTx(ctx, s.opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.write(ctx, tx, v); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return SqlTransaction{tx}, nil
+}
+
+// OnSuccess writes v in a transaction committed right away, for use
+// outside RunFlow.
+func (s SqlTx[T]) OnSuccess(ctx context.Context, v T) error {
+	tx, err := s.Prepare(ctx, v)
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (s SqlTx[T]) Ping(ctx context.Context) error { return s.db.PingContext(ctx) }
+
+func (s SqlTx[T]) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
@@ -0,0 +1,32 @@
+package sink
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Writer struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+// NewWriter writes each output to w as is, so a pipeline of byte chunks
+// can feed a gzip.Writer, a tar.Writer, a net.Conn or any other writer.
+func NewWriter(w io.Writer) chord.Sink[[]byte] {
+	return Writer{new(sync.Mutex), w}
+}
+
+func (w Writer) OnSuccess(_ context.Context, b []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := w.w.Write(b)
+	return err
+}
+
+func (w Writer) OnError(ctx context.Context, err error) {
+	LogError(ctx, err)
+}
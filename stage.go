@@ -0,0 +1,215 @@
+package chord
+
+import (
+	"context"
+	"sync"
+
+	"github.com/0x180db/go-conduit"
+)
+
+// Result is a value or error flowing through a stage, with its context.
+type Result[T any] = conduit.Result[T]
+
+// Ok wraps a value into a result carrying ctx.
+func Ok[T any](ctx context.Context, v T) Result[T] {
+	return conduit.Ok(ctx, v)
+}
+
+// Emit sends a value, or an error if err is non-nil, downstream with the
+// given result context. It reports false once the producer's context is
+// done.
+type Emit[T any] func(context.Context, T, error) bool
+
+// item carries a value or an error through a stage until unwrap turns it
+// into a result.
+type item[T any] struct {
+	val T
+	err error
+}
+
+// unwrap turns the items of a stage into values and errors.
+func unwrap[T any](s func() <-chan Result[item[T]]) Stage[T] {
+	return newStage(s, func(_ context.Context, it item[T]) (T, error) {
+		return it.val, it.err
+	})
+}
+
+// NewProducer runs fn in its own goroutine and turns everything it emits
+// into results, routing errors to the flow's error path. If fn gives up
+// while ctx is still live and the last thing it emitted was an error, the
+// run is stopped with a TriggerError.
+func NewProducer[T any](ctx context.Context, fn func(context.Context, Emit[T])) Stage[T] {
+	out := newProducer(ctx, fn)
+	record[T](Node{Kind: KindProducer, Name: funcName(fn)}, nil, out)
+	return out
+}
+
+func newProducer[T any](ctx context.Context, fn func(context.Context, Emit[T])) Stage[T] {
+	return unwrap(func() <-chan Result[item[T]] {
+		ch := make(chan Result[item[T]])
+
+		// Callbacks may still emit after fn has returned; closed keeps
+		// them from sending on the closed channel.
+		var (
+			mu      sync.RWMutex
+			closed  bool
+			lastMu  sync.Mutex
+			lastErr error
+		)
+
+		go func() {
+			defer func() {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if lastErr != nil && ctx.Err() == nil {
+					Stop(ctx, &TriggerError{Err: lastErr})
+				}
+				closed = true
+				close(ch)
+			}()
+
+			fn(ctx, func(c context.Context, v T, err error) bool {
+				mu.RLock()
+				defer mu.RUnlock()
+
+				if closed {
+					return false
+				}
+
+				select {
+				case <-ctx.Done():
+					return false
+				case ch <- Ok(c, item[T]{v, err}):
+				}
+
+				// Emits run concurrently under the read lock.
+				lastMu.Lock()
+				lastErr = err
+				lastMu.Unlock()
+				return true
+			})
+		}()
+		return ch
+	})
+}
+
+// NewConsumer consumes the stage until it completes, passing outputs to
+// onSuccess and errors, including those onSuccess returns, to onError.
+func NewConsumer[T any](s Stage[T], onSuccess func(context.Context, T) error, onError func(context.Context, error)) {
+	conduit.NewConsumer(conduit.Stage[T](s), onSuccess, onError)
+}
+
+// Merge fans in: it runs all stages concurrently and completes once they
+// all have. Outputs keep their result contexts.
+func Merge[T any](stages ...Stage[T]) Stage[T] {
+	out := merge(stages...)
+	record(Node{Kind: KindMerge, Fan: len(stages)}, stages, out)
+	return out
+}
+
+func merge[T any](stages ...Stage[T]) Stage[T] {
+	return newProducer(context.Background(), func(_ context.Context, emit Emit[T]) {
+		var wg sync.WaitGroup
+		for _, s := range stages {
+			wg.Go(func() {
+				NewConsumer(s,
+					func(ctx context.Context, v T) error {
+						emit(ctx, v, nil)
+						return nil
+					},
+					func(ctx context.Context, err error) {
+						var zero T
+						emit(ctx, zero, err)
+					},
+				)
+			})
+		}
+		wg.Wait()
+	})
+}
+
+// Broadcast fans out: each output and error of s is sent to all n
+// returned stages, and its event is only acked once every branch acks it.
+// s starts when the first of them does, and every one of them must be
+// consumed for s to make progress.
+func Broadcast[T any](s Stage[T], n int) []Stage[T] {
+	chs := make([]chan Result[item[T]], n)
+	for i := range chs {
+		chs[i] = make(chan Result[item[T]])
+	}
+
+	var once sync.Once
+	start := func() {
+		once.Do(func() {
+			go func() {
+				defer func() {
+					for _, ch := range chs {
+						close(ch)
+					}
+				}()
+
+				send := func(ctx context.Context, it item[T]) {
+					ctxs := ForkAcks(ctx, n)
+					for i, ch := range chs {
+						ch <- Ok(ctxs[i], it)
+					}
+				}
+				NewConsumer(s,
+					func(ctx context.Context, v T) error {
+						send(ctx, item[T]{val: v})
+						return nil
+					},
+					func(ctx context.Context, err error) {
+						send(ctx, item[T]{err: err})
+					},
+				)
+			}()
+		})
+	}
+
+	out := make([]Stage[T], n)
+	for i, ch := range chs {
+		out[i] = unwrap(func() <-chan Result[item[T]] {
+			start()
+			return ch
+		})
+	}
+	record(Node{Kind: KindBroadcast, Fan: n}, []Stage[T]{s}, out...)
+	return out
+}
+
+// NewParallelStage is NewStage with fn running on up to workers outputs of
+// p at once. Outputs may be reordered.
+func NewParallelStage[In, Out any](p Stage[In], workers int, fn func(context.Context, In) (Out, error)) Stage[Out] {
+	var (
+		once sync.Once
+		ch   = make(chan Result[item[In]])
+	)
+	shared := func() <-chan Result[item[In]] {
+		once.Do(func() {
+			go func() {
+				defer close(ch)
+
+				NewConsumer(p,
+					func(ctx context.Context, v In) error {
+						ch <- Ok(ctx, item[In]{val: v})
+						return nil
+					},
+					func(ctx context.Context, err error) {
+						ch <- Ok(ctx, item[In]{err: err})
+					},
+				)
+			}()
+		})
+		return ch
+	}
+
+	stages := make([]Stage[Out], max(workers, 1))
+	for i := range stages {
+		stages[i] = newStage(unwrap(shared), fn)
+	}
+	out := merge(stages...)
+	record(Node{Kind: KindStage, Name: funcName(fn), Workers: len(stages)}, []Stage[In]{p}, out)
+	return out
+}
@@ -0,0 +1,180 @@
+package stage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+type Compression int
+
+const (
+	Gzip Compression = iota
+	Zstd
+	Snappy
+)
+
+func (c Compression) String() string {
+	switch c {
+	case Gzip:
+		return "gzip"
+	case Zstd:
+		return "zstd"
+	case Snappy:
+		return "snappy"
+	default:
+		return fmt.Sprintf("Compression(%d)", int(c))
+	}
+}
+
+// MaxDecompressed caps a decompressed payload, so a small malicious input
+// cannot exhaust memory.
+const MaxDecompressed = 256 << 20
+
+var ErrTooLarge = errors.New("stage: decompressed payload too large")
+
+var (
+	gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+	gzipReaders sync.Pool
+
+	// zstd encoders and decoders are safe for concurrent EncodeAll and
+	// DecodeAll calls, so one of each is shared.
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(MaxDecompressed))
+)
+
+func compress(c Compression, b []byte) ([]byte, error) {
+	switch c {
+	case Gzip:
+		var buf bytes.Buffer
+		zw := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(zw)
+
+		zw.Reset(&buf)
+		if _, err := zw.Write(b); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case Zstd:
+		return zstdEncoder.EncodeAll(b, nil), nil
+	case Snappy:
+		return snappy.Encode(nil, b), nil
+	default:
+		return nil, fmt.Errorf("stage: unknown compression %v", c)
+	}
+}
+
+func decompress(c Compression, b []byte) ([]byte, error) {
+	switch c {
+	case Gzip:
+		r, err := newGzipReader(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+
+		out, err := io.ReadAll(io.LimitReader(r, MaxDecompressed+1))
+		if err == nil && len(out) > MaxDecompressed {
+			err = ErrTooLarge
+		}
+		return out, err
+	case Zstd:
+		return zstdDecoder.DecodeAll(b, nil)
+	case Snappy:
+		if n, err := snappy.DecodedLen(b); err != nil {
+			return nil, err
+		} else if n > MaxDecompressed {
+			return nil, ErrTooLarge
+		}
+		return snappy.Decode(nil, b)
+	default:
+		return nil, fmt.Errorf("stage: unknown compression %v", c)
+	}
+}
+
+// pooledGzipReader returns its reader to the pool on Close.
+type pooledGzipReader struct {
+	*gzip.Reader
+}
+
+func (r pooledGzipReader) Close() error {
+	err := r.Reader.Close()
+	gzipReaders.Put(r.Reader)
+	return err
+}
+
+func newGzipReader(r io.Reader) (io.ReadCloser, error) {
+	if zr, ok := gzipReaders.Get().(*gzip.Reader); ok {
+		if err := zr.Reset(r); err != nil {
+			gzipReaders.Put(zr)
+			return nil, err
+		}
+		return pooledGzipReader{zr}, nil
+	}
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return pooledGzipReader{zr}, nil
+}
+
+// Compress compresses each payload on its own.
+func Compress(s chord.Stage[[]byte], c Compression) chord.Stage[[]byte] {
+	return chord.NewStage(s, func(_ context.Context, b []byte) ([]byte, error) {
+		return compress(c, b)
+	})
+}
+
+// Decompress decompresses each payload on its own, routing corrupt or
+// oversized payloads to the error path.
+func Decompress(s chord.Stage[[]byte], c Compression) chord.Stage[[]byte] {
+	return chord.NewStage(s, func(_ context.Context, b []byte) ([]byte, error) {
+		return decompress(c, b)
+	})
+}
+
+// NewCompressWriter compresses a stream written to w, such as the output
+// of sink.NewWriter. Close flushes it without closing w.
+func NewCompressWriter(w io.Writer, c Compression) (io.WriteCloser, error) {
+	switch c {
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	case Snappy:
+		return snappy.NewBufferedWriter(w), nil
+	default:
+		return nil, fmt.Errorf("stage: unknown compression %v", c)
+	}
+}
+
+// NewDecompressReader decompresses a stream read from r, such as one from
+// chord.NewReader.
+func NewDecompressReader(r io.Reader, c Compression) (io.ReadCloser, error) {
+	switch c {
+	case Gzip:
+		return newGzipReader(r)
+	case Zstd:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	case Snappy:
+		return io.NopCloser(snappy.NewReader(r)), nil
+	default:
+		return nil, fmt.Errorf("stage: unknown compression %v", c)
+	}
+}
@@ -0,0 +1,181 @@
+package stage
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"github.com/0x180db/go-chord"
+)
+
+var (
+	ErrInvalidSignature = errors.New("stage: invalid signature")
+	errSealedFormat     = errors.New("stage: malformed sealed payload")
+)
+
+// sealedVersion leads every encrypted or signed payload, so the format can
+// change without breaking payloads already written.
+const sealedVersion = 1
+
+// KeyProvider supplies keys by ID, so keys can be rotated: payloads are
+// sealed with the current key and opened with whichever key sealed them.
+type KeyProvider interface {
+	Current(context.Context) (id string, key []byte, err error)
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+type StaticKeys struct {
+	current string
+	keys    map[string][]byte
+}
+
+// NewStaticKeys provides keys from memory, sealing with the one named
+// current.
+func NewStaticKeys(current string, keys map[string][]byte) StaticKeys {
+	return StaticKeys{current, keys}
+}
+
+func (s StaticKeys) Current(ctx context.Context) (string, []byte, error) {
+	key, err := s.Key(ctx, s.current)
+	return s.current, key, err
+}
+
+func (s StaticKeys) Key(_ context.Context, id string) ([]byte, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("stage: unknown key %q", id)
+	}
+	return key, nil
+}
+
+// header is the version and key ID prefix of a sealed payload.
+func header(id string) ([]byte, error) {
+	if len(id) > 255 {
+		return nil, fmt.Errorf("stage: key id %q too long", id)
+	}
+	return append([]byte{sealedVersion, byte(len(id))}, id...), nil
+}
+
+func parseHeader(b []byte) (string, []byte, error) {
+	if len(b) < 2 || b[0] != sealedVersion || len(b) < 2+int(b[1]) {
+		return "", nil, errSealedFormat
+	}
+	n := 2 + int(b[1])
+	return string(b[2:n]), b[n:], nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Encrypt seals each payload with AES-GCM under the provider's current
+// key, which must be 16, 24 or 32 bytes. The key ID is authenticated along
+// with the payload.
+func Encrypt(s chord.Stage[[]byte], keys KeyProvider) chord.Stage[[]byte] {
+	return chord.NewStage(s, func(ctx context.Context, b []byte) ([]byte, error) {
+		id, key, err := keys.Current(ctx)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		h, err := header(id)
+		if err != nil {
+			return nil, err
+		}
+
+		out := make([]byte, len(h)+aead.NonceSize(), len(h)+aead.NonceSize()+len(b)+aead.Overhead())
+		copy(out, h)
+		nonce := out[len(h):]
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+		return aead.Seal(out, nonce, b, h), nil
+	})
+}
+
+// Decrypt opens payloads sealed by Encrypt, routing those that were
+// tampered with or use an unknown key to the error path.
+func Decrypt(s chord.Stage[[]byte], keys KeyProvider) chord.Stage[[]byte] {
+	return chord.NewStage(s, func(ctx context.Context, b []byte) ([]byte, error) {
+		id, rest, err := parseHeader(b)
+		if err != nil {
+			return nil, err
+		}
+		key, err := keys.Key(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rest) < aead.NonceSize() {
+			return nil, errSealedFormat
+		}
+		h := b[:len(b)-len(rest)]
+		return aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], h)
+	})
+}
+
+func mac(key, h, payload []byte) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write(h)
+	m.Write(payload)
+	return m.Sum(nil)
+}
+
+// Sign appends an HMAC-SHA256 of each payload under the provider's current
+// key. The payload itself stays readable.
+func Sign(s chord.Stage[[]byte], keys KeyProvider) chord.Stage[[]byte] {
+	return chord.NewStage(s, func(ctx context.Context, b []byte) ([]byte, error) {
+		id, key, err := keys.Current(ctx)
+		if err != nil {
+			return nil, err
+		}
+		h, err := header(id)
+		if err != nil {
+			return nil, err
+		}
+
+		out := append(h, b...)
+		return append(out, mac(key, h, b)...), nil
+	})
+}
+
+// Verify checks and strips the signatures added by Sign, routing payloads
+// with an invalid signature to the error path as ErrInvalidSignature.
+func Verify(s chord.Stage[[]byte], keys KeyProvider) chord.Stage[[]byte] {
+	return chord.NewStage(s, func(ctx context.Context, b []byte) ([]byte, error) {
+		id, rest, err := parseHeader(b)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) < sha256.Size {
+			return nil, errSealedFormat
+		}
+		key, err := keys.Key(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		h := b[:len(b)-len(rest)]
+		payload, sig := rest[:len(rest)-sha256.Size], rest[len(rest)-sha256.Size:]
+		if !hmac.Equal(sig, mac(key, h, payload)) {
+			return nil, ErrInvalidSignature
+		}
+		return payload, nil
+	})
+}
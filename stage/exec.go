@@ -0,0 +1,234 @@
+package stage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+var errExecNewline = errors.New("stage: exec payload contains a newline")
+
+// ExecError is routed to the error path when the command fails an event,
+// with whatever it wrote to stderr.
+type ExecError struct {
+	Err    error
+	Stderr []byte
+}
+
+func (e *ExecError) Error() string {
+	if len(e.Stderr) == 0 {
+		return "stage: exec: " + e.Err.Error()
+	}
+	return fmt.Sprintf("stage: exec: %v: %s", e.Err, bytes.TrimSpace(e.Stderr))
+}
+
+func (e *ExecError) Unwrap() error { return e.Err }
+
+type ExecConfig struct {
+	Path string
+	Args []string
+	Dir  string
+	// Env defaults to the environment of the current process.
+	Env []string
+	// Persistent keeps the commands running and exchanges one line per
+	// event over stdin and stdout, so payloads must not contain newlines.
+	// Otherwise a command is started per event, reading the payload from
+	// stdin until EOF.
+	Persistent bool
+	// Workers caps how many commands run at once. It defaults to 1.
+	Workers int
+	// Timeout bounds each event. It defaults to 30s.
+	Timeout time.Duration
+}
+
+// execProc is a running persistent command.
+type execProc struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+func (p *execProc) stop() {
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+	p.cmd.Wait()
+}
+
+// execSlot is one of the Workers commands, restarted after it crashes or
+// times out.
+type execSlot struct {
+	proc     *execProc
+	failures int
+}
+
+type Exec struct {
+	cfg   ExecConfig
+	slots chan *execSlot
+}
+
+// NewExec integrates an external command into flows, restarting
+// persistent commands that crash or time out. Close stops them.
+func NewExec(cfg ExecConfig) *Exec {
+	if cfg.Workers == 0 {
+		cfg.Workers = 1
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+
+	e := &Exec{cfg: cfg, slots: make(chan *execSlot, cfg.Workers)}
+	for range cfg.Workers {
+		e.slots <- new(execSlot)
+	}
+	return e
+}
+
+func (e *Exec) command(ctx context.Context) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, e.cfg.Path, e.cfg.Args...)
+	cmd.Dir = e.cfg.Dir
+	cmd.Env = e.cfg.Env
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+func (e *Exec) start() (*execProc, error) {
+	cmd := e.command(context.Background())
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &execProc{cmd, stdin, bufio.NewReader(stdout)}, nil
+}
+
+// restartDelay backs off restarts of a command that keeps failing.
+func restartDelay(ctx context.Context, failures int) error {
+	if failures == 0 {
+		return nil
+	}
+	t := chord.ClockFrom(ctx).NewTimer(min(100*time.Millisecond<<min(failures, 8), 10*time.Second))
+	defer t.Stop()
+
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (e *Exec) exchange(ctx context.Context, slot *execSlot, in []byte) ([]byte, error) {
+	if bytes.IndexByte(in, '\n') >= 0 {
+		return nil, errExecNewline
+	}
+
+	if slot.proc == nil {
+		if err := restartDelay(ctx, slot.failures); err != nil {
+			return nil, err
+		}
+		proc, err := e.start()
+		if err != nil {
+			slot.failures++
+			return nil, &ExecError{Err: err}
+		}
+		slot.proc = proc
+	}
+
+	type reply struct {
+		line []byte
+		err  error
+	}
+	// The exchange outlives a timeout until the killed command's pipes
+	// are closed, so it keeps its own reference to the process.
+	p := slot.proc
+	done := make(chan reply, 1)
+	go func() {
+		if _, err := p.stdin.Write(append(in[:len(in):len(in)], '\n')); err != nil {
+			done <- reply{err: err}
+			return
+		}
+		line, err := p.stdout.ReadBytes('\n')
+		done <- reply{line, err}
+	}()
+
+	var r reply
+	select {
+	case r = <-done:
+	case <-ctx.Done():
+		r.err = ctx.Err()
+	}
+
+	if r.err != nil {
+		slot.proc.stop()
+		slot.proc = nil
+		slot.failures++
+		return nil, &ExecError{Err: r.err}
+	}
+	slot.failures = 0
+	return bytes.TrimSuffix(bytes.TrimSuffix(r.line, []byte("\n")), []byte("\r")), nil
+}
+
+func (e *Exec) process(ctx context.Context, in []byte) ([]byte, error) {
+	var slot *execSlot
+	select {
+	case slot = <-e.slots:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { e.slots <- slot }()
+
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+
+	if e.cfg.Persistent {
+		return e.exchange(ctx, slot, in)
+	}
+
+	cmd := e.command(ctx)
+	cmd.Stdin = bytes.NewReader(in)
+	out, err := cmd.Output()
+	if err != nil {
+		ee := &ExecError{Err: err}
+		if exit, ok := err.(*exec.ExitError); ok {
+			ee.Stderr = exit.Stderr
+		}
+		return nil, ee
+	}
+	return out, nil
+}
+
+// Apply pipes each payload of s through the command, up to
+// ExecConfig.Workers at once. Outputs may be reordered.
+func (e *Exec) Apply(s chord.Stage[[]byte]) chord.Stage[[]byte] {
+	return chord.NewParallelStage(s, e.cfg.Workers, e.process)
+}
+
+// Close stops the persistent commands once the events they are processing
+// are done.
+func (e *Exec) Close() error {
+	for range e.cfg.Workers {
+		slot := <-e.slots
+		if slot.proc != nil {
+			slot.proc.stop()
+			slot.proc = nil
+		}
+		defer func() { e.slots <- slot }()
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package stage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types/ref"
+)
+
+// exprEnv declares the single variable expressions see: the event in its
+// JSON form, so fields are reached as event.user.id.
+var exprEnv = sync.OnceValues(func() (*cel.Env, error) {
+	return cel.NewEnv(cel.Variable("event", cel.DynType))
+})
+
+// mustCompileExpr compiles expr, panicking like regexp.MustCompile if it
+// is invalid, so the mistake surfaces when the flow is built.
+func mustCompileExpr(expr string) cel.Program {
+	env, err := exprEnv()
+	if err != nil {
+		panic(err)
+	}
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		panic(fmt.Sprintf("stage: expr %q: %v", expr, iss.Err()))
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		panic(fmt.Sprintf("stage: expr %q: %v", expr, err))
+	}
+	return prg
+}
+
+// exprInput converts an event to JSON values, decoding []byte payloads as
+// JSON documents.
+func exprInput(v any) (any, error) {
+	b, ok := v.([]byte)
+	if !ok {
+		var err error
+		if b, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	var in any
+	err := json.Unmarshal(b, &in)
+	return in, err
+}
+
+func evalExpr(ctx context.Context, prg cel.Program, v any) (ref.Val, error) {
+	in, err := exprInput(v)
+	if err != nil {
+		return nil, err
+	}
+	out, _, err := prg.ContextEval(ctx, map[string]any{"event": in})
+	return out, err
+}
+
+// Filter passes on the events for which the CEL expression is true, e.g.
+// `event.level == "error" && event.code >= 500`. Events it fails on go to
+// the error path; those it drops are acked. It panics if expr does not
+// compile.
+func Filter[T any](s chord.Stage[T], expr string) chord.Stage[T] {
+	prg := mustCompileExpr(expr)
+
+	return chord.NewProducer(context.Background(), func(_ context.Context, emit chord.Emit[T]) {
+		chord.NewConsumer(s,
+			func(ctx context.Context, v T) error {
+				out, err := evalExpr(ctx, prg, v)
+				if err != nil {
+					return err
+				}
+				keep, ok := out.Value().(bool)
+				if !ok {
+					return fmt.Errorf("stage: expr %q yields %s, not bool", expr, out.Type().TypeName())
+				}
+				if keep {
+					emit(ctx, v, nil)
+				} else {
+					chord.Ack(ctx)
+				}
+				return nil
+			},
+			func(ctx context.Context, err error) {
+				var zero T
+				emit(ctx, zero, err)
+			},
+		)
+	})
+}
+
+// Transform replaces each event with the result of the CEL expression,
+// e.g. `{"id": event.id, "total": event.price * event.qty}`, converted to
+// Out. It panics if expr does not compile.
+func Transform[In, Out any](s chord.Stage[In], expr string) chord.Stage[Out] {
+	prg := mustCompileExpr(expr)
+
+	return chord.NewStage(s, func(ctx context.Context, v In) (Out, error) {
+		var zero Out
+
+		out, err := evalExpr(ctx, prg, v)
+		if err != nil {
+			return zero, err
+		}
+
+		t := reflect.TypeFor[Out]()
+		if t.Kind() == reflect.Interface {
+			if o, ok := out.Value().(Out); ok {
+				return o, nil
+			}
+		}
+		native, err := out.ConvertToNative(t)
+		if err != nil {
+			return zero, fmt.Errorf("stage: expr %q: %w", expr, err)
+		}
+		return native.(Out), nil
+	})
+}
@@ -0,0 +1,90 @@
+package stage
+
+import (
+	"context"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/idempotency"
+)
+
+// IdempotencyStore remembers which event keys are in flight or processed.
+// See the idempotency package for implementations.
+type IdempotencyStore interface {
+	// Claim reserves key for lease, reporting false if it is already
+	// claimed or processed.
+	Claim(ctx context.Context, key string, lease time.Duration) (bool, error)
+	// Complete marks key as processed for ttl.
+	Complete(ctx context.Context, key string, ttl time.Duration) error
+	// Release drops the claim on key so a redelivery is processed.
+	Release(ctx context.Context, key string) error
+}
+
+type IdempotencyConfig[T any] struct {
+	// Key identifies an event across redeliveries, such as a message ID.
+	Key func(T) string
+	// Store defaults to idempotency.NewMemory(), which only covers
+	// redeliveries to the same process.
+	Store IdempotencyStore
+	// TTL is how long a processed key is remembered. It defaults to 24h.
+	TTL time.Duration
+	// Lease is how long an event may be in flight before a copy of it is
+	// processed again. It defaults to 5m.
+	Lease time.Duration
+}
+
+// idempotentAck records the outcome of an event in the store before
+// settling it with its source.
+type idempotentAck[T any] struct {
+	ctx context.Context
+	key string
+	cfg IdempotencyConfig[T]
+}
+
+func (a idempotentAck[T]) Ack() {
+	a.cfg.Store.Complete(context.WithoutCancel(a.ctx), a.key, a.cfg.TTL)
+	chord.Ack(a.ctx)
+}
+
+func (a idempotentAck[T]) Nack() {
+	a.cfg.Store.Release(context.WithoutCancel(a.ctx), a.key)
+	chord.Nack(a.ctx)
+}
+
+// Idempotent skips events whose key has already been processed, acking
+// them, for effectively-once processing on top of at-least-once triggers.
+// A key counts as processed once RunFlow acks its event after OnSuccess;
+// if the event fails instead, its claim is released for the redelivery.
+func Idempotent[T any](s chord.Stage[T], cfg IdempotencyConfig[T]) chord.Stage[T] {
+	if cfg.Store == nil {
+		cfg.Store = idempotency.NewMemory()
+	}
+	if cfg.TTL == 0 {
+		cfg.TTL = 24 * time.Hour
+	}
+	if cfg.Lease == 0 {
+		cfg.Lease = 5 * time.Minute
+	}
+
+	return chord.NewProducer(context.Background(), func(_ context.Context, emit chord.Emit[T]) {
+		chord.NewConsumer(s,
+			func(ctx context.Context, v T) error {
+				key := cfg.Key(v)
+				ok, err := cfg.Store.Claim(ctx, key, cfg.Lease)
+				if err != nil {
+					return err
+				}
+				if ok {
+					emit(chord.WithAcker(ctx, idempotentAck[T]{ctx, key, cfg}), v, nil)
+				} else {
+					chord.Ack(ctx)
+				}
+				return nil
+			},
+			func(ctx context.Context, err error) {
+				var zero T
+				emit(ctx, zero, err)
+			},
+		)
+	})
+}
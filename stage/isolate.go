@@ -0,0 +1,272 @@
+package stage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+var (
+	// ErrTenantOverloaded fails events of a tenant whose queue is full.
+	// They are failed at once, so a source redelivering nacked events
+	// immediately retries them as fast as it can while the tenant floods.
+	ErrTenantOverloaded = errors.New("stage: tenant queue full")
+	// ErrTenantSuspended fails events of a tenant that exhausted its error
+	// budget, until its cooldown ends. They wait in the tenant's queue and
+	// are failed when the cooldown ends, so their redelivery is not
+	// rejected again; once the queue is full, they fail with
+	// ErrTenantOverloaded instead.
+	ErrTenantSuspended = errors.New("stage: tenant error budget exhausted")
+)
+
+type IsolationConfig[T any] struct {
+	// Key identifies the tenant of an event.
+	Key func(T) string
+	// Queue is the number of events a tenant may have waiting. Further
+	// events fail with ErrTenantOverloaded, so the source can redeliver
+	// them later, instead of holding up other tenants. It defaults to 100.
+	Queue int
+	// Workers is the number of events of a tenant processed concurrently.
+	// It defaults to 1, which keeps each tenant's events in order.
+	Workers int
+	// Rate caps the events of a tenant processed per second. Zero does not
+	// limit them.
+	Rate float64
+	// Errors is the error budget: once a tenant's events fail Errors times
+	// within Window, anywhere in the flow, its events fail with
+	// ErrTenantSuspended for Cooldown. Zero disables the budget.
+	Errors int
+	// Window defaults to 1m.
+	Window time.Duration
+	// Cooldown defaults to Window.
+	Cooldown time.Duration
+	// Idle is how long a tenant without events keeps its workers. It
+	// defaults to 5m.
+	Idle time.Duration
+}
+
+type tenantEvent[T any] struct {
+	ctx context.Context
+	v   T
+	// suspended events are failed once the tenant's cooldown ends.
+	suspended bool
+}
+
+type tenant[T any] struct {
+	queue chan tenantEvent[T]
+	// pending counts the events queued or in process and idle is when it
+	// last dropped to zero, both guarded by the tenants' mutex.
+	pending int
+	idle    time.Time
+
+	mu        sync.Mutex
+	next      time.Time
+	failures  []time.Time
+	suspended time.Time
+}
+
+// reserve returns how long to wait before processing the next event to stay
+// within rate.
+func (t *tenant[T]) reserve(now time.Time, rate float64) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	at := now
+	if t.next.After(now) {
+		at = t.next
+	}
+	t.next = at.Add(time.Duration(float64(time.Second) / rate))
+	return at.Sub(now)
+}
+
+func (t *tenant[T]) fail(now time.Time, errs int, window, cooldown time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	i := 0
+	for i < len(t.failures) && now.Sub(t.failures[i]) >= window {
+		i++
+	}
+	t.failures = append(t.failures[i:], now)
+	if len(t.failures) >= errs {
+		t.suspended = now.Add(cooldown)
+		t.failures = nil
+	}
+}
+
+// suspendedFor returns how long the tenant remains suspended.
+func (t *tenant[T]) suspendedFor(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return max(t.suspended.Sub(now), 0)
+}
+
+// tenantAck charges a failed event to its tenant's error budget before
+// settling it with its source.
+type tenantAck[T any] struct {
+	ctx context.Context
+	t   *tenant[T]
+	cfg IsolationConfig[T]
+}
+
+func (a tenantAck[T]) Ack() { chord.Ack(a.ctx) }
+
+func (a tenantAck[T]) Nack() {
+	a.t.fail(chord.ClockFrom(a.ctx).Now(), a.cfg.Errors, a.cfg.Window, a.cfg.Cooldown)
+	chord.Nack(a.ctx)
+}
+
+// Isolate runs fn with each tenant of a shared flow isolated from the
+// others: every tenant has its own bounded queue and workers, and
+// optionally its own rate limit and error budget, so a tenant flooding the
+// flow or failing persistently cannot starve the rest. A tenant's workers
+// start with its first event and stop once it has been idle for Idle.
+// Isolate panics if Queue, Workers or Rate is negative.
+func Isolate[In, Out any](s chord.Stage[In], cfg IsolationConfig[In], fn func(context.Context, In) (Out, error)) chord.Stage[Out] {
+	if cfg.Queue < 0 || cfg.Workers < 0 || cfg.Rate < 0 {
+		panic("stage: isolation Queue, Workers and Rate must not be negative")
+	}
+	if cfg.Queue == 0 {
+		cfg.Queue = 100
+	}
+	if cfg.Workers == 0 {
+		cfg.Workers = 1
+	}
+	if cfg.Window == 0 {
+		cfg.Window = time.Minute
+	}
+	if cfg.Cooldown == 0 {
+		cfg.Cooldown = cfg.Window
+	}
+	if cfg.Idle == 0 {
+		cfg.Idle = 5 * time.Minute
+	}
+
+	return chord.NewProducer(context.Background(), func(_ context.Context, emit chord.Emit[Out]) {
+		var (
+			wg      sync.WaitGroup
+			zero    Out
+			mu      sync.Mutex
+			tenants = make(map[string]*tenant[In])
+
+			// Idle tenants are reaped on the clock of the first event.
+			clock chord.Clock
+			done  = make(chan struct{})
+		)
+
+		// sleep waits for d on the clock of ctx, reporting false if ctx is
+		// done first.
+		sleep := func(ctx context.Context, d time.Duration) bool {
+			timer := chord.ClockFrom(ctx).NewTimer(d)
+			defer timer.Stop()
+			select {
+			case <-timer.C():
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		process := func(key string, t *tenant[In], ev tenantEvent[In]) {
+			ctx := ev.ctx
+			now := chord.ClockFrom(ctx).Now()
+			if ev.suspended {
+				sleep(ctx, t.suspendedFor(now))
+				emit(ctx, zero, fmt.Errorf("%w: %s", ErrTenantSuspended, key))
+				return
+			}
+			if cfg.Rate > 0 {
+				if d := t.reserve(now, cfg.Rate); d > 0 && !sleep(ctx, d) {
+					emit(ctx, zero, context.Cause(ctx))
+					return
+				}
+			}
+
+			if cfg.Errors > 0 {
+				ctx = chord.WithAcker(ctx, tenantAck[In]{ev.ctx, t, cfg})
+			}
+			out, err := fn(ctx, ev.v)
+			emit(ctx, out, err)
+		}
+
+		work := func(key string, t *tenant[In]) {
+			for ev := range t.queue {
+				process(key, t, ev)
+
+				mu.Lock()
+				if t.pending--; t.pending == 0 {
+					t.idle = clock.Now()
+				}
+				mu.Unlock()
+			}
+		}
+
+		reap := func() {
+			tick := clock.NewTicker(cfg.Idle)
+			defer tick.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-tick.C():
+				}
+
+				mu.Lock()
+				now := clock.Now()
+				for key, t := range tenants {
+					if t.pending == 0 && now.Sub(t.idle) >= cfg.Idle && t.suspendedFor(now) == 0 {
+						close(t.queue)
+						delete(tenants, key)
+					}
+				}
+				mu.Unlock()
+			}
+		}
+
+		chord.NewConsumer(s,
+			func(ctx context.Context, v In) error {
+				key := cfg.Key(v)
+
+				mu.Lock()
+				if clock == nil {
+					clock = chord.ClockFrom(ctx)
+					wg.Go(reap)
+				}
+				t, ok := tenants[key]
+				if !ok {
+					t = &tenant[In]{queue: make(chan tenantEvent[In], cfg.Queue)}
+					tenants[key] = t
+					for range cfg.Workers {
+						wg.Go(func() { work(key, t) })
+					}
+				}
+
+				suspended := cfg.Errors > 0 && t.suspendedFor(chord.ClockFrom(ctx).Now()) > 0
+				select {
+				case t.queue <- tenantEvent[In]{ctx, v, suspended}:
+					t.pending++
+					mu.Unlock()
+				default:
+					mu.Unlock()
+					emit(ctx, zero, fmt.Errorf("%w: %s", ErrTenantOverloaded, key))
+				}
+				return nil
+			},
+			func(ctx context.Context, err error) {
+				emit(ctx, zero, err)
+			},
+		)
+
+		close(done)
+		mu.Lock()
+		for _, t := range tenants {
+			close(t.queue)
+		}
+		mu.Unlock()
+		wg.Wait()
+	})
+}
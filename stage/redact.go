@@ -0,0 +1,184 @@
+package stage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Common patterns for RedactConfig.Patterns.
+var (
+	EmailPattern      = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	CardNumberPattern = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+)
+
+type RedactConfig struct {
+	// Fields names struct fields, JSON keys and map keys to mask wherever
+	// they appear, ignoring case. Struct fields tagged `redact:""` are
+	// masked as well.
+	Fields []string
+	// Patterns masks matches within every string.
+	Patterns []*regexp.Regexp
+	// Mask replaces masked strings. It defaults to "[REDACTED]". Masked
+	// values of other types are zeroed.
+	Mask string
+}
+
+type redactor struct {
+	fields   map[string]bool
+	patterns []*regexp.Regexp
+	mask     string
+}
+
+func (r redactor) field(name string) bool {
+	return r.fields[strings.ToLower(name)]
+}
+
+func (r redactor) structField(f reflect.StructField) bool {
+	if _, ok := f.Tag.Lookup("redact"); ok {
+		return true
+	}
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return r.field(f.Name) || name != "" && r.field(name)
+}
+
+func (r redactor) string(s string) string {
+	for _, p := range r.patterns {
+		s = p.ReplaceAllString(s, r.mask)
+	}
+	return s
+}
+
+var numberType = reflect.TypeFor[json.Number]()
+
+// copy returns a redacted deep copy of v, leaving v untouched since it may
+// be shared with other stages.
+func (r redactor) copy(v reflect.Value, masked bool) reflect.Value {
+	if masked {
+		switch {
+		case v.Kind() == reflect.String && v.Type() != numberType:
+			return reflect.ValueOf(r.mask).Convert(v.Type())
+		case v.Kind() == reflect.Interface && !v.IsNil() && v.Elem().Kind() == reflect.String:
+			out := reflect.New(v.Type()).Elem()
+			out.Set(reflect.ValueOf(r.mask))
+			return out
+		}
+		return reflect.Zero(v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if v.Type() == numberType {
+			return v
+		}
+		return reflect.ValueOf(r.string(v.String())).Convert(v.Type())
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(r.copy(v.Elem(), false))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		// A number a pattern matches in becomes the masked string, since
+		// the mask is no valid json.Number.
+		if e := v.Elem(); e.Type() == numberType {
+			if s := r.string(e.String()); s != e.String() {
+				out.Set(reflect.ValueOf(s))
+				return out
+			}
+		}
+		out.Set(r.copy(v.Elem(), false))
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := range v.NumField() {
+			if f := v.Type().Field(i); f.IsExported() {
+				out.Field(i).Set(r.copy(v.Field(i), r.structField(f)))
+			}
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := range v.Len() {
+			out.Index(i).Set(r.copy(v.Index(i), false))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := range v.Len() {
+			out.Index(i).Set(r.copy(v.Index(i), false))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for it := v.MapRange(); it.Next(); {
+			k := it.Key()
+			masked := k.Kind() == reflect.String && r.field(k.String())
+			out.SetMapIndex(k, r.copy(it.Value(), masked))
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// redactJson masks a JSON document, keeping numbers as they were written.
+func (r redactor) redactJson(b []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var doc any
+	if err := d.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return b, nil
+	}
+	return json.Marshal(r.copy(reflect.ValueOf(doc), false).Interface())
+}
+
+// Redact masks sensitive data before it reaches sinks or logs. Events are
+// copied rather than modified; []byte payloads are treated as JSON
+// documents.
+func Redact[T any](s chord.Stage[T], cfg RedactConfig) chord.Stage[T] {
+	r := redactor{
+		fields:   make(map[string]bool, len(cfg.Fields)),
+		patterns: cfg.Patterns,
+		mask:     cfg.Mask,
+	}
+	for _, f := range cfg.Fields {
+		r.fields[strings.ToLower(f)] = true
+	}
+	if r.mask == "" {
+		r.mask = "[REDACTED]"
+	}
+
+	return chord.NewStage(s, func(_ context.Context, v T) (T, error) {
+		if b, ok := any(v).([]byte); ok {
+			out, err := r.redactJson(b)
+			return any(out).(T), err
+		}
+
+		// A nil interface copies to nil, which the assertion would reject.
+		rv := reflect.ValueOf(&v).Elem()
+		out, _ := r.copy(rv, false).Interface().(T)
+		return out, nil
+	})
+}
@@ -0,0 +1,80 @@
+package stage
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+)
+
+type sagaKey struct{}
+
+// sagaLog holds the compensations of the steps an event has completed.
+type sagaLog struct {
+	ctx  context.Context
+	mu   sync.Mutex
+	undo []func(context.Context) error
+}
+
+func (l *sagaLog) add(fn func(context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.undo = append(l.undo, fn)
+}
+
+func (l *sagaLog) Ack() {
+	chord.Ack(l.ctx)
+}
+
+// Nack compensates the completed steps, latest first, before passing the
+// nack on to the event's source.
+func (l *sagaLog) Nack() {
+	l.mu.Lock()
+	undo := l.undo
+	l.undo = nil
+	l.mu.Unlock()
+
+	ctx := context.WithoutCancel(l.ctx)
+	for i := len(undo) - 1; i >= 0; i-- {
+		if err := undo[i](ctx); err != nil {
+			log.Printf("chord: saga compensation: %v", err)
+		}
+	}
+	chord.Nack(l.ctx)
+}
+
+// Saga starts a saga for each event of s: when an event fails anywhere
+// after it, RunFlow's nack runs the compensations of the Steps the event
+// completed in reverse order. Failed compensations are logged.
+func Saga[T any](s chord.Stage[T]) chord.Stage[T] {
+	return chord.NewProducer(context.Background(), func(_ context.Context, emit chord.Emit[T]) {
+		chord.NewConsumer(s,
+			func(ctx context.Context, v T) error {
+				l := &sagaLog{ctx: ctx}
+				emit(context.WithValue(chord.WithAcker(ctx, l), sagaKey{}, l), v, nil)
+				return nil
+			},
+			func(ctx context.Context, err error) {
+				var zero T
+				emit(ctx, zero, err)
+			},
+		)
+	})
+}
+
+// Step runs do for each event and, once it succeeds, registers compensate
+// with the event's saga to undo it with do's output if a later stage
+// fails. Events not started with Saga are not compensated.
+func Step[In, Out any](s chord.Stage[In], do func(context.Context, In) (Out, error), compensate func(context.Context, Out) error) chord.Stage[Out] {
+	return chord.NewStage(s, func(ctx context.Context, v In) (Out, error) {
+		out, err := do(ctx, v)
+		if err != nil {
+			return out, err
+		}
+		if l, ok := ctx.Value(sagaKey{}).(*sagaLog); ok {
+			l.add(func(ctx context.Context) error { return compensate(ctx, out) })
+		}
+		return out, nil
+	})
+}
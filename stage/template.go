@@ -0,0 +1,109 @@
+package stage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"path/filepath"
+	"sync"
+	"text/template"
+
+	"github.com/0x180db/go-chord"
+)
+
+type TemplateConfig[T any] struct {
+	// Text is the template source. Glob instead parses every matching file
+	// into one set, each template named after its file.
+	Text string
+	Glob string
+	// Name picks the template of the set to render an event with. It
+	// defaults to the first one parsed.
+	Name func(T) string
+	// Funcs are available to templates in addition to json, which
+	// renders its argument as JSON.
+	Funcs map[string]any
+	// HTML escapes output with html/template.
+	HTML bool
+	// Strict fails events whose data lacks a key the template uses.
+	Strict bool
+}
+
+type templateSet interface {
+	ExecuteTemplate(w io.Writer, name string, data any) error
+	Name() string
+}
+
+var renderBufs = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+func templateJson(v any) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+func parseTemplates[T any](cfg TemplateConfig[T]) (templateSet, error) {
+	funcs := map[string]any{"json": templateJson}
+	for k, f := range cfg.Funcs {
+		funcs[k] = f
+	}
+	missing := "missingkey=default"
+	if cfg.Strict {
+		missing = "missingkey=error"
+	}
+
+	name := "template"
+	var files []string
+	if cfg.Glob != "" {
+		var err error
+		if files, err = filepath.Glob(cfg.Glob); err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("stage: template glob %q matches no files", cfg.Glob)
+		}
+		name = filepath.Base(files[0])
+	}
+
+	if cfg.HTML {
+		t := htmltemplate.New(name).Funcs(funcs).Option(missing)
+		if files != nil {
+			return t.ParseFiles(files...)
+		}
+		return t.Parse(cfg.Text)
+	}
+
+	t := template.New(name).Funcs(funcs).Option(missing)
+	if files != nil {
+		return t.ParseFiles(files...)
+	}
+	return t.Parse(cfg.Text)
+}
+
+// Render renders each event with a text or HTML template, for sinks that
+// send notifications or write files. Templates are parsed once, when the
+// flow is built.
+func Render[T any](s chord.Stage[T], cfg TemplateConfig[T]) chord.Stage[[]byte] {
+	t, perr := parseTemplates(cfg)
+
+	return chord.NewStage(s, func(_ context.Context, v T) ([]byte, error) {
+		if perr != nil {
+			return nil, perr
+		}
+
+		name := t.Name()
+		if cfg.Name != nil {
+			name = cfg.Name(v)
+		}
+
+		buf := renderBufs.Get().(*bytes.Buffer)
+		defer renderBufs.Put(buf)
+		buf.Reset()
+
+		if err := t.ExecuteTemplate(buf, name, v); err != nil {
+			return nil, err
+		}
+		return bytes.Clone(buf.Bytes()), nil
+	})
+}
@@ -0,0 +1,39 @@
+package stage
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/0x180db/go-chord"
+)
+
+// InTx runs fn in a transaction per event, which fn and the code it calls
+// get with chord.TxFrom. The transaction is committed if fn succeeds and
+// rolled back if it fails or panics. For a transaction per batch, run it
+// over a stage of slices.
+func InTx[In, Out any](s chord.Stage[In], db *sql.DB, opts *sql.TxOptions, fn func(context.Context, In) (Out, error)) chord.Stage[Out] {
+	return chord.NewStage(s, func(ctx context.Context, v In) (Out, error) {
+		var zero Out
+
+		tx, err := db.BeginTx(ctx, opts)
+		if err != nil {
+			return zero, err
+		}
+		defer func() {
+			if p := recover(); p != nil {
+				tx.Rollback()
+				panic(p)
+			}
+		}()
+
+		out, err := fn(chord.WithTx(ctx, tx), v)
+		if err != nil {
+			tx.Rollback()
+			return zero, err
+		}
+		if err := tx.Commit(); err != nil {
+			return zero, err
+		}
+		return out, nil
+	})
+}
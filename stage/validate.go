@@ -0,0 +1,105 @@
+package stage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"buf.build/go/protovalidate"
+	"github.com/0x180db/go-chord"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"google.golang.org/protobuf/proto"
+)
+
+type Violation struct {
+	// Path locates the offending value, as a JSON pointer for JSON Schema
+	// and a field path for protobuf.
+	Path    string
+	Message string
+}
+
+// ValidationError is routed to the error path for a payload that does not
+// match its schema, listing every violation found.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Path + ": " + v.Message
+	}
+	return "stage: invalid payload: " + strings.Join(msgs, "; ")
+}
+
+// ValidateJson passes on JSON payloads that match the JSON Schema in
+// schema.
+func ValidateJson(s chord.Stage[[]byte], schema string) chord.Stage[[]byte] {
+	sch, cerr := compileSchema(schema)
+
+	return chord.NewStage(s, func(_ context.Context, b []byte) ([]byte, error) {
+		if cerr != nil {
+			return nil, cerr
+		}
+
+		inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+
+		var ve *jsonschema.ValidationError
+		if err := sch.Validate(inst); errors.As(err, &ve) {
+			return nil, jsonViolations(ve)
+		} else if err != nil {
+			return nil, err
+		}
+		return b, nil
+	})
+}
+
+func compileSchema(schema string) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("stage: schema: %w", err)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("schema.json", doc); err != nil {
+		return nil, err
+	}
+	return c.Compile("schema.json")
+}
+
+func jsonViolations(ve *jsonschema.ValidationError) *ValidationError {
+	var out ValidationError
+	for _, u := range ve.BasicOutput().Errors {
+		if u.Error != nil {
+			out.Violations = append(out.Violations, Violation{Path: u.InstanceLocation, Message: u.Error.String()})
+		}
+	}
+	return &out
+}
+
+// ValidateProto passes on messages that satisfy their protovalidate
+// constraints.
+func ValidateProto[T proto.Message](s chord.Stage[T]) chord.Stage[T] {
+	return chord.NewStage(s, func(_ context.Context, m T) (T, error) {
+		err := protovalidate.Validate(m)
+
+		var ve *protovalidate.ValidationError
+		if !errors.As(err, &ve) {
+			return m, err
+		}
+
+		out := &ValidationError{Violations: make([]Violation, len(ve.Violations))}
+		for i, v := range ve.Violations {
+			out.Violations[i] = Violation{
+				Path:    protovalidate.FieldPathString(v.Proto.GetField()),
+				Message: v.Proto.GetMessage(),
+			}
+		}
+		return m, out
+	})
+}
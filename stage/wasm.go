@@ -0,0 +1,256 @@
+package stage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+var errWasmABI = errors.New("stage: wasm module must export memory, alloc and process")
+
+// WasmError is routed to the error path when the module reports a failure
+// through chord.error.
+type WasmError struct {
+	Message string
+}
+
+func (e *WasmError) Error() string { return "stage: wasm: " + e.Message }
+
+type WasmConfig struct {
+	// Instances caps how many module instances process events at once.
+	// It defaults to 1.
+	Instances int
+	// MaxMemoryPages caps each instance's memory in 64KiB pages. It
+	// defaults to 256 (16MiB).
+	MaxMemoryPages uint32
+}
+
+// wasmModule is one loaded version of the module with its instances,
+// which are created on demand and reused.
+type wasmModule struct {
+	compiled wazero.CompiledModule
+	pool     chan api.Module
+	// retired is closed once a newer version is loaded, so calls waiting
+	// for an instance move on to it.
+	retired chan struct{}
+}
+
+// retire closes the instances once they are all back in the pool.
+func (m *wasmModule) retire(ctx context.Context) {
+	for range cap(m.pool) {
+		if inst := <-m.pool; inst != nil {
+			inst.Close(ctx)
+		}
+	}
+	m.compiled.Close(ctx)
+}
+
+// wasmCall collects what the module reports for the event being processed.
+type wasmCall struct {
+	err *WasmError
+}
+
+type wasmCallKey struct{}
+
+// Wasm runs each event through a sandboxed WebAssembly module. The module
+// implements this ABI:
+//
+//	alloc(size i32) i32            reserve size bytes for the input
+//	process(ptr, len i32) i64      handle the input, return ptr<<32 | len of the output
+//	free(ptr, len i32)             optional, release the output once copied
+//
+// and may import chord.error(ptr, len i32) to fail the event with a
+// message and chord.log(ptr, len i32) to log one. WASI is available, so
+// reactors built with TinyGo or Rust work as is.
+type Wasm struct {
+	rt  wazero.Runtime
+	cfg WasmConfig
+
+	mu  sync.RWMutex
+	cur *wasmModule
+}
+
+func NewWasm(ctx context.Context, module []byte, cfg WasmConfig) (*Wasm, error) {
+	if cfg.Instances == 0 {
+		cfg.Instances = 1
+	}
+	if cfg.MaxMemoryPages == 0 {
+		cfg.MaxMemoryPages = 256
+	}
+
+	rt := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(cfg.MaxMemoryPages).
+		WithCloseOnContextDone(true))
+
+	w := &Wasm{rt: rt, cfg: cfg}
+	if err := w.init(ctx); err != nil {
+		rt.Close(ctx)
+		return nil, err
+	}
+	if err := w.Reload(ctx, module); err != nil {
+		rt.Close(ctx)
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Wasm) init(ctx context.Context) error {
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, w.rt); err != nil {
+		return err
+	}
+
+	_, err := w.rt.NewHostModuleBuilder("chord").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, n uint32) {
+			c, ok := ctx.Value(wasmCallKey{}).(*wasmCall)
+			if !ok {
+				return
+			}
+			msg, _ := m.Memory().Read(ptr, n)
+			c.err = &WasmError{Message: string(msg)}
+		}).
+		Export("error").
+		NewFunctionBuilder().
+		WithFunc(func(_ context.Context, m api.Module, ptr, n uint32) {
+			msg, _ := m.Memory().Read(ptr, n)
+			log.Printf("chord: wasm: %s", msg)
+		}).
+		Export("log").
+		Instantiate(ctx)
+	return err
+}
+
+// Reload swaps in a new version of the module. Events already being
+// processed finish on the old one, and those waiting for an instance move
+// on to the new one.
+func (w *Wasm) Reload(ctx context.Context, module []byte) error {
+	compiled, err := w.rt.CompileModule(ctx, module)
+	if err != nil {
+		return err
+	}
+	if _, ok := compiled.ExportedMemories()["memory"]; !ok {
+		compiled.Close(ctx)
+		return errWasmABI
+	}
+	fns := compiled.ExportedFunctions()
+	if fns["alloc"] == nil || fns["process"] == nil {
+		compiled.Close(ctx)
+		return errWasmABI
+	}
+
+	m := &wasmModule{compiled: compiled, pool: make(chan api.Module, w.cfg.Instances), retired: make(chan struct{})}
+	for range w.cfg.Instances {
+		m.pool <- nil
+	}
+
+	w.mu.Lock()
+	old := w.cur
+	w.cur = m
+	w.mu.Unlock()
+
+	if old != nil {
+		close(old.retired)
+		go old.retire(context.WithoutCancel(ctx))
+	}
+	return nil
+}
+
+// Close releases the runtime and every module instance.
+func (w *Wasm) Close(ctx context.Context) error {
+	return w.rt.Close(ctx)
+}
+
+func (w *Wasm) module() *wasmModule {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.cur
+}
+
+// acquire takes an instance slot of the current module, moving on to a
+// newer one if the module is reloaded while it waits.
+func (w *Wasm) acquire(ctx context.Context) (*wasmModule, api.Module, error) {
+	for {
+		m := w.module()
+		select {
+		case inst := <-m.pool:
+			return m, inst, nil
+		case <-m.retired:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+}
+
+func (w *Wasm) process(ctx context.Context, in []byte) ([]byte, error) {
+	m, inst, err := w.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if inst == nil {
+		inst, err = w.rt.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().
+			WithName("").
+			WithStartFunctions("_initialize"))
+		if err != nil {
+			m.pool <- nil
+			return nil, err
+		}
+	}
+
+	out, err := call(ctx, inst, in)
+
+	// A trap can leave the instance in any state, so it is replaced.
+	var we *WasmError
+	if err != nil && !errors.As(err, &we) {
+		inst.Close(ctx)
+		inst = nil
+	}
+	m.pool <- inst
+	return out, err
+}
+
+func call(ctx context.Context, inst api.Module, in []byte) ([]byte, error) {
+	res, err := inst.ExportedFunction("alloc").Call(ctx, uint64(len(in)))
+	if err != nil {
+		return nil, err
+	}
+	ptr := uint32(res[0])
+	if !inst.Memory().Write(ptr, in) {
+		return nil, errors.New("stage: wasm alloc returned memory out of range")
+	}
+
+	c := new(wasmCall)
+	res, err = inst.ExportedFunction("process").Call(context.WithValue(ctx, wasmCallKey{}, c), uint64(ptr), uint64(len(in)))
+	if err != nil {
+		return nil, err
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	optr, olen := uint32(res[0]>>32), uint32(res[0])
+	b, ok := inst.Memory().Read(optr, olen)
+	if !ok {
+		return nil, errors.New("stage: wasm process returned memory out of range")
+	}
+	out := append([]byte(nil), b...)
+
+	if free := inst.ExportedFunction("free"); free != nil {
+		if _, err := free.Call(ctx, uint64(optr), uint64(olen)); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Apply runs each payload of s through the module, up to
+// WasmConfig.Instances at once. Outputs may be reordered.
+func (w *Wasm) Apply(s chord.Stage[[]byte]) chord.Stage[[]byte] {
+	return chord.NewParallelStage(s, w.cfg.Instances, w.process)
+}
@@ -0,0 +1,271 @@
+package stage
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Window is the events of one key whose event time falls in [Start, End).
+// A window fires again with the late events it receives, so sinks should
+// upsert windows by Key and Start.
+type Window[K comparable, T any] struct {
+	Key    K
+	Start  time.Time
+	End    time.Time
+	Events []T
+}
+
+type WindowConfig[K comparable, T any] struct {
+	// Time returns an event's timestamp.
+	Time func(T) time.Time
+	// Key splits events into windows per key. Without it all events share
+	// the zero key.
+	Key func(T) K
+	// Size is the length of a window. Slide, when shorter, starts a window
+	// every Slide so windows overlap; it defaults to Size. Both must be
+	// positive.
+	Size  time.Duration
+	Slide time.Duration
+	// MaxDelay is how far the watermark trails the latest event time, i.e.
+	// how out of order events may arrive and still be on time. A window
+	// fires once the watermark passes its end.
+	MaxDelay time.Duration
+	// AllowedLateness keeps fired windows around this much longer; events
+	// arriving for them fire them again, updated.
+	AllowedLateness time.Duration
+	// Late is the side output for events arriving after all their windows
+	// are gone. Without it they are dropped. Either way they are acked.
+	Late func(context.Context, T)
+	// Idle, if set, moves the watermark on with processing time once no
+	// event has arrived for this long, so the last windows fire even if
+	// the input goes quiet.
+	Idle time.Duration
+}
+
+type windowID[K comparable] struct {
+	key   K
+	start int64
+}
+
+type windowState[K comparable, T any] struct {
+	Window[K, T]
+	ctx     context.Context
+	fired   bool
+	pending []context.Context
+}
+
+// windowAck settles the events a window firing covers.
+type windowAck []context.Context
+
+func (a windowAck) Ack() {
+	for _, ctx := range a {
+		chord.Ack(ctx)
+	}
+}
+
+func (a windowAck) Nack() {
+	for _, ctx := range a {
+		chord.Nack(ctx)
+	}
+}
+
+type windower[K comparable, T any] struct {
+	cfg  WindowConfig[K, T]
+	wins map[windowID[K]]*windowState[K, T]
+	// watermark is the event time up to which all events are assumed to
+	// have arrived.
+	watermark time.Time
+	// due is the earliest time at which a window fires or is dropped.
+	due time.Time
+}
+
+// starts returns the start of every window t falls in, latest first.
+func (w *windower[K, T]) starts(t time.Time) []time.Time {
+	var out []time.Time
+	for s := t.Truncate(w.cfg.Slide); s.After(t.Add(-w.cfg.Size)); s = s.Add(-w.cfg.Slide) {
+		out = append(out, s)
+	}
+	return out
+}
+
+// add puts v in its windows, reporting false if they are all gone. With
+// sliding windows, each window gets its own share of the event's ack.
+func (w *windower[K, T]) add(ctx context.Context, v T, emit chord.Emit[Window[K, T]]) bool {
+	t := w.cfg.Time(v)
+	var key K
+	if w.cfg.Key != nil {
+		key = w.cfg.Key(v)
+	}
+
+	var starts []time.Time
+	for _, start := range w.starts(t) {
+		end := start.Add(w.cfg.Size)
+		if w.watermark.IsZero() || w.watermark.Before(end.Add(w.cfg.AllowedLateness)) {
+			starts = append(starts, start)
+		}
+	}
+	ctxs := chord.ForkAcks(ctx, len(starts))
+
+	for i, start := range starts {
+		ctx, end := ctxs[i], start.Add(w.cfg.Size)
+		id := windowID[K]{key, start.UnixNano()}
+		ws := w.wins[id]
+		if ws == nil {
+			ws = &windowState[K, T]{Window: Window[K, T]{Key: key, Start: start, End: end}, ctx: ctx}
+			w.wins[id] = ws
+			if w.due.IsZero() || end.Before(w.due) {
+				w.due = end
+			}
+		}
+		ws.Events = append(ws.Events, v)
+		ws.pending = append(ws.pending, ctx)
+
+		if ws.fired || !w.watermark.IsZero() && !w.watermark.Before(end) {
+			w.fire(ws, emit)
+		}
+	}
+	return len(starts) > 0
+}
+
+func (w *windower[K, T]) fire(ws *windowState[K, T], emit chord.Emit[Window[K, T]]) {
+	win := ws.Window
+	win.Events = slices.Clone(ws.Events)
+
+	ack := windowAck(ws.pending)
+	ws.pending = nil
+	ws.fired = true
+	emit(chord.WithAcker(ws.ctx, ack), win, nil)
+}
+
+// advance fires the windows the watermark has passed and drops those past
+// their allowed lateness. A zero watermark flushes every window.
+func (w *windower[K, T]) advance(wm time.Time, emit chord.Emit[Window[K, T]]) {
+	flush := wm.IsZero()
+	if !flush && (w.due.IsZero() || wm.Before(w.due)) {
+		return
+	}
+
+	var ready []*windowState[K, T]
+	w.due = time.Time{}
+	for id, ws := range w.wins {
+		expire := ws.End.Add(w.cfg.AllowedLateness)
+		if flush || !wm.Before(expire) {
+			delete(w.wins, id)
+			if !ws.fired {
+				ready = append(ready, ws)
+			}
+			continue
+		}
+
+		next := expire
+		switch {
+		case !ws.fired && !wm.Before(ws.End):
+			ready = append(ready, ws)
+		case !ws.fired:
+			next = ws.End
+		}
+		if w.due.IsZero() || next.Before(w.due) {
+			w.due = next
+		}
+	}
+
+	slices.SortFunc(ready, func(a, b *windowState[K, T]) int {
+		return cmp.Or(a.End.Compare(b.End), a.Start.Compare(b.Start))
+	})
+	for _, ws := range ready {
+		w.fire(ws, emit)
+	}
+}
+
+// Windows groups events into tumbling or sliding event-time windows per
+// key. A watermark trailing the latest event time by MaxDelay decides when
+// a window is complete, so out-of-order events still land in the right
+// window; windows still open when the input ends are fired then. An event
+// is acked once every window it is in is acked, and nacked with the first
+// of them nacked. Windows panics if Size or Slide is not positive.
+func Windows[K comparable, T any](s chord.Stage[T], cfg WindowConfig[K, T]) chord.Stage[Window[K, T]] {
+	if cfg.Slide == 0 {
+		cfg.Slide = cfg.Size
+	}
+	if cfg.Size <= 0 || cfg.Slide <= 0 {
+		panic("stage: window Size and Slide must be positive")
+	}
+
+	return chord.NewProducer(context.Background(), func(_ context.Context, emit chord.Emit[Window[K, T]]) {
+		var (
+			mu sync.Mutex
+			w  = &windower[K, T]{cfg: cfg, wins: make(map[windowID[K]]*windowState[K, T])}
+
+			// The idle timer starts with the first event, on its clock.
+			clock    chord.Clock
+			last     time.Time
+			lastMark time.Time
+			done     = make(chan struct{})
+			wg       sync.WaitGroup
+		)
+		idle := func() {
+			tick := clock.NewTicker(cfg.Idle)
+			defer tick.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-tick.C():
+				}
+
+				mu.Lock()
+				if d := clock.Now().Sub(last); d >= cfg.Idle {
+					if wm := lastMark.Add(d); wm.After(w.watermark) {
+						w.watermark = wm
+						w.advance(wm, emit)
+					}
+				}
+				mu.Unlock()
+			}
+		}
+
+		chord.NewConsumer(s,
+			func(ctx context.Context, v T) error {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if cfg.Idle > 0 {
+					if clock == nil {
+						clock = chord.ClockFrom(ctx)
+						wg.Go(idle)
+					}
+					last = clock.Now()
+					defer func() { lastMark = w.watermark }()
+				}
+
+				if !w.add(ctx, v, emit) {
+					if cfg.Late != nil {
+						cfg.Late(ctx, v)
+					}
+					chord.Ack(ctx)
+					return nil
+				}
+
+				if wm := cfg.Time(v).Add(-cfg.MaxDelay); wm.After(w.watermark) {
+					w.watermark = wm
+					w.advance(wm, emit)
+				}
+				return nil
+			},
+			func(ctx context.Context, err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				emit(ctx, Window[K, T]{}, err)
+			},
+		)
+
+		close(done)
+		wg.Wait()
+		w.advance(time.Time{}, emit)
+	})
+}
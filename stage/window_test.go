@@ -0,0 +1,222 @@
+package stage
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/chordtest"
+)
+
+type event struct {
+	key string
+	at  time.Duration
+}
+
+var epoch = time.Unix(1_700_000_000, 0)
+
+func eventTime(e event) time.Time { return epoch.Add(e.at) }
+
+type countAcker struct {
+	mu          sync.Mutex
+	acks, nacks int
+}
+
+func (a *countAcker) Ack() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acks++
+}
+
+func (a *countAcker) Nack() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nacks++
+}
+
+// ackedEvents emits evs, each with its own acker.
+func ackedEvents(evs ...event) (chord.Stage[event], []*countAcker) {
+	ackers := make([]*countAcker, len(evs))
+	for i := range ackers {
+		ackers[i] = new(countAcker)
+	}
+	return chord.NewProducer(context.Background(), func(ctx context.Context, emit chord.Emit[event]) {
+		for i, e := range evs {
+			emit(chord.WithAcker(ctx, ackers[i]), e, nil)
+		}
+	}), ackers
+}
+
+// summary is a window as "key start+size: event times".
+func summary(w Window[string, event]) string {
+	s := w.Key + " " + w.Start.Sub(epoch).String() + "+" + w.End.Sub(w.Start).String() + ":"
+	for _, e := range w.Events {
+		s += " " + e.at.String()
+	}
+	return s
+}
+
+func collectWindows(t *testing.T, s chord.Stage[Window[string, event]]) []string {
+	t.Helper()
+	var got []string
+	chord.NewConsumer(s,
+		func(ctx context.Context, w Window[string, event]) error {
+			got = append(got, summary(w))
+			chord.Ack(ctx)
+			return nil
+		},
+		func(_ context.Context, err error) { t.Errorf("unexpected error: %v", err) },
+	)
+	return got
+}
+
+func TestTumblingWindows(t *testing.T) {
+	src, ackers := ackedEvents(
+		event{"a", 0},
+		event{"b", time.Second},
+		event{"a", 3 * time.Second},
+		// Out of order, but within MaxDelay.
+		event{"a", 6 * time.Second},
+		event{"a", 4 * time.Second},
+		event{"a", 12 * time.Second},
+	)
+	got := collectWindows(t, Windows(src, WindowConfig[string, event]{
+		Time:     eventTime,
+		Key:      func(e event) string { return e.key },
+		Size:     5 * time.Second,
+		MaxDelay: 2 * time.Second,
+	}))
+
+	want := []string{
+		"a 0s+5s: 0s 3s 4s",
+		"b 0s+5s: 1s",
+		"a 5s+5s: 6s",
+		"a 10s+5s: 12s",
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Fatalf("windows\n%q\nwant\n%q", got, want)
+	}
+	for i, a := range ackers {
+		if a.acks != 1 || a.nacks != 0 {
+			t.Errorf("event %d: %d acks, %d nacks", i, a.acks, a.nacks)
+		}
+	}
+}
+
+func TestSlidingWindowsAckOnceAllWindowsAre(t *testing.T) {
+	src, ackers := ackedEvents(event{"", 7 * time.Second})
+
+	var ctxs []context.Context
+	chord.NewConsumer(Windows(src, WindowConfig[string, event]{
+		Time:  eventTime,
+		Size:  10 * time.Second,
+		Slide: 5 * time.Second,
+	}),
+		func(ctx context.Context, w Window[string, event]) error {
+			ctxs = append(ctxs, ctx)
+			return nil
+		},
+		func(_ context.Context, err error) { t.Errorf("unexpected error: %v", err) },
+	)
+
+	if len(ctxs) != 2 {
+		t.Fatalf("event landed in %d windows, want 2", len(ctxs))
+	}
+	chord.Ack(ctxs[0])
+	if ackers[0].acks != 0 {
+		t.Fatal("event acked before all its windows were")
+	}
+	chord.Ack(ctxs[1])
+	if ackers[0].acks != 1 {
+		t.Fatal("event not acked once all its windows were")
+	}
+}
+
+func TestLateEvents(t *testing.T) {
+	src, ackers := ackedEvents(
+		event{"", 0},
+		event{"", 20 * time.Second},
+		event{"", time.Second},
+	)
+
+	var late []event
+	got := collectWindows(t, Windows(src, WindowConfig[string, event]{
+		Time: eventTime,
+		Size: 5 * time.Second,
+		Late: func(_ context.Context, e event) { late = append(late, e) },
+	}))
+
+	if want := []string{" 0s+5s: 0s", " 20s+5s: 20s"}; !slices.Equal(got, want) {
+		t.Fatalf("windows %q, want %q", got, want)
+	}
+	if want := []event{{"", time.Second}}; !slices.Equal(late, want) {
+		t.Fatalf("late events %v, want %v", late, want)
+	}
+	if ackers[2].acks != 1 {
+		t.Error("late event not acked")
+	}
+}
+
+func TestIdleWindowsFire(t *testing.T) {
+	clock := chordtest.NewClock(epoch)
+	ctx := clock.Context(context.Background())
+
+	in := make(chan event)
+	src := chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[event]) {
+		for e := range in {
+			emit(ctx, e, nil)
+		}
+	})
+
+	fired := make(chan string, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		chord.NewConsumer(Windows(src, WindowConfig[string, event]{
+			Time: eventTime,
+			Size: 5 * time.Second,
+			Idle: time.Minute,
+		}),
+			func(_ context.Context, w Window[string, event]) error {
+				fired <- summary(w)
+				return nil
+			},
+			func(_ context.Context, err error) { t.Errorf("unexpected error: %v", err) },
+		)
+	}()
+
+	in <- event{"", time.Second}
+	clock.WaitForTimers(t, 1, time.Second)
+	select {
+	case w := <-fired:
+		t.Fatalf("window %q fired before the input went idle", w)
+	default:
+	}
+
+	clock.Advance(time.Minute)
+	select {
+	case w := <-fired:
+		if want := " 0s+5s: 1s"; w != want {
+			t.Errorf("fired %q, want %q", w, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("window did not fire once the input went idle")
+	}
+
+	close(in)
+	<-done
+}
+
+func TestWindowsRejectInvalidSize(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Windows accepted a zero Size")
+		}
+	}()
+	Windows(chord.FromSeq(func(func(event, error) bool) {}), WindowConfig[string, event]{Time: eventTime})
+}
@@ -0,0 +1,250 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+type NodeKind string
+
+const (
+	KindTrigger   NodeKind = "trigger"
+	KindStage     NodeKind = "stage"
+	KindProducer  NodeKind = "producer"
+	KindMerge     NodeKind = "merge"
+	KindBroadcast NodeKind = "broadcast"
+	KindSink      NodeKind = "sink"
+)
+
+// Node is a component of a pipeline. Name is the type of a trigger or
+// sink and the function of a stage or producer.
+type Node struct {
+	Kind NodeKind
+	Name string
+	// Workers is how many outputs a stage processes at once.
+	Workers int
+	// Fan is how many stages a merge joins or a broadcast feeds.
+	Fan int
+	// Parents are the indexes in Topology.Nodes of the nodes whose outputs
+	// this one takes, several for a merge.
+	Parents []int
+}
+
+// Topology lists the trigger, the stages in the order the pipeline built
+// them, and the sink. Their Parents link them into a graph.
+type Topology struct {
+	Nodes []Node
+}
+
+// Pinger is implemented by triggers and sinks that can check they reach
+// their backend, as DryRun does before a deploy.
+type Pinger interface {
+	Ping(context.Context) error
+}
+
+// build collects the nodes of the pipeline Describe builds. A stage belongs
+// to it if it is built on a stage that does, starting from the input
+// Describe passes in, so pipelines built concurrently elsewhere are left
+// out.
+type build struct {
+	mu    sync.Mutex
+	nodes []Node
+	// ids maps the stages of the pipeline to their nodes. Stages are
+	// closures, identified by their address; keep holds on to them so no
+	// address is reused while Describe runs.
+	ids  map[unsafe.Pointer]int
+	keep []any
+}
+
+var (
+	// describing serializes Describe, so there is one build to record
+	// producers in.
+	describing sync.Mutex
+	building   atomic.Pointer[build]
+)
+
+func stageAddr[T any](s Stage[T]) unsafe.Pointer {
+	return *(*unsafe.Pointer)(unsafe.Pointer(&s))
+}
+
+// record adds n, built on the stages in, to the build if they belong to
+// it, along with the stages out it returns. Producers have no stage to go
+// by and are recorded in the build Describe is running, if any, as taking
+// the outputs of the node recorded before them, which is what stages
+// wrapping a producer around their input look like.
+func record[In, Out any](n Node, in []Stage[In], out ...Stage[Out]) {
+	b := building.Load()
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, s := range in {
+		i, ok := b.ids[stageAddr(s)]
+		if !ok {
+			return
+		}
+		n.Parents = append(n.Parents, i)
+	}
+	if in == nil && len(b.nodes) > 0 {
+		n.Parents = []int{len(b.nodes) - 1}
+	}
+	b.nodes = append(b.nodes, n)
+	for _, s := range out {
+		b.ids[stageAddr(s)] = len(b.nodes) - 1
+		b.keep = append(b.keep, s)
+	}
+}
+
+func funcName(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return ""
+	}
+	name := strings.TrimSuffix(f.Name(), "-fm")
+	return name[strings.LastIndexByte(name, '/')+1:]
+}
+
+// sinkOf returns the sink of f: the one given to NewFlow, or f itself.
+func sinkOf[In, Out any](f Flow[In, Out]) any {
+	if fl, ok := f.(flow[In, Out]); ok {
+		return fl.Sink
+	}
+	return f
+}
+
+// Describe builds the pipeline of f without running it and returns its
+// topology. Stage wiring is type-checked by the compiler; Describe catches
+// pipelines that panic or return no stage while being built. Only stages
+// built with this package's constructors on the pipeline's input are
+// recorded, except producers, which are recorded if built while Describe
+// runs.
+func Describe[In, Out any](t Trigger[In], f Flow[In, Out]) (top Topology, err error) {
+	describing.Lock()
+	defer describing.Unlock()
+
+	// The input never emits, so building the pipeline processes nothing.
+	in := Stage[In](func() <-chan Result[In] {
+		ch := make(chan Result[In])
+		close(ch)
+		return ch
+	})
+
+	b := &build{ids: make(map[unsafe.Pointer]int)}
+	building.Store(b)
+	record[In](Node{Kind: KindTrigger, Name: fmt.Sprintf("%T", t)}, nil, in)
+
+	var out Stage[Out]
+	defer func() {
+		building.Store(nil)
+
+		if r := recover(); r != nil {
+			err = fmt.Errorf("chord: building pipeline: %v", r)
+		}
+		sink := Node{Kind: KindSink, Name: fmt.Sprintf("%T", sinkOf(f))}
+		if i, ok := b.ids[stageAddr(out)]; ok && out != nil {
+			sink.Parents = []int{i}
+		} else {
+			sink.Parents = []int{len(b.nodes) - 1}
+		}
+		top.Nodes = append(b.nodes, sink)
+	}()
+
+	if out = f.Pipeline(in); out == nil {
+		err = errors.New("chord: pipeline returned no stage")
+	}
+	return top, err
+}
+
+// DryRun describes f like Describe and pings the trigger and the sink if
+// they implement Pinger, without processing any event. It is meant for CI
+// and for checking a deploy before it takes traffic.
+func DryRun[In, Out any](ctx context.Context, t Trigger[In], f Flow[In, Out]) (Topology, error) {
+	top, err := Describe(t, f)
+	errs := []error{err}
+
+	if p, ok := t.(Pinger); ok {
+		if err := p.Ping(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("chord: ping trigger %T: %w", t, err))
+		}
+	}
+	if p, ok := sinkOf(f).(Pinger); ok {
+		if err := p.Ping(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("chord: ping sink %T: %w", p, err))
+		}
+	}
+	return top, errors.Join(errs...)
+}
+
+func (n Node) label() string {
+	l := string(n.Kind)
+	if n.Name != "" {
+		l += ": " + n.Name
+	}
+	switch {
+	case n.Workers > 1:
+		l += fmt.Sprintf(" (%d workers)", n.Workers)
+	case n.Kind == KindMerge:
+		l += fmt.Sprintf(" (%d inputs)", n.Fan)
+	case n.Kind == KindBroadcast:
+		l += fmt.Sprintf(" (%d outputs)", n.Fan)
+	}
+	return l
+}
+
+// DOT renders the topology as a Graphviz digraph. Stages hand outputs
+// over through unbuffered channels, so edges carry no buffer size.
+func (t Topology) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph pipeline {\n\trankdir=LR;\n\tnode [shape=box];\n")
+	for i, n := range t.Nodes {
+		shape := ""
+		switch n.Kind {
+		case KindTrigger, KindSink:
+			shape = ", shape=ellipse"
+		case KindMerge, KindBroadcast:
+			shape = ", shape=diamond"
+		}
+		fmt.Fprintf(&b, "\tn%d [label=%q%s];\n", i, n.label(), shape)
+	}
+	for i, n := range t.Nodes {
+		for _, p := range n.Parents {
+			fmt.Fprintf(&b, "\tn%d -> n%d;\n", p, i)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the topology as a Mermaid flowchart.
+func (t Topology) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range t.Nodes {
+		l, r := "[", "]"
+		switch n.Kind {
+		case KindTrigger:
+			l, r = "([", "])"
+		case KindSink:
+			l, r = "[(", ")]"
+		case KindMerge, KindBroadcast:
+			l, r = "{{", "}}"
+		}
+		label := strings.ReplaceAll(n.label(), `"`, "#quot;")
+		fmt.Fprintf(&b, "    n%d%s\"%s\"%s\n", i, l, label, r)
+	}
+	for i, n := range t.Nodes {
+		for _, p := range n.Parents {
+			fmt.Fprintf(&b, "    n%d --> n%d\n", p, i)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,74 @@
+package trigger
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Alert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// Firing reports whether the alert is still active rather than resolved.
+func (a Alert) Firing() bool {
+	return a.Status == "firing"
+}
+
+// AlertGroup is one notification of Alertmanager's webhook receiver
+// (payload version 4).
+type AlertGroup struct {
+	Version           string            `json:"version"`
+	GroupKey          string            `json:"groupKey"`
+	TruncatedAlerts   int               `json:"truncatedAlerts"`
+	Status            string            `json:"status"`
+	Receiver          string            `json:"receiver"`
+	GroupLabels       map[string]string `json:"groupLabels"`
+	CommonLabels      map[string]string `json:"commonLabels"`
+	CommonAnnotations map[string]string `json:"commonAnnotations"`
+	ExternalURL       string            `json:"externalURL"`
+	Alerts            []Alert           `json:"alerts"`
+}
+
+type alertmanagerHandler struct {
+	webhook[AlertGroup]
+}
+
+func (h alertmanagerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var g AlertGroup
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4<<20)).Decode(&g); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.deliver(r, g) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+type Alertmanager struct {
+	webhook[AlertGroup]
+}
+
+// NewAlertmanager receives Alertmanager webhook notifications on pattern.
+func NewAlertmanager(s *http.Server, pattern string) chord.Trigger[AlertGroup] {
+	wh := newWebhook[AlertGroup](s)
+	mount(s, pattern, alertmanagerHandler{wh})
+	return Alertmanager{wh}
+}
@@ -0,0 +1,286 @@
+// Package aws holds triggers for AWS services.
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+type S3EventType string
+
+const (
+	S3ObjectCreated S3EventType = "created"
+	S3ObjectRemoved S3EventType = "removed"
+)
+
+type S3EventsConfig struct {
+	QueueURL string
+	// S3 is required when Fetch is set.
+	S3 *s3.Client
+	// Fetch opens each created object before it is emitted, so Body can be
+	// streamed by the first stage.
+	Fetch bool
+}
+
+type S3Event struct {
+	Type      S3EventType
+	Name      string
+	Bucket    string
+	Key       string
+	Size      int64
+	ETag      string
+	VersionID string
+	Time      time.Time
+	// Body is the object content when Fetch is set. The flow must close it.
+	Body io.ReadCloser
+
+	msg  *s3Message
+	once *sync.Once
+}
+
+// Ack deletes the queue message once every event it carried is acked.
+func (e S3Event) Ack() {
+	e.once.Do(e.msg.ack)
+}
+
+// Nack makes the queue message visible again for redelivery.
+func (e S3Event) Nack() {
+	e.once.Do(e.msg.nack)
+}
+
+type s3Message struct {
+	pending atomic.Int32
+	failed  atomic.Bool
+	settle  func(ok bool)
+}
+
+func (m *s3Message) ack() {
+	if m.pending.Add(-1) == 0 && !m.failed.Load() {
+		m.settle(true)
+	}
+}
+
+func (m *s3Message) nack() {
+	if m.failed.CompareAndSwap(false, true) {
+		m.settle(false)
+	}
+}
+
+type s3Notification struct {
+	Records []struct {
+		EventName string    `json:"eventName"`
+		EventTime time.Time `json:"eventTime"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key       string `json:"key"`
+				Size      int64  `json:"size"`
+				ETag      string `json:"eTag"`
+				VersionID string `json:"versionId"`
+			} `json:"object"`
+		} `json:"s3"`
+	}
+	// SNS-wrapped notifications carry the S3 payload in Message.
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+	// EventBridge events.
+	DetailType string    `json:"detail-type"`
+	Time       time.Time `json:"time"`
+	Detail     struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key       string `json:"key"`
+			Size      int64  `json:"size"`
+			ETag      string `json:"etag"`
+			VersionID string `json:"version-id"`
+		} `json:"object"`
+	} `json:"detail"`
+}
+
+// parseS3Events decodes S3 event notifications (direct or via SNS) and
+// EventBridge S3 events. Test events yield no events.
+func parseS3Events(body string) ([]S3Event, error) {
+	var n s3Notification
+	if err := json.Unmarshal([]byte(body), &n); err != nil {
+		return nil, err
+	}
+	if n.Type == "Notification" && n.Message != "" {
+		return parseS3Events(n.Message)
+	}
+
+	if n.DetailType != "" {
+		ev := S3Event{
+			Name:      n.DetailType,
+			Bucket:    n.Detail.Bucket.Name,
+			Key:       n.Detail.Object.Key,
+			Size:      n.Detail.Object.Size,
+			ETag:      n.Detail.Object.ETag,
+			VersionID: n.Detail.Object.VersionID,
+			Time:      n.Time,
+		}
+		switch n.DetailType {
+		case "Object Created":
+			ev.Type = S3ObjectCreated
+		case "Object Deleted":
+			ev.Type = S3ObjectRemoved
+		default:
+			return nil, nil
+		}
+		return []S3Event{ev}, nil
+	}
+
+	events := make([]S3Event, 0, len(n.Records))
+	for _, r := range n.Records {
+		key, err := url.QueryUnescape(r.S3.Object.Key)
+		if err != nil {
+			return nil, err
+		}
+
+		ev := S3Event{
+			Name:      r.EventName,
+			Bucket:    r.S3.Bucket.Name,
+			Key:       key,
+			Size:      r.S3.Object.Size,
+			ETag:      r.S3.Object.ETag,
+			VersionID: r.S3.Object.VersionID,
+			Time:      r.EventTime,
+		}
+		switch {
+		case strings.HasPrefix(r.EventName, "ObjectCreated:"):
+			ev.Type = S3ObjectCreated
+		case strings.HasPrefix(r.EventName, "ObjectRemoved:"):
+			ev.Type = S3ObjectRemoved
+		default:
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+type S3Events struct {
+	*sqs.Client
+	cfg S3EventsConfig
+}
+
+// NewS3Events emits the S3 events notified on an SQS queue. Messages that
+// are not S3 notifications are reported as errors and deleted.
+func NewS3Events(c *sqs.Client, cfg S3EventsConfig) chord.Trigger[S3Event] {
+	return S3Events{c, cfg}
+}
+
+func (s S3Events) settler(ctx context.Context, m types.Message) func(bool) {
+	return func(ok bool) {
+		if ok {
+			s.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+				QueueUrl:      &s.cfg.QueueURL,
+				ReceiptHandle: m.ReceiptHandle,
+			})
+			return
+		}
+		s.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+			QueueUrl:          &s.cfg.QueueURL,
+			ReceiptHandle:     m.ReceiptHandle,
+			VisibilityTimeout: 0,
+		})
+	}
+}
+
+// handle emits the events of one queue message, reporting false once the
+// trigger context is done.
+func (s S3Events) handle(ctx context.Context, m types.Message, emit chord.Emit[S3Event]) bool {
+	settle := s.settler(context.WithoutCancel(ctx), m)
+
+	// A message that cannot be parsed never will be, so it is deleted
+	// once reported rather than redelivered forever.
+	events, err := parseS3Events(aws.ToString(m.Body))
+	if err != nil {
+		if !emit(ctx, S3Event{}, fmt.Errorf("aws: s3 notification %s: %w", aws.ToString(m.MessageId), err)) {
+			return false
+		}
+		settle(true)
+		return true
+	}
+	if len(events) == 0 {
+		settle(true)
+		return true
+	}
+
+	msg := &s3Message{settle: settle}
+	msg.pending.Store(int32(len(events)))
+
+	for _, ev := range events {
+		ev.msg = msg
+		ev.once = new(sync.Once)
+
+		var err error
+		if s.cfg.Fetch && ev.Type == S3ObjectCreated {
+			in := &s3.GetObjectInput{Bucket: &ev.Bucket, Key: &ev.Key}
+			if ev.VersionID != "" {
+				in.VersionId = &ev.VersionID
+			}
+
+			var out *s3.GetObjectOutput
+			if out, err = s.cfg.S3.GetObject(ctx, in); err == nil {
+				ev.Body = out.Body
+			} else {
+				ev.Nack()
+			}
+		}
+
+		if !emit(chord.WithAcker(ctx, ev), ev, err) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ping checks that the queue is reachable.
+func (s S3Events) Ping(ctx context.Context) error {
+	_, err := s.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{QueueUrl: &s.cfg.QueueURL})
+	return err
+}
+
+func (s S3Events) Stage(ctx context.Context) chord.Stage[S3Event] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[S3Event]) {
+		for attempt := 0; ; {
+			out, err := s.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+				QueueUrl:            &s.cfg.QueueURL,
+				MaxNumberOfMessages: 10,
+				WaitTimeSeconds:     20,
+			})
+			if err != nil {
+				if ctx.Err() != nil || !emit(ctx, S3Event{}, err) || !source.Backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				continue
+			}
+			attempt = 0
+
+			for _, m := range out.Messages {
+				if !s.handle(ctx, m, emit) {
+					return
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,151 @@
+// Package azure holds triggers for Azure services.
+package azure
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+)
+
+var serviceBusDeadLetterReason = "chord: poison message"
+
+type ServiceBusConfig struct {
+	// MaxMessages caps how many messages are fetched per receive call.
+	MaxMessages int
+	// LockRenewal is how often the peek-lock of an in-flight message is
+	// renewed. Zero disables renewal.
+	LockRenewal time.Duration
+	// MaxDeliveryCount is the delivery count at which a nacked message is
+	// dead-lettered instead of abandoned. Zero always abandons.
+	MaxDeliveryCount uint32
+}
+
+type ServiceBusMessage struct {
+	*azservicebus.ReceivedMessage
+	receiver *azservicebus.Receiver
+	cfg      ServiceBusConfig
+	ctx      context.Context
+	once     *sync.Once
+	done     chan struct{}
+	settling *sync.WaitGroup
+	report   func(error)
+}
+
+func (m ServiceBusMessage) Ack() {
+	m.once.Do(func() {
+		defer m.settling.Done()
+		close(m.done)
+
+		if err := m.receiver.CompleteMessage(m.ctx, m.ReceivedMessage, nil); err != nil {
+			m.report(err)
+		}
+	})
+}
+
+func (m ServiceBusMessage) Nack() {
+	m.once.Do(func() {
+		defer m.settling.Done()
+		close(m.done)
+
+		var err error
+		if m.cfg.MaxDeliveryCount != 0 && m.DeliveryCount >= m.cfg.MaxDeliveryCount {
+			err = m.receiver.DeadLetterMessage(m.ctx, m.ReceivedMessage, &azservicebus.DeadLetterOptions{
+				Reason: &serviceBusDeadLetterReason,
+			})
+		} else {
+			err = m.receiver.AbandonMessage(m.ctx, m.ReceivedMessage, nil)
+		}
+		if err != nil {
+			m.report(err)
+		}
+	})
+}
+
+func (m ServiceBusMessage) renewLock() {
+	t := chord.ClockFrom(m.ctx).NewTicker(m.cfg.LockRenewal)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-t.C():
+			if err := m.receiver.RenewMessageLock(m.ctx, m.ReceivedMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+type ServiceBus struct {
+	*azservicebus.Receiver
+	cfg ServiceBusConfig
+}
+
+func NewServiceBus(r *azservicebus.Receiver, cfg ServiceBusConfig) chord.Trigger[ServiceBusMessage] {
+	if cfg.MaxMessages <= 0 {
+		cfg.MaxMessages = 1
+	}
+	return ServiceBus{r, cfg}
+}
+
+func (sb ServiceBus) Stage(ctx context.Context) chord.Stage[ServiceBusMessage] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[ServiceBusMessage]) {
+		settleCtx := context.WithoutCancel(ctx)
+
+		report, stop := source.SettleErrors(ctx, emit)
+		defer stop()
+
+		// Messages still in the flow settle after the stage ends, as it
+		// drains, so the receiver is closed once they have.
+		var settling sync.WaitGroup
+		defer func() {
+			go func() {
+				settling.Wait()
+				sb.Close(settleCtx)
+			}()
+		}()
+
+		for attempt := 0; ; {
+			msgs, err := sb.ReceiveMessages(ctx, sb.cfg.MaxMessages, nil)
+			if err != nil {
+				if ctx.Err() != nil || !emit(ctx, ServiceBusMessage{}, err) || !source.Backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				continue
+			}
+			attempt = 0
+
+			for i, m := range msgs {
+				msg := ServiceBusMessage{
+					ReceivedMessage: m,
+					receiver:        sb.Receiver,
+					cfg:             sb.cfg,
+					ctx:             settleCtx,
+					once:            new(sync.Once),
+					done:            make(chan struct{}),
+					settling:        &settling,
+					report:          report,
+				}
+				settling.Add(1)
+
+				if sb.cfg.LockRenewal > 0 {
+					go msg.renewLock()
+				}
+
+				if !emit(chord.WithAcker(ctx, msg), msg, nil) {
+					msg.Nack()
+					for _, m := range msgs[i+1:] {
+						sb.AbandonMessage(settleCtx, m, nil)
+					}
+					return
+				}
+			}
+		}
+	})
+}
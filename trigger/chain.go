@@ -0,0 +1,34 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+
+	"github.com/0x180db/go-chord"
+)
+
+type chainedFlow[In, Out any] struct {
+	chord.Flow[In, Out]
+	out Manual[Out]
+}
+
+func (c chainedFlow[In, Out]) OnSuccess(ctx context.Context, v Out) error {
+	if err := c.Flow.OnSuccess(ctx, v); err != nil {
+		return err
+	}
+
+	if err := c.out.Emit(ctx, v); err != nil && !errors.Is(err, ErrStopped) {
+		return err
+	}
+	return nil
+}
+
+// Chain wraps f so that every output f handles successfully is also emitted
+// by the returned trigger, letting a second flow run on the first one's
+// results in-process. Run the wrapped flow in place of f. Each output waits
+// until the second flow accepts it; once the second flow stops, outputs are
+// no longer forwarded.
+func Chain[In, Out any](f chord.Flow[In, Out]) (chord.Flow[In, Out], chord.Trigger[Out]) {
+	out := NewManual[Out]()
+	return chainedFlow[In, Out]{f, out}, out
+}
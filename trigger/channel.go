@@ -0,0 +1,32 @@
+package trigger
+
+import (
+	"context"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Channel[T any] struct {
+	ch <-chan T
+}
+
+// FromChannel emits every value received on ch and completes the stage
+// when ch is closed.
+func FromChannel[T any](ch <-chan T) chord.Trigger[T] {
+	return Channel[T]{ch}
+}
+
+func (c Channel[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[T]) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-c.ch:
+				if !ok || !emit(ctx, v, nil) {
+					return
+				}
+			}
+		}
+	})
+}
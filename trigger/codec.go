@@ -0,0 +1,38 @@
+package trigger
+
+import (
+	"context"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Encoded is a message carrying an encoded event, such as an
+// mqtt.Message, TcpMessage or TailLine.
+type Encoded interface {
+	Bytes() []byte
+}
+
+type decoded[T any, M Encoded] struct {
+	t chord.Trigger[M]
+	c chord.Codec[T]
+}
+
+// Decode emits the events carried by the messages of t, decoded with c.
+// Each event is settled with its message, and messages that do not decode
+// go to the error path.
+func Decode[T any, M Encoded](t chord.Trigger[M], c chord.Codec[T]) chord.Trigger[T] {
+	return decoded[T, M]{t, c}
+}
+
+func (d decoded[T, M]) Stage(ctx context.Context) chord.Stage[T] {
+	return chord.NewStage(d.t.Stage(ctx), func(_ context.Context, m M) (T, error) {
+		return d.c.Decode(m.Bytes())
+	})
+}
+
+func (d decoded[T, M]) Ping(ctx context.Context) error {
+	if p, ok := d.t.(chord.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
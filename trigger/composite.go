@@ -0,0 +1,73 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Labeled is an event from a composite trigger together with the name of
+// the source that produced it.
+type Labeled struct {
+	Source string
+	Event  any
+}
+
+// SourceError wraps an error produced by one source of a composite trigger.
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+func (e SourceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Source, e.Err)
+}
+
+func (e SourceError) Unwrap() error {
+	return e.Err
+}
+
+// CompositeSource is a named trigger to be combined with NewComposite.
+type CompositeSource struct {
+	run func(context.Context, emitFunc[Labeled])
+}
+
+func Source[T any](name string, t chord.Trigger[T]) CompositeSource {
+	return CompositeSource{
+		run: func(ctx context.Context, emit emitFunc[Labeled]) {
+			chord.NewConsumer(
+				t.Stage(ctx),
+				func(c context.Context, v T) error {
+					emit(c, Labeled{Source: name, Event: v}, nil)
+					return nil
+				},
+				func(c context.Context, err error) {
+					emit(c, Labeled{Source: name}, SourceError{Source: name, Err: err})
+				},
+			)
+		},
+	}
+}
+
+type Composite struct {
+	sources []CompositeSource
+}
+
+// NewComposite merges the events of several triggers of any type into one
+// stage, labeling each with its source. The stage completes once every
+// source has completed.
+func NewComposite(sources ...CompositeSource) chord.Trigger[Labeled] {
+	return Composite{sources}
+}
+
+func (c Composite) Stage(ctx context.Context) chord.Stage[Labeled] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[Labeled]) {
+		var wg sync.WaitGroup
+		for _, s := range c.sources {
+			wg.Go(func() { s.run(ctx, emit) })
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,133 @@
+// Package consul is a trigger following Consul with blocking queries.
+package consul
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/hashicorp/consul/api"
+)
+
+type WatchType string
+
+const (
+	// WatchKeyPrefix watches all KV pairs under Key.
+	WatchKeyPrefix WatchType = "keyprefix"
+	// WatchService watches the health-checked instances of Service.
+	WatchService WatchType = "service"
+	// WatchChecks watches the health checks of Service, or of every
+	// service when Service is empty.
+	WatchChecks WatchType = "checks"
+	// WatchServices watches the catalog's list of services.
+	WatchServices WatchType = "services"
+)
+
+type Watch struct {
+	Type        WatchType
+	Key         string
+	Service     string
+	Tag         string
+	PassingOnly bool
+}
+
+// Event carries the full result of a watch each time it changes.
+// Only the field matching Watch.Type is set.
+type Event struct {
+	Watch     Watch
+	Index     uint64
+	Pairs     api.KVPairs
+	Instances []*api.ServiceEntry
+	Checks    api.HealthChecks
+	Services  map[string][]string
+}
+
+type Trigger struct {
+	*api.Client
+	watches []Watch
+}
+
+// New panics if a watch has an unknown Type.
+func New(c *api.Client, watches ...Watch) chord.Trigger[Event] {
+	for _, w := range watches {
+		switch w.Type {
+		case WatchKeyPrefix, WatchService, WatchChecks, WatchServices:
+		default:
+			panic(fmt.Sprintf("consul: unknown watch type %q", w.Type))
+		}
+	}
+	return Trigger{c, watches}
+}
+
+func (c Trigger) query(w Watch, q *api.QueryOptions) (Event, *api.QueryMeta, error) {
+	ev := Event{Watch: w}
+
+	var (
+		meta *api.QueryMeta
+		err  error
+	)
+	switch w.Type {
+	case WatchKeyPrefix:
+		ev.Pairs, meta, err = c.KV().List(w.Key, q)
+	case WatchService:
+		ev.Instances, meta, err = c.Health().Service(w.Service, w.Tag, w.PassingOnly, q)
+	case WatchChecks:
+		if w.Service == "" {
+			ev.Checks, meta, err = c.Health().State(api.HealthAny, q)
+		} else {
+			ev.Checks, meta, err = c.Health().Checks(w.Service, q)
+		}
+	case WatchServices:
+		ev.Services, meta, err = c.Catalog().Services(q)
+	default:
+		return ev, nil, fmt.Errorf("consul: unknown watch type %q", w.Type)
+	}
+	return ev, meta, err
+}
+
+// watch runs blocking queries for w and emits an Event each time its
+// index moves.
+func (c Trigger) watch(ctx context.Context, w Watch, emit chord.Emit[Event]) {
+	var index uint64
+
+	for attempt := 0; ; {
+		q := (&api.QueryOptions{WaitIndex: index, WaitTime: 5 * time.Minute}).WithContext(ctx)
+
+		ev, meta, err := c.query(w, q)
+		if err != nil {
+			if ctx.Err() != nil || !emit(ctx, Event{}, err) || !source.Backoff(ctx, attempt) {
+				return
+			}
+			attempt++
+			continue
+		}
+		attempt = 0
+
+		if meta.LastIndex < index {
+			index = 0
+			continue
+		}
+		if meta.LastIndex == index {
+			continue
+		}
+		index = meta.LastIndex
+		ev.Index = index
+
+		if !emit(ctx, ev, nil) {
+			return
+		}
+	}
+}
+
+func (c Trigger) Stage(ctx context.Context) chord.Stage[Event] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Event]) {
+		var wg sync.WaitGroup
+		for _, w := range c.watches {
+			wg.Go(func() { c.watch(ctx, w, emit) })
+		}
+		wg.Wait()
+	})
+}
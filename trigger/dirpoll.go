@@ -0,0 +1,248 @@
+package trigger
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+)
+
+type DirAction int
+
+const (
+	// DirKeep leaves processed files in place; they are emitted once per
+	// process lifetime, or once overall with a Checkpointer.
+	DirKeep DirAction = iota
+	// DirMove moves processed files into DoneDir.
+	DirMove
+	// DirDelete removes processed files.
+	DirDelete
+)
+
+type DirPollConfig struct {
+	Dir string
+	// Pattern is a filepath.Match glob applied to file names. Empty
+	// matches every file.
+	Pattern  string
+	Interval time.Duration
+	// MinAge skips files modified more recently than this, so files still
+	// being written are picked up on a later scan.
+	MinAge time.Duration
+	// Action is applied when a file is acked. A file that cannot be moved
+	// or removed is reported and not emitted again while it stays in Dir.
+	Action DirAction
+	// DoneDir must be set for DirMove.
+	DoneDir string
+	// FailedDir, when set, receives nacked files. Otherwise a nacked file
+	// stays and is emitted again on the next scan.
+	FailedDir string
+	// Checkpointer saves the modification time and name of the files
+	// handled so far under CheckpointKey, which defaults to "dirpoll:" and
+	// Dir. After a restart, files at or before it are skipped, including
+	// ones that appear later with an older modification time.
+	Checkpointer  chord.Checkpointer
+	CheckpointKey string
+}
+
+type DirFile struct {
+	Path    string
+	Name    string
+	Size    int64
+	ModTime time.Time
+	poll    *dirPoll
+	once    *sync.Once
+	tracked chord.Acker
+}
+
+func (f DirFile) Ack() {
+	f.once.Do(func() { f.poll.settle(f, true) })
+}
+
+func (f DirFile) Nack() {
+	f.once.Do(func() { f.poll.settle(f, false) })
+}
+
+func (f DirFile) AckIn(ctx context.Context, tx chord.Transaction) error {
+	return source.AckIn(ctx, f.tracked, tx)
+}
+
+type dirPoll struct {
+	cfg    DirPollConfig
+	report func(error)
+	mu     sync.Mutex
+	seen   map[string]bool
+}
+
+func (d *dirPoll) settle(f DirFile, ok bool) {
+	var err error
+	switch {
+	case ok && d.cfg.Action == DirMove:
+		err = os.Rename(f.Path, filepath.Join(d.cfg.DoneDir, f.Name))
+	case ok && d.cfg.Action == DirDelete:
+		err = os.Remove(f.Path)
+	case !ok && d.cfg.FailedDir != "":
+		err = os.Rename(f.Path, filepath.Join(d.cfg.FailedDir, f.Name))
+	}
+
+	// A file left in place by an error stays marked as seen, rather than
+	// being emitted again on every scan. Forgetting a nacked file has the
+	// next scan emit it again.
+	if err != nil {
+		d.report(err)
+	} else if !ok && d.cfg.FailedDir == "" {
+		d.mu.Lock()
+		delete(d.seen, f.Name)
+		d.mu.Unlock()
+	}
+
+	switch {
+	case f.tracked == nil:
+	case ok:
+		f.tracked.Ack()
+	default:
+		f.tracked.Nack()
+	}
+}
+
+// scan returns the matching files not emitted yet and older than MinAge
+// at now, oldest first, and forgets files that have disappeared, so a later
+// file with the same name is picked up again.
+func (d *dirPoll) scan(now time.Time) ([]DirFile, error) {
+	entries, err := os.ReadDir(d.cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	present := make(map[string]bool, len(entries))
+	var files []DirFile
+
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if d.cfg.Pattern != "" {
+			if ok, err := filepath.Match(d.cfg.Pattern, e.Name()); err != nil || !ok {
+				continue
+			}
+		}
+		present[e.Name()] = true
+
+		if d.seen[e.Name()] {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil || now.Sub(fi.ModTime()) < d.cfg.MinAge {
+			continue
+		}
+
+		d.seen[e.Name()] = true
+		files = append(files, DirFile{
+			Path:    filepath.Join(d.cfg.Dir, e.Name()),
+			Name:    e.Name(),
+			Size:    fi.Size(),
+			ModTime: fi.ModTime(),
+			poll:    d,
+			once:    new(sync.Once),
+		})
+	}
+
+	for name := range d.seen {
+		if !present[name] {
+			delete(d.seen, name)
+		}
+	}
+
+	slices.SortFunc(files, func(a, b DirFile) int {
+		return a.pos().Compare(b.pos())
+	})
+	return files, nil
+}
+
+func (f DirFile) pos() source.FilePos {
+	return source.FilePos{Mod: f.ModTime, Name: f.Name}
+}
+
+type DirPoll struct {
+	cfg DirPollConfig
+}
+
+// NewDirPoll panics if cfg.Action is DirMove without a DoneDir.
+func NewDirPoll(cfg DirPollConfig) chord.Trigger[DirFile] {
+	switch cfg.Action {
+	case DirKeep, DirDelete:
+	case DirMove:
+		if cfg.DoneDir == "" {
+			panic("trigger: DirMove needs a DoneDir")
+		}
+	default:
+		panic(fmt.Sprintf("trigger: unknown DirAction %d", cfg.Action))
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.CheckpointKey == "" {
+		cfg.CheckpointKey = "dirpoll:" + cfg.Dir
+	}
+	return DirPoll{cfg}
+}
+
+func (dp DirPoll) Stage(ctx context.Context) chord.Stage[DirFile] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[DirFile]) {
+		report, stop := source.SettleErrors(ctx, emit)
+		defer stop()
+
+		d := &dirPoll{cfg: dp.cfg, report: report, seen: make(map[string]bool)}
+
+		var (
+			cps  *source.Checkpoints
+			done *source.FilePos
+		)
+		if dp.cfg.Checkpointer != nil {
+			cps = source.NewCheckpoints(ctx, dp.cfg.Checkpointer, dp.cfg.CheckpointKey, report)
+
+			var err error
+			if done, err = source.LoadFilePos(cps); err != nil {
+				emit(ctx, DirFile{}, err)
+				return
+			}
+		}
+
+		clock := chord.ClockFrom(ctx)
+		tick := clock.NewTicker(dp.cfg.Interval)
+		defer tick.Stop()
+
+		for {
+			files, err := d.scan(clock.Now())
+			if err != nil && !emit(ctx, DirFile{}, err) {
+				return
+			}
+
+			for _, f := range files {
+				if cps != nil {
+					if done != nil && f.pos().Compare(*done) <= 0 {
+						continue
+					}
+					f.tracked = cps.Track(f.pos().Encode())
+				}
+				if !emit(chord.WithAcker(ctx, f), f, nil) {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C():
+			}
+		}
+	})
+}
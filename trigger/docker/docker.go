@@ -0,0 +1,63 @@
+// Package docker is a trigger following the Docker events API.
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+)
+
+type Trigger struct {
+	*client.Client
+	filters map[string][]string
+}
+
+// New follows the Docker events API. Filters use the same keys as
+// `docker events --filter`, e.g. {"type": {"container"}, "event": {"die"}}.
+func New(c *client.Client, filters map[string][]string) chord.Trigger[events.Message] {
+	return Trigger{c, filters}
+}
+
+func (d Trigger) Stage(ctx context.Context) chord.Stage[events.Message] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[events.Message]) {
+		args := filters.NewArgs()
+		for k, vs := range d.filters {
+			for _, v := range vs {
+				args.Add(k, v)
+			}
+		}
+
+		var since string
+
+		for attempt := 0; ; attempt++ {
+			msgs, errs := d.Events(ctx, events.ListOptions{Since: since, Filters: args})
+
+		recv:
+			for {
+				select {
+				case m := <-msgs:
+					attempt = 0
+					if !emit(ctx, m, nil) {
+						return
+					}
+					next := m.TimeNano + 1
+					since = fmt.Sprintf("%d.%09d", next/1e9, next%1e9)
+				case err := <-errs:
+					if ctx.Err() != nil || !emit(ctx, events.Message{}, err) {
+						return
+					}
+					break recv
+				}
+			}
+
+			if !source.Backoff(ctx, attempt) {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,139 @@
+// Package durable buffers the events of a trigger on disk with bbolt
+// until they are settled.
+package durable
+
+import (
+	"context"
+	"encoding/binary"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/codec"
+	bolt "go.etcd.io/bbolt"
+)
+
+var durableBucket = []byte("events")
+
+type Config[T any] struct {
+	// Path is the bbolt database file holding the buffered events.
+	Path string
+	// Codec stores events. It defaults to JSON.
+	Codec chord.Codec[T]
+}
+
+type Trigger[T any] struct {
+	t   chord.Trigger[T]
+	cfg Config[T]
+}
+
+// New wraps t with a write-ahead buffer: each event is persisted
+// before it enters the pipeline and trimmed once it is settled, and events
+// left over from a crash are emitted again on the next start. The source's
+// own acker is acked as soon as the event is persisted, so sources that
+// cannot redeliver, such as webhooks, lose nothing when the process dies
+// mid-flight.
+func New[T any](t chord.Trigger[T], cfg Config[T]) chord.Trigger[T] {
+	if cfg.Codec == nil {
+		cfg.Codec = codec.NewJson[T]()
+	}
+	return Trigger[T]{t, cfg}
+}
+
+type durableEntry struct {
+	db  *bolt.DB
+	key []byte
+}
+
+// trim forgets the event. If the database is already closed the event is
+// simply emitted again on the next start.
+func (e durableEntry) trim() {
+	e.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(durableBucket).Delete(e.key)
+	})
+}
+
+func (e durableEntry) Ack()  { e.trim() }
+func (e durableEntry) Nack() { e.trim() }
+
+func (d Trigger[T]) persist(db *bolt.DB, v T) ([]byte, error) {
+	b, err := d.cfg.Codec.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	err = db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(durableBucket)
+		seq, err := bk.NextSequence()
+		if err != nil {
+			return err
+		}
+		key = binary.BigEndian.AppendUint64(nil, seq)
+		return bk.Put(key, b)
+	})
+	return key, err
+}
+
+// pending returns the events persisted by a previous run.
+func pending(db *bolt.DB) (keys, values [][]byte, err error) {
+	err = db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(durableBucket).ForEach(func(k, v []byte) error {
+			keys = append(keys, append([]byte(nil), k...))
+			values = append(values, append([]byte(nil), v...))
+			return nil
+		})
+	})
+	return keys, values, err
+}
+
+func (d Trigger[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[T]) {
+		var zero T
+
+		db, err := bolt.Open(d.cfg.Path, 0o600, &bolt.Options{Timeout: time.Second})
+		if err != nil {
+			emit(ctx, zero, err)
+			return
+		}
+		defer db.Close()
+
+		err = db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucketIfNotExists(durableBucket)
+			return err
+		})
+		if err != nil {
+			emit(ctx, zero, err)
+			return
+		}
+
+		keys, values, err := pending(db)
+		if err != nil && !emit(ctx, zero, err) {
+			return
+		}
+		for i, b := range values {
+			e := durableEntry{db, keys[i]}
+			v, err := d.cfg.Codec.Decode(b)
+			if err != nil {
+				// It would fail again on every start.
+				e.trim()
+			}
+			if !emit(chord.WithAcker(ctx, e), v, err) {
+				return
+			}
+		}
+
+		chord.NewConsumer(d.t.Stage(ctx), func(c context.Context, v T) error {
+			key, err := d.persist(db, v)
+			if err != nil {
+				// Not buffered, so the source keeps the event if it can.
+				emit(c, zero, err)
+				return nil
+			}
+			chord.Ack(c)
+			emit(chord.WithAcker(c, durableEntry{db, key}), v, nil)
+			return nil
+		}, func(c context.Context, err error) {
+			emit(c, zero, err)
+		})
+	})
+}
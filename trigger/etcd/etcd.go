@@ -0,0 +1,115 @@
+// Package etcd is a trigger watching etcd key prefixes.
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type EventType string
+
+const (
+	Put    EventType = "put"
+	Delete EventType = "delete"
+)
+
+type Config struct {
+	Prefixes []string
+	// Revision starts watching at a past revision. Zero starts at the
+	// current one.
+	Revision int64
+}
+
+type Event struct {
+	Type      EventType
+	Key       string
+	Value     []byte
+	PrevValue []byte
+	Revision  int64
+}
+
+type Trigger struct {
+	*clientv3.Client
+	cfg Config
+}
+
+func New(c *clientv3.Client, cfg Config) chord.Trigger[Event] {
+	return Trigger{c, cfg}
+}
+
+// watch follows one prefix, re-establishing the watch from the revision
+// after the last one seen whenever it breaks.
+func (e Trigger) watch(ctx context.Context, prefix string, emit chord.Emit[Event]) {
+	rev := e.cfg.Revision
+
+	for attempt := 0; ; attempt++ {
+		opts := []clientv3.OpOption{clientv3.WithPrefix(), clientv3.WithPrevKV()}
+		if rev > 0 {
+			opts = append(opts, clientv3.WithRev(rev))
+		}
+
+		wctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+
+		for resp := range e.Watch(wctx, prefix, opts...) {
+			if resp.CompactRevision != 0 {
+				err := fmt.Errorf("etcd: revision %d of %q compacted, resuming at %d", rev, prefix, resp.CompactRevision)
+				rev = resp.CompactRevision
+				if !emit(ctx, Event{}, err) {
+					cancel()
+					return
+				}
+				break
+			}
+			if err := resp.Err(); err != nil {
+				if !emit(ctx, Event{}, err) {
+					cancel()
+					return
+				}
+				break
+			}
+			attempt = 0
+
+			for _, ev := range resp.Events {
+				out := Event{
+					Type:     Put,
+					Key:      string(ev.Kv.Key),
+					Value:    ev.Kv.Value,
+					Revision: ev.Kv.ModRevision,
+				}
+				if ev.Type == mvccpb.DELETE {
+					out.Type = Delete
+				}
+				if ev.PrevKv != nil {
+					out.PrevValue = ev.PrevKv.Value
+				}
+
+				if !emit(ctx, out, nil) {
+					cancel()
+					return
+				}
+				rev = ev.Kv.ModRevision + 1
+			}
+		}
+		cancel()
+
+		if ctx.Err() != nil || !source.Backoff(ctx, attempt) {
+			return
+		}
+	}
+}
+
+func (e Trigger) Stage(ctx context.Context) chord.Stage[Event] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Event]) {
+		var wg sync.WaitGroup
+		for _, p := range e.cfg.Prefixes {
+			wg.Go(func() { e.watch(ctx, p, emit) })
+		}
+		wg.Wait()
+	})
+}
@@ -0,0 +1,133 @@
+// Package fswatch is a trigger emitting file system events with fsnotify.
+package fswatch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/fsnotify/fsnotify"
+)
+
+type Config struct {
+	Paths []string
+	// Recursive watches every directory below Paths, including ones
+	// created later.
+	Recursive bool
+	// Debounce coalesces the events for a path until it has been quiet
+	// for this long, emitting one event with the ops combined.
+	Debounce time.Duration
+}
+
+type Trigger struct {
+	cfg Config
+}
+
+func New(cfg Config) chord.Trigger[fsnotify.Event] {
+	return Trigger{cfg}
+}
+
+func (f Trigger) add(w *fsnotify.Watcher, path string) error {
+	if !f.cfg.Recursive {
+		return w.Add(path)
+	}
+
+	return filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return w.Add(p)
+		}
+		return nil
+	})
+}
+
+type fsPending struct {
+	op  fsnotify.Op
+	gen int
+}
+
+func (f Trigger) Stage(ctx context.Context) chord.Stage[fsnotify.Event] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[fsnotify.Event]) {
+		w, err := fsnotify.NewWatcher()
+		if err != nil {
+			emit(ctx, fsnotify.Event{}, err)
+			return
+		}
+		defer w.Close()
+
+		for _, p := range f.cfg.Paths {
+			if err := f.add(w, p); err != nil {
+				emit(ctx, fsnotify.Event{}, err)
+				return
+			}
+		}
+
+		type fsReady struct {
+			name string
+			gen  int
+		}
+
+		pending := make(map[string]fsPending)
+		ready := make(chan fsReady)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-w.Errors:
+				if !emit(ctx, fsnotify.Event{}, err) {
+					return
+				}
+			case r := <-ready:
+				p, ok := pending[r.name]
+				if !ok || p.gen != r.gen {
+					continue
+				}
+				delete(pending, r.name)
+				if !emit(ctx, fsnotify.Event{Name: r.name, Op: p.op}, nil) {
+					return
+				}
+			case ev := <-w.Events:
+				if f.cfg.Recursive && ev.Has(fsnotify.Create) {
+					if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+						if err := f.add(w, ev.Name); err != nil && !emit(ctx, fsnotify.Event{}, err) {
+							return
+						}
+					}
+				}
+
+				if f.cfg.Debounce == 0 {
+					if !emit(ctx, ev, nil) {
+						return
+					}
+					continue
+				}
+
+				p := pending[ev.Name]
+				p.op |= ev.Op
+				p.gen++
+				pending[ev.Name] = p
+
+				r := fsReady{ev.Name, p.gen}
+				timer := chord.ClockFrom(ctx).NewTimer(f.cfg.Debounce)
+				go func() {
+					defer timer.Stop()
+					select {
+					case <-timer.C():
+					case <-ctx.Done():
+						return
+					}
+					select {
+					case ready <- r:
+					case <-ctx.Done():
+					}
+				}()
+			}
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+
+	"github.com/0x180db/go-chord"
+)
+
+// ErrDone is returned by a FromFunc source to complete the stage.
+var ErrDone = errors.New("trigger: done")
+
+type Func[T any] struct {
+	fn func(context.Context) (T, error)
+}
+
+// FromFunc calls fn in a loop and emits what it returns until it returns
+// ErrDone or ctx is done. Other errors go to the error path and the loop
+// continues.
+func FromFunc[T any](fn func(context.Context) (T, error)) chord.Trigger[T] {
+	return Func[T]{fn}
+}
+
+func (f Func[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[T]) {
+		for ctx.Err() == nil {
+			v, err := f.fn(ctx)
+			if errors.Is(err, ErrDone) || !emit(ctx, v, err) {
+				return
+			}
+		}
+	})
+}
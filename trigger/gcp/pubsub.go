@@ -0,0 +1,81 @@
+// Package gcp holds triggers for Google Cloud services.
+package gcp
+
+import (
+	"context"
+	"sync"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/0x180db/go-chord"
+)
+
+type PubSubConfig struct {
+	MaxOutstandingMessages int
+	MaxOutstandingBytes    int
+	NumGoroutines          int
+}
+
+type PubSubMessage struct {
+	*pubsub.Message
+	once *sync.Once
+	done chan struct{}
+}
+
+func (m PubSubMessage) Ack() {
+	m.Message.Ack()
+	m.settle()
+}
+
+func (m PubSubMessage) Nack() {
+	m.Message.Nack()
+	m.settle()
+}
+
+func (m PubSubMessage) settle() {
+	m.once.Do(func() { close(m.done) })
+}
+
+type PubSub struct {
+	*pubsub.Subscription
+}
+
+func NewPubSub(sub *pubsub.Subscription, cfg PubSubConfig) chord.Trigger[PubSubMessage] {
+	if cfg.MaxOutstandingMessages != 0 {
+		sub.ReceiveSettings.MaxOutstandingMessages = cfg.MaxOutstandingMessages
+	}
+	if cfg.MaxOutstandingBytes != 0 {
+		sub.ReceiveSettings.MaxOutstandingBytes = cfg.MaxOutstandingBytes
+	}
+	if cfg.NumGoroutines != 0 {
+		sub.ReceiveSettings.NumGoroutines = cfg.NumGoroutines
+	}
+
+	return PubSub{sub}
+}
+
+// Stage receives messages until ctx is done. Messages that carry an
+// ordering key hold back the next message for the same key until they are
+// acked or nacked, so per-key ordering survives the pipeline.
+func (p PubSub) Stage(ctx context.Context) chord.Stage[PubSubMessage] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[PubSubMessage]) {
+		err := p.Receive(ctx, func(_ context.Context, m *pubsub.Message) {
+			msg := PubSubMessage{Message: m, once: new(sync.Once), done: make(chan struct{})}
+
+			if !emit(chord.WithAcker(ctx, msg), msg, nil) {
+				m.Nack()
+				return
+			}
+
+			if m.OrderingKey != "" {
+				select {
+				case <-msg.done:
+				case <-ctx.Done():
+				}
+			}
+		})
+
+		if err != nil && ctx.Err() == nil {
+			emit(ctx, PubSubMessage{}, err)
+		}
+	})
+}
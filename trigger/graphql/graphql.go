@@ -0,0 +1,158 @@
+// Package graphql is a trigger running GraphQL subscriptions.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const graphqlSubprotocol = "graphql-transport-ws"
+
+type Config struct {
+	URL           string
+	Query         string
+	OperationName string
+	Variables     map[string]any
+	// InitPayload is sent with connection_init, typically for auth.
+	InitPayload map[string]any
+	Header      http.Header
+}
+
+type Error struct {
+	Message string         `json:"message"`
+	Path    []any          `json:"path,omitempty"`
+	Extra   map[string]any `json:"extensions,omitempty"`
+}
+
+// Errors is routed to the error path when a result carries errors.
+type Errors []Error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Message
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+type graphqlMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type Trigger struct {
+	cfg Config
+}
+
+// New runs a subscription over the graphql-ws protocol and emits
+// the data of each result, reconnecting and resubscribing when the
+// connection drops.
+func New(cfg Config) chord.Trigger[json.RawMessage] {
+	return Trigger{cfg}
+}
+
+func (g Trigger) subscribe(ctx context.Context, emit chord.Emit[json.RawMessage]) (bool, error) {
+	c, _, err := websocket.Dial(ctx, g.cfg.URL, &websocket.DialOptions{
+		Subprotocols: []string{graphqlSubprotocol},
+		HTTPHeader:   g.cfg.Header,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer c.CloseNow()
+	c.SetReadLimit(16 << 20)
+
+	var init json.RawMessage
+	if g.cfg.InitPayload != nil {
+		if init, err = json.Marshal(g.cfg.InitPayload); err != nil {
+			return false, err
+		}
+	}
+	if err := wsjson.Write(ctx, c, graphqlMessage{Type: "connection_init", Payload: init}); err != nil {
+		return false, err
+	}
+
+	var ack graphqlMessage
+	if err := wsjson.Read(ctx, c, &ack); err != nil {
+		return false, err
+	}
+	if ack.Type != "connection_ack" {
+		return false, fmt.Errorf("graphql: expected connection_ack, got %q", ack.Type)
+	}
+
+	sub, err := json.Marshal(map[string]any{
+		"query":         g.cfg.Query,
+		"operationName": g.cfg.OperationName,
+		"variables":     g.cfg.Variables,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := wsjson.Write(ctx, c, graphqlMessage{ID: "1", Type: "subscribe", Payload: sub}); err != nil {
+		return false, err
+	}
+
+	for {
+		var msg graphqlMessage
+		if err := wsjson.Read(ctx, c, &msg); err != nil {
+			return true, err
+		}
+
+		switch msg.Type {
+		case "ping":
+			if err := wsjson.Write(ctx, c, graphqlMessage{Type: "pong"}); err != nil {
+				return true, err
+			}
+		case "next":
+			var res struct {
+				Data   json.RawMessage `json:"data"`
+				Errors Errors          `json:"errors"`
+			}
+			if err := json.Unmarshal(msg.Payload, &res); err != nil {
+				return true, err
+			}
+
+			if len(res.Errors) > 0 {
+				err = res.Errors
+			}
+			if !emit(ctx, res.Data, err) {
+				return true, nil
+			}
+		case "error":
+			var errs Errors
+			if err := json.Unmarshal(msg.Payload, &errs); err != nil {
+				return true, err
+			}
+			c.Close(websocket.StatusNormalClosure, "")
+			return true, errs
+		case "complete":
+			c.Close(websocket.StatusNormalClosure, "")
+			return true, errors.New("graphql: subscription completed by server")
+		}
+	}
+}
+
+func (g Trigger) Stage(ctx context.Context) chord.Stage[json.RawMessage] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[json.RawMessage]) {
+		for attempt := 0; ; attempt++ {
+			subscribed, err := g.subscribe(ctx, emit)
+			if subscribed {
+				attempt = 0
+			}
+
+			if ctx.Err() != nil || err != nil && !emit(ctx, nil, err) || !source.Backoff(ctx, attempt) {
+				return
+			}
+		}
+	})
+}
@@ -5,7 +5,6 @@ import (
 	"net/http"
 
 	"github.com/0x180db/go-chord"
-	"github.com/0x180db/go-conduit"
 )
 
 type HttpContext struct {
@@ -26,8 +25,11 @@ func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ch := make(chan struct{})
 	defer close(ch)
 
-	h.ch <- HttpContext{Writer: w, Request: r, done: ch}
-	<-ch
+	select {
+	case h.ch <- HttpContext{Writer: w, Request: r, done: ch}:
+		<-ch
+	case <-r.Context().Done():
+	}
 }
 
 type Http struct {
@@ -38,8 +40,12 @@ type Http struct {
 func NewHttp(s *http.Server, pattern string) chord.Trigger[HttpContext] {
 	ch := make(chan HttpContext)
 
-	h := Handler{ch}
+	mount(s, pattern, Handler{ch})
+
+	return Http{s, ch}
+}
 
+func mount(s *http.Server, pattern string, h http.Handler) {
 	var mux *http.ServeMux
 
 	if existingMux, ok := s.Handler.(*http.ServeMux); ok {
@@ -50,27 +56,23 @@ func NewHttp(s *http.Server, pattern string) chord.Trigger[HttpContext] {
 
 	mux.Handle(pattern, h)
 	s.Handler = mux
-
-	return Http{s, ch}
 }
 
 func (ht Http) Stage(ctx context.Context) chord.Stage[HttpContext] {
-	return func() <-chan conduit.Result[HttpContext] {
-		ch := make(chan conduit.Result[HttpContext])
-		go func() {
-			defer ht.Close()
-			defer close(ch)
-			defer close(ht.ch)
-
-			for {
-				select {
-				case <-ctx.Done():
+	return stage(ctx, func(ctx context.Context, emit emitFunc[HttpContext]) {
+		defer ht.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case hc := <-ht.ch:
+				if !emit(ctx, hc, nil) {
+					hc.Writer.WriteHeader(http.StatusServiceUnavailable)
+					hc.Done()
 					return
-				default:
-					ch <- conduit.Ok(ctx, <-ht.ch)
 				}
 			}
-		}()
-		return ch
-	}
+		}
+	})
 }
@@ -0,0 +1,298 @@
+// Package imap is a trigger emitting the emails arriving in an IMAP mailbox.
+package imap
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/emersion/go-imap/v2"
+	"github.com/emersion/go-imap/v2/imapclient"
+	"github.com/emersion/go-message/mail"
+)
+
+type Config struct {
+	// Addr is the host:port of an IMAPS (implicit TLS) server.
+	Addr     string
+	Username string
+	Password string
+	// Mailbox defaults to INBOX.
+	Mailbox string
+	// Poll is how often the mailbox is checked when the server does not
+	// support IDLE, and how often IDLE is restarted otherwise.
+	Poll time.Duration
+	// SinceUID resumes after a previously processed message. Otherwise
+	// only messages arriving after the stage starts are emitted.
+	SinceUID imap.UID
+	// Checkpointer saves the UIDVALIDITY of the mailbox and the UID of
+	// each settled message under CheckpointKey, which defaults to "imap:"
+	// with the username, address and mailbox, and the stage resumes after
+	// it when SinceUID is unset. If the UIDVALIDITY changes, the saved UID
+	// no longer identifies a message, so only messages arriving after that
+	// are emitted.
+	Checkpointer  chord.Checkpointer
+	CheckpointKey string
+}
+
+// Message streams a message straight from the server: read the body
+// with NextPart before acking, since the next message is not fetched until
+// this one is acked or nacked. The mailbox is opened read-only, so messages
+// are never flagged \Seen.
+type Message struct {
+	UID imap.UID
+	*mail.Reader
+	once   *sync.Once
+	done   chan struct{}
+	nacked *bool
+}
+
+func (m Message) Ack() {
+	m.once.Do(func() { close(m.done) })
+}
+
+// Nack leaves the message unsettled, without a checkpoint, so it is
+// fetched again, with the messages after it, on the next poll.
+func (m Message) Nack() {
+	m.once.Do(func() {
+		*m.nacked = true
+		close(m.done)
+	})
+}
+
+type Trigger struct {
+	cfg Config
+}
+
+func New(cfg Config) chord.Trigger[Message] {
+	if cfg.Mailbox == "" {
+		cfg.Mailbox = "INBOX"
+	}
+	if cfg.Poll == 0 {
+		cfg.Poll = 5 * time.Minute
+	}
+	if cfg.CheckpointKey == "" {
+		cfg.CheckpointKey = "imap:" + cfg.Username + "@" + cfg.Addr + "/" + cfg.Mailbox
+	}
+	return Trigger{cfg}
+}
+
+// resumeUID loads the checkpointed UIDVALIDITY and UID, if any.
+func (im Trigger) resumeUID(ctx context.Context) (validity uint32, uid imap.UID, err error) {
+	b, err := im.cfg.Checkpointer.Load(ctx, im.cfg.CheckpointKey)
+	if err != nil || b == nil {
+		return 0, 0, err
+	}
+	vs, us, ok := strings.Cut(string(b), "/")
+	v, verr := strconv.ParseUint(vs, 10, 32)
+	u, uerr := strconv.ParseUint(us, 10, 32)
+	if !ok || verr != nil || uerr != nil {
+		return 0, 0, fmt.Errorf("imap: invalid checkpoint %q", b)
+	}
+	return uint32(v), imap.UID(u), nil
+}
+
+// fetch emits messages with a UID of at least next and returns the UID to
+// continue from, which is that of a nacked message.
+func (im Trigger) fetch(ctx context.Context, c *imapclient.Client, validity uint32, next imap.UID, emit chord.Emit[Message]) (imap.UID, error) {
+	var uids imap.UIDSet
+	uids.AddRange(next, 0)
+
+	search, err := c.UIDSearch(&imap.SearchCriteria{UID: []imap.UIDSet{uids}}, nil).Wait()
+	if err != nil {
+		return next, err
+	}
+
+	var fetchSet imap.UIDSet
+	for _, uid := range search.AllUIDs() {
+		if uid >= next {
+			fetchSet.AddNum(uid)
+		}
+	}
+	if len(fetchSet) == 0 {
+		return next, nil
+	}
+
+	cmd := c.Fetch(fetchSet, &imap.FetchOptions{
+		UID:         true,
+		BodySection: []*imap.FetchItemBodySection{{Peek: true}},
+	})
+	defer cmd.Close()
+
+	for {
+		msg := cmd.Next()
+		if msg == nil {
+			break
+		}
+
+		var uid imap.UID
+		for {
+			item := msg.Next()
+			if item == nil {
+				break
+			}
+
+			switch item := item.(type) {
+			case imapclient.FetchItemDataUID:
+				uid = item.UID
+			case imapclient.FetchItemDataBodySection:
+				r, err := mail.CreateReader(item.Literal)
+				if err != nil {
+					if !emit(ctx, Message{}, err) {
+						return next, ctx.Err()
+					}
+					continue
+				}
+
+				m := Message{UID: uid, Reader: r, once: new(sync.Once), done: make(chan struct{}), nacked: new(bool)}
+				if !emit(chord.WithAcker(ctx, m), m, nil) {
+					return next, ctx.Err()
+				}
+
+				select {
+				case <-m.done:
+				case <-ctx.Done():
+					return next, ctx.Err()
+				}
+				if *m.nacked {
+					return uid, nil
+				}
+
+				// Messages are settled one at a time, in UID order.
+				if im.cfg.Checkpointer != nil {
+					b := fmt.Appendf(nil, "%d/%d", validity, uid)
+					err := im.cfg.Checkpointer.Save(context.WithoutCancel(ctx), im.cfg.CheckpointKey, b)
+					if err != nil && !emit(ctx, Message{}, err) {
+						return next, ctx.Err()
+					}
+				}
+			}
+		}
+
+		if uid >= next {
+			next = uid + 1
+		}
+	}
+
+	return next, cmd.Close()
+}
+
+// wait blocks until the server reports new messages, the poll interval
+// passes or ctx is done.
+func (im Trigger) wait(ctx context.Context, c *imapclient.Client, notify <-chan struct{}) error {
+	t := chord.ClockFrom(ctx).NewTimer(im.cfg.Poll)
+	defer t.Stop()
+
+	if !c.Caps().Has(imap.CapIdle) {
+		select {
+		case <-ctx.Done():
+		case <-t.C():
+		}
+		return nil
+	}
+
+	idle, err := c.Idle()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-t.C():
+	case <-notify:
+	}
+
+	if err := idle.Close(); err != nil {
+		return err
+	}
+	return idle.Wait()
+}
+
+// session emits messages from next on until it fails or ctx is done, and
+// returns the mailbox's UIDVALIDITY and the UID to continue from. If the
+// UIDVALIDITY differs from validity, which is zero when unknown, next is
+// meaningless and only messages arriving from then on are emitted.
+func (im Trigger) session(ctx context.Context, validity uint32, next imap.UID, emit chord.Emit[Message]) (uint32, imap.UID, error) {
+	notify := make(chan struct{}, 1)
+
+	c, err := imapclient.DialTLS(im.cfg.Addr, &imapclient.Options{
+		UnilateralDataHandler: &imapclient.UnilateralDataHandler{
+			Mailbox: func(data *imapclient.UnilateralDataMailbox) {
+				if data.NumMessages != nil {
+					select {
+					case notify <- struct{}{}:
+					default:
+					}
+				}
+			},
+		},
+	})
+	if err != nil {
+		return validity, next, err
+	}
+	defer c.Close()
+
+	stop := context.AfterFunc(ctx, func() { c.Close() })
+	defer stop()
+
+	if err := c.Login(im.cfg.Username, im.cfg.Password).Wait(); err != nil {
+		return validity, next, err
+	}
+
+	sel, err := c.Select(im.cfg.Mailbox, &imap.SelectOptions{ReadOnly: true}).Wait()
+	if err != nil {
+		return validity, next, err
+	}
+	if validity != 0 && sel.UIDValidity != validity {
+		next = 0
+	}
+	validity = sel.UIDValidity
+	if next == 0 {
+		next = sel.UIDNext
+	}
+
+	for {
+		if next, err = im.fetch(ctx, c, validity, next, emit); err != nil {
+			return validity, next, err
+		}
+		if err = im.wait(ctx, c, notify); err != nil || ctx.Err() != nil {
+			return validity, next, err
+		}
+	}
+}
+
+func (im Trigger) Stage(ctx context.Context) chord.Stage[Message] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Message]) {
+		var validity uint32
+		since := im.cfg.SinceUID
+		if since == 0 && im.cfg.Checkpointer != nil {
+			var err error
+			if validity, since, err = im.resumeUID(ctx); err != nil {
+				emit(ctx, Message{}, err)
+				return
+			}
+		}
+
+		var next imap.UID
+		if since != 0 {
+			next = since + 1
+		}
+
+		for attempt := 0; ; attempt++ {
+			var err error
+			before := next
+			validity, next, err = im.session(ctx, validity, next, emit)
+			if next != before {
+				attempt = 0
+			}
+
+			if ctx.Err() != nil || err != nil && !emit(ctx, Message{}, err) || !source.Backoff(ctx, attempt) {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package source
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Checkpoints saves a trigger's position as its events are acked. A
+// position is only saved once every event emitted before it is acked too,
+// so events finishing out of order are not skipped after a restart. A
+// nacked event holds the checkpoint back until an event tracked later at
+// the same position, such as its redelivery or retry, is acked; until then
+// it and everything after it are emitted again after a restart. Save
+// errors go to fail, which must not block (see SettleErrors).
+type Checkpoints struct {
+	ctx  context.Context
+	cp   chord.Checkpointer
+	key  string
+	fail func(error)
+
+	mu      sync.Mutex
+	next    uint64
+	settled uint64
+	// pending holds the positions of events acked ahead of settled, nil
+	// for a retry, which moves nothing past its nacked event.
+	pending map[uint64][]byte
+	// nacked holds the positions of events holding the checkpoint back.
+	nacked map[uint64][]byte
+
+	saveMu sync.Mutex
+	saved  uint64
+}
+
+func NewCheckpoints(ctx context.Context, cp chord.Checkpointer, key string, fail func(error)) *Checkpoints {
+	return &Checkpoints{
+		ctx:     context.WithoutCancel(ctx),
+		cp:      cp,
+		key:     key,
+		fail:    fail,
+		pending: make(map[uint64][]byte),
+		nacked:  make(map[uint64][]byte),
+	}
+}
+
+// Load returns the saved position, or nil if there is none.
+func (c *Checkpoints) Load() ([]byte, error) {
+	return c.cp.Load(c.ctx, c.key)
+}
+
+// Track returns the acker of the next event, after which the trigger is at
+// pos.
+func (c *Checkpoints) Track(pos []byte) chord.Acker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	seq := c.next
+	c.next++
+	return &checkpointAck{c: c, seq: seq, pos: pos}
+}
+
+// retried returns the earliest nacked event before seq at pos, which the
+// event at seq retries.
+func (c *Checkpoints) retried(seq uint64, pos []byte) (uint64, bool) {
+	var (
+		first uint64
+		found bool
+	)
+	for s, p := range c.nacked {
+		if s < seq && bytes.Equal(p, pos) && (!found || s < first) {
+			first, found = s, true
+		}
+	}
+	return first, found
+}
+
+func (c *Checkpoints) settle(seq uint64, pos []byte, ok bool) {
+	c.mu.Lock()
+	if nacked, retry := c.retried(seq, pos); retry {
+		c.pending[seq] = nil
+		if ok {
+			delete(c.nacked, nacked)
+			c.pending[nacked] = pos
+		}
+	} else if ok {
+		c.pending[seq] = pos
+	} else {
+		c.nacked[seq] = pos
+	}
+
+	var last []byte
+	for {
+		p, acked := c.pending[c.settled]
+		if !acked {
+			break
+		}
+		delete(c.pending, c.settled)
+		if p != nil {
+			last = p
+		}
+		c.settled++
+	}
+	upto := c.settled
+	c.mu.Unlock()
+
+	if !ok {
+		// A position saved within an aborted transaction was not saved.
+		c.saveMu.Lock()
+		c.saved = min(c.saved, seq)
+		c.saveMu.Unlock()
+	}
+	if last == nil {
+		return
+	}
+
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	// A later position may have been saved while this one waited.
+	if upto <= c.saved {
+		return
+	}
+	if err := c.cp.Save(c.ctx, c.key, last); err != nil {
+		c.fail(err)
+		return
+	}
+	c.saved = upto
+}
+
+type checkpointAck struct {
+	c    *Checkpoints
+	seq  uint64
+	pos  []byte
+	once sync.Once
+}
+
+func (a *checkpointAck) Ack() {
+	a.once.Do(func() { a.c.settle(a.seq, a.pos, true) })
+}
+
+func (a *checkpointAck) Nack() {
+	a.once.Do(func() { a.c.settle(a.seq, a.pos, false) })
+}
+
+// AckIn saves the position within tx if the checkpointer can and every
+// earlier event is acked already; otherwise it is saved by Ack, after the
+// commit.
+func (a *checkpointAck) AckIn(ctx context.Context, tx chord.Transaction) error {
+	tc, ok := a.c.cp.(chord.TxCheckpointer)
+	if !ok {
+		return nil
+	}
+
+	a.c.mu.Lock()
+	next := a.seq == a.c.settled
+	a.c.mu.Unlock()
+	if !next {
+		return nil
+	}
+
+	a.c.saveMu.Lock()
+	defer a.c.saveMu.Unlock()
+
+	err := tc.SaveIn(ctx, tx, a.c.key, a.pos)
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err == nil {
+		// Ack has nothing left to save unless later events settled
+		// meanwhile. If the transaction aborts, the event is nacked,
+		// which takes this back.
+		a.c.saved = a.seq + 1
+	}
+	return err
+}
+
+// FilePos is the checkpoint of a directory poller: the modification time
+// and name of a file. Pollers emit files in this order, so the files at or
+// before a saved position were all acked.
+type FilePos struct {
+	Mod  time.Time
+	Name string
+}
+
+func (p FilePos) Compare(q FilePos) int {
+	return cmp.Or(p.Mod.Compare(q.Mod), strings.Compare(p.Name, q.Name))
+}
+
+func (p FilePos) Encode() []byte {
+	return fmt.Appendf(nil, "%d/%s", p.Mod.UnixNano(), p.Name)
+}
+
+func DecodeFilePos(b []byte) (FilePos, error) {
+	ns, name, ok := strings.Cut(string(b), "/")
+	n, err := strconv.ParseInt(ns, 10, 64)
+	if !ok || err != nil {
+		return FilePos{}, fmt.Errorf("trigger: invalid file checkpoint %q", b)
+	}
+	return FilePos{time.Unix(0, n), name}, nil
+}
+
+// LoadFilePos returns the saved position of a poller, or nil if there is
+// none.
+func LoadFilePos(cps *Checkpoints) (*FilePos, error) {
+	b, err := cps.Load()
+	if err != nil || b == nil {
+		return nil, err
+	}
+	p, err := DecodeFilePos(b)
+	return &p, err
+}
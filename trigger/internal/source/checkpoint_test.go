@@ -0,0 +1,191 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// memCheckpointer records every checkpoint saved, optionally within
+// transactions.
+type memCheckpointer struct {
+	mu    sync.Mutex
+	saves []string
+	txs   []string
+}
+
+func (m *memCheckpointer) Load(context.Context, string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.saves) == 0 {
+		return nil, nil
+	}
+	return []byte(m.saves[len(m.saves)-1]), nil
+}
+
+func (m *memCheckpointer) Save(_ context.Context, _ string, b []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.saves = append(m.saves, string(b))
+	return nil
+}
+
+func (m *memCheckpointer) SaveIn(_ context.Context, _ chord.Transaction, _ string, b []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txs = append(m.txs, string(b))
+	return nil
+}
+
+func (m *memCheckpointer) saved() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.saves)
+}
+
+func noFail(t *testing.T) func(error) {
+	return func(err error) { t.Errorf("save failed: %v", err) }
+}
+
+func trackAll(cps *Checkpoints, positions ...string) []chord.Acker {
+	ackers := make([]chord.Acker, len(positions))
+	for i, p := range positions {
+		ackers[i] = cps.Track([]byte(p))
+	}
+	return ackers
+}
+
+func TestCheckpointsSaveInOrder(t *testing.T) {
+	cp := new(memCheckpointer)
+	cps := NewCheckpoints(context.Background(), cp, "k", noFail(t))
+	a := trackAll(cps, "1", "2", "3", "4")
+
+	a[1].Ack()
+	a[2].Ack()
+	if got := cp.saved(); len(got) != 0 {
+		t.Fatalf("saved %v before the first event was acked", got)
+	}
+
+	a[0].Ack()
+	if got, want := cp.saved(), []string{"3"}; !slices.Equal(got, want) {
+		t.Fatalf("saved %v, want %v", got, want)
+	}
+
+	a[3].Ack()
+	if got, want := cp.saved(), []string{"3", "4"}; !slices.Equal(got, want) {
+		t.Fatalf("saved %v, want %v", got, want)
+	}
+}
+
+func TestCheckpointsHoldBackAtNack(t *testing.T) {
+	cp := new(memCheckpointer)
+	cps := NewCheckpoints(context.Background(), cp, "k", noFail(t))
+	a := trackAll(cps, "1", "2", "3", "4")
+
+	a[0].Ack()
+	a[2].Ack()
+	a[1].Nack()
+	a[3].Ack()
+	// A late ack of a settled event changes nothing.
+	a[1].Ack()
+
+	if got, want := cp.saved(), []string{"1"}; !slices.Equal(got, want) {
+		t.Fatalf("saved %v, want %v", got, want)
+	}
+}
+
+func TestCheckpointsAdvanceOnceNackedEventIsRetried(t *testing.T) {
+	cp := new(memCheckpointer)
+	cps := NewCheckpoints(context.Background(), cp, "k", noFail(t))
+	a := trackAll(cps, "1", "2", "3")
+
+	a[0].Ack()
+	a[1].Nack()
+	a[2].Ack()
+
+	// The redelivered event is tracked again at its position; a failed
+	// retry keeps holding the checkpoint back.
+	cps.Track([]byte("2")).Nack()
+	if got, want := cp.saved(), []string{"1"}; !slices.Equal(got, want) {
+		t.Fatalf("saved %v, want %v", got, want)
+	}
+
+	cps.Track([]byte("2")).Ack()
+	if got, want := cp.saved(), []string{"1", "3"}; !slices.Equal(got, want) {
+		t.Fatalf("saved %v, want %v", got, want)
+	}
+}
+
+func TestCheckpointsReportSaveErrors(t *testing.T) {
+	errSave := errors.New("save failed")
+	var got []error
+	cps := NewCheckpoints(context.Background(), failingCheckpointer{errSave}, "k", func(err error) { got = append(got, err) })
+
+	cps.Track([]byte("1")).Ack()
+	if !slices.Equal(got, []error{errSave}) {
+		t.Fatalf("reported %v, want %v", got, errSave)
+	}
+}
+
+type failingCheckpointer struct{ err error }
+
+func (f failingCheckpointer) Load(context.Context, string) ([]byte, error) { return nil, nil }
+func (f failingCheckpointer) Save(context.Context, string, []byte) error   { return f.err }
+
+type nopTx struct{}
+
+func (nopTx) Commit(context.Context) error { return nil }
+func (nopTx) Abort(context.Context) error  { return nil }
+
+func TestCheckpointsAckIn(t *testing.T) {
+	cp := new(memCheckpointer)
+	cps := NewCheckpoints(context.Background(), cp, "k", noFail(t))
+	a := trackAll(cps, "1", "2")
+
+	// The second event is not next, so its position waits for Ack.
+	if err := a[1].(chord.TxAcker).AckIn(context.Background(), nopTx{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a[0].(chord.TxAcker).AckIn(context.Background(), nopTx{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1"}; !slices.Equal(cp.txs, want) {
+		t.Fatalf("saved in transactions %v, want %v", cp.txs, want)
+	}
+
+	a[0].Ack()
+	if got := cp.saved(); len(got) != 0 {
+		t.Fatalf("saved %v again after the transaction", got)
+	}
+	a[1].Ack()
+	if got, want := cp.saved(), []string{"2"}; !slices.Equal(got, want) {
+		t.Fatalf("saved %v, want %v", got, want)
+	}
+}
+
+func TestFilePos(t *testing.T) {
+	p := FilePos{time.Unix(10, 5), "a/b.txt"}
+	q, err := DecodeFilePos(p.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Compare(p) != 0 || q.Name != p.Name {
+		t.Fatalf("decoded %v, want %v", q, p)
+	}
+
+	later := []FilePos{{time.Unix(10, 5), "b"}, {time.Unix(11, 0), "a"}}
+	for _, l := range later {
+		if p.Compare(l) >= 0 {
+			t.Errorf("%v does not sort before %v", p, l)
+		}
+	}
+
+	if _, err := DecodeFilePos([]byte("nope")); err == nil {
+		t.Error("decoded an invalid position")
+	}
+}
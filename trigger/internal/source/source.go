@@ -0,0 +1,95 @@
+// Package source holds what the triggers in package trigger and its
+// subpackages share: retry backoff, error reporting while events settle
+// and checkpoint tracking.
+package source
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+// Backoff waits before the given retry attempt, doubling from 100ms up to
+// 30s. It reports false if ctx is done first.
+func Backoff(ctx context.Context, attempt int) bool {
+	d := 100 * time.Millisecond << min(attempt, 9)
+	if d > 30*time.Second {
+		d = 30 * time.Second
+	}
+
+	t := chord.ClockFrom(ctx).NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C():
+		return true
+	}
+}
+
+// SettleErrors returns report, which passes errors raised while events are
+// settled, such as a failing checkpoint save, to the error path. Settling
+// runs inside Ack and Nack on the flow's consumer goroutine, which emit
+// waits on, so the errors are emitted from a goroutine of their own. Once
+// stop is called, or if they pile up, they are logged instead. The
+// producer defers stop.
+func SettleErrors[T any](ctx context.Context, emit chord.Emit[T]) (report func(error), stop func()) {
+	var (
+		ch   = make(chan error, 16)
+		done = make(chan struct{})
+		once sync.Once
+	)
+
+	go func() {
+		var zero T
+		for {
+			select {
+			case <-done:
+				return
+			case err := <-ch:
+				emit(ctx, zero, err)
+			}
+		}
+	}()
+
+	report = func(err error) {
+		select {
+		case <-done:
+		default:
+			select {
+			case ch <- err:
+				return
+			default:
+			}
+		}
+		log.Printf("chord: %v", err)
+	}
+
+	stop = func() {
+		once.Do(func() {
+			close(done)
+			for {
+				select {
+				case err := <-ch:
+					log.Printf("chord: %v", err)
+				default:
+					return
+				}
+			}
+		})
+	}
+	return report, stop
+}
+
+// AckIn passes a transaction on to a, such as the tracker of a checkpoint,
+// if it is a chord.TxAcker.
+func AckIn(ctx context.Context, a chord.Acker, tx chord.Transaction) error {
+	if ta, ok := a.(chord.TxAcker); ok {
+		return ta.AckIn(ctx, tx)
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+// Package journal is a trigger following the systemd journal. It needs
+// Linux and cgo.
+package journal
@@ -0,0 +1,117 @@
+//go:build linux && cgo
+
+package journal
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/coreos/go-systemd/v22/sdjournal"
+)
+
+type Config struct {
+	// Matches are FIELD=value filters, e.g. "_SYSTEMD_UNIT=nginx.service".
+	// Matches on the same field are ORed, different fields are ANDed.
+	Matches []string
+	// Cursor resumes after a previously seen entry. Otherwise only new
+	// entries are followed, unless FromStart is set.
+	Cursor    string
+	FromStart bool
+}
+
+type Entry struct {
+	Cursor   string
+	Time     time.Time
+	Message  string
+	Unit     string
+	Priority int
+	Fields   map[string]string
+}
+
+type Trigger struct {
+	cfg Config
+}
+
+func New(cfg Config) chord.Trigger[Entry] {
+	return Trigger{cfg}
+}
+
+func (jt Trigger) open() (*sdjournal.Journal, error) {
+	j, err := sdjournal.NewJournal()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, m := range jt.cfg.Matches {
+		if err := j.AddMatch(m); err != nil {
+			j.Close()
+			return nil, err
+		}
+	}
+
+	switch {
+	case jt.cfg.Cursor != "":
+		if err = j.SeekCursor(jt.cfg.Cursor); err == nil {
+			_, err = j.Next()
+		}
+	case jt.cfg.FromStart:
+		err = j.SeekHead()
+	default:
+		if err = j.SeekTail(); err == nil {
+			_, err = j.Previous()
+		}
+	}
+	if err != nil {
+		j.Close()
+		return nil, err
+	}
+	return j, nil
+}
+
+func (jt Trigger) Stage(ctx context.Context) chord.Stage[Entry] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Entry]) {
+		j, err := jt.open()
+		if err != nil {
+			emit(ctx, Entry{}, err)
+			return
+		}
+		defer j.Close()
+
+		for attempt := 0; ctx.Err() == nil; {
+			n, err := j.Next()
+			if err == nil && n == 0 {
+				j.Wait(time.Second)
+				continue
+			}
+
+			var e *sdjournal.JournalEntry
+			if err == nil {
+				e, err = j.GetEntry()
+			}
+			if err != nil {
+				if !emit(ctx, Entry{}, err) || !source.Backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				continue
+			}
+			attempt = 0
+
+			prio, _ := strconv.Atoi(e.Fields[sdjournal.SD_JOURNAL_FIELD_PRIORITY])
+			entry := Entry{
+				Cursor:   e.Cursor,
+				Time:     time.UnixMicro(int64(e.RealtimeTimestamp)),
+				Message:  e.Fields[sdjournal.SD_JOURNAL_FIELD_MESSAGE],
+				Unit:     e.Fields[sdjournal.SD_JOURNAL_FIELD_SYSTEMD_UNIT],
+				Priority: prio,
+				Fields:   e.Fields,
+			}
+			if !emit(ctx, entry, nil) {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,105 @@
+// Package kubernetes is a trigger emitting changes to Kubernetes resources.
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+type EventType string
+
+const (
+	Add    EventType = "add"
+	Update EventType = "update"
+	Delete EventType = "delete"
+)
+
+type Config struct {
+	Resource schema.GroupVersionResource
+	// Namespace limits the watch to one namespace; empty watches all.
+	Namespace     string
+	LabelSelector string
+	FieldSelector string
+	// Resync replays update events for every cached object on this
+	// interval. Zero disables resyncs.
+	Resync time.Duration
+}
+
+// Event describes a change to a watched object. OldObject is only
+// set for updates.
+type Event struct {
+	Type      EventType
+	Object    *unstructured.Unstructured
+	OldObject *unstructured.Unstructured
+}
+
+type Trigger struct {
+	dynamic.Interface
+	cfg Config
+}
+
+func New(c dynamic.Interface, cfg Config) chord.Trigger[Event] {
+	return Trigger{c, cfg}
+}
+
+func kubernetesObject(obj any) *unstructured.Unstructured {
+	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = d.Obj
+	}
+	u, _ := obj.(*unstructured.Unstructured)
+	return u
+}
+
+func (k Trigger) Stage(ctx context.Context) chord.Stage[Event] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Event]) {
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(
+			k.Interface, k.cfg.Resync, k.cfg.Namespace,
+			func(o *metav1.ListOptions) {
+				o.LabelSelector = k.cfg.LabelSelector
+				o.FieldSelector = k.cfg.FieldSelector
+			},
+		)
+		defer factory.Shutdown()
+
+		informer := factory.ForResource(k.cfg.Resource).Informer()
+
+		_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj any) {
+				emit(ctx, Event{Type: Add, Object: kubernetesObject(obj)}, nil)
+			},
+			UpdateFunc: func(old, obj any) {
+				emit(ctx, Event{
+					Type:      Update,
+					Object:    kubernetesObject(obj),
+					OldObject: kubernetesObject(old),
+				}, nil)
+			},
+			DeleteFunc: func(obj any) {
+				emit(ctx, Event{Type: Delete, Object: kubernetesObject(obj)}, nil)
+			},
+		})
+		if err != nil {
+			emit(ctx, Event{}, err)
+			return
+		}
+
+		factory.Start(ctx.Done())
+		if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+			if ctx.Err() == nil {
+				emit(ctx, Event{}, errors.New("kubernetes: informer cache failed to sync"))
+			}
+			return
+		}
+
+		<-ctx.Done()
+	})
+}
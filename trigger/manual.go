@@ -0,0 +1,57 @@
+package trigger
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+)
+
+// ErrStopped is returned by Manual.Emit once the stage has stopped.
+var ErrStopped = errors.New("trigger: stopped")
+
+// Manual is a trigger that application code pushes events into.
+type Manual[T any] struct {
+	ch      chan T
+	stopped chan struct{}
+	once    *sync.Once
+}
+
+func NewManual[T any]() Manual[T] {
+	return Manual[T]{
+		ch:      make(chan T),
+		stopped: make(chan struct{}),
+		once:    new(sync.Once),
+	}
+}
+
+// Emit hands v to the running stage. It is safe for concurrent use and
+// blocks until the pipeline accepts v, ctx is done, or the stage stops.
+func (m Manual[T]) Emit(ctx context.Context, v T) error {
+	select {
+	case m.ch <- v:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-m.stopped:
+		return ErrStopped
+	}
+}
+
+func (m Manual[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[T]) {
+		defer m.once.Do(func() { close(m.stopped) })
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v := <-m.ch:
+				if !emit(ctx, v, nil) {
+					return
+				}
+			}
+		}
+	})
+}
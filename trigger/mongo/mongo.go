@@ -0,0 +1,168 @@
+// Package mongo is a trigger following MongoDB change streams.
+package mongo
+
+import (
+	"context"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Watcher is implemented by *mongo.Client, *mongo.Database and
+// *mongo.Collection.
+type Watcher interface {
+	Watch(context.Context, any, ...*options.ChangeStreamOptions) (*mongo.ChangeStream, error)
+}
+
+type Config struct {
+	Pipeline     mongo.Pipeline
+	FullDocument options.FullDocument
+	// ResumeToken starts the stream after a previously checkpointed event.
+	ResumeToken bson.Raw
+	// Checkpoint is called with the event's resume token when it is acked.
+	// Its errors go to the error path.
+	Checkpoint func(context.Context, bson.Raw) error
+	// Checkpointer saves the resume token of acked changes under
+	// CheckpointKey, which defaults to "mongo", and the stream resumes
+	// from the saved one when ResumeToken is unset.
+	Checkpointer  chord.Checkpointer
+	CheckpointKey string
+}
+
+type Namespace struct {
+	Database   string `bson:"db"`
+	Collection string `bson:"coll"`
+}
+
+type Change struct {
+	OperationType     string              `bson:"operationType"`
+	Namespace         Namespace           `bson:"ns"`
+	DocumentKey       bson.M              `bson:"documentKey"`
+	FullDocument      bson.M              `bson:"fullDocument"`
+	UpdateDescription bson.M              `bson:"updateDescription"`
+	ClusterTime       primitive.Timestamp `bson:"clusterTime"`
+	ResumeToken       bson.Raw            `bson:"-"`
+
+	settle  func(ok bool)
+	once    *sync.Once
+	tracked chord.Acker
+}
+
+// Ack checkpoints the change's resume token.
+func (c Change) Ack() {
+	c.once.Do(func() { c.settle(true) })
+}
+
+func (c Change) Nack() {
+	c.once.Do(func() { c.settle(false) })
+}
+
+func (c Change) AckIn(ctx context.Context, tx chord.Transaction) error {
+	return source.AckIn(ctx, c.tracked, tx)
+}
+
+type Trigger struct {
+	w   Watcher
+	cfg Config
+}
+
+func New(w Watcher, cfg Config) chord.Trigger[Change] {
+	if cfg.Pipeline == nil {
+		cfg.Pipeline = mongo.Pipeline{}
+	}
+	if cfg.CheckpointKey == "" {
+		cfg.CheckpointKey = "mongo"
+	}
+	return Trigger{w, cfg}
+}
+
+func (m Trigger) Stage(ctx context.Context) chord.Stage[Change] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Change]) {
+		token := m.cfg.ResumeToken
+		saveCtx := context.WithoutCancel(ctx)
+
+		report, stop := source.SettleErrors(ctx, emit)
+		defer stop()
+
+		var cps *source.Checkpoints
+		if m.cfg.Checkpointer != nil {
+			cps = source.NewCheckpoints(ctx, m.cfg.Checkpointer, m.cfg.CheckpointKey, report)
+			if token == nil {
+				b, err := cps.Load()
+				if err != nil {
+					emit(ctx, Change{}, err)
+					return
+				}
+				if b != nil {
+					token = b
+				}
+			}
+		}
+
+		for attempt := 0; ; attempt++ {
+			opts := options.ChangeStream()
+			if m.cfg.FullDocument != "" {
+				opts.SetFullDocument(m.cfg.FullDocument)
+			}
+			if token != nil {
+				opts.SetResumeAfter(token)
+			}
+
+			cs, err := m.w.Watch(ctx, m.cfg.Pipeline, opts)
+			if err == nil {
+				for cs.Next(ctx) {
+					attempt = 0
+
+					// An undecodable change is reported and skipped, rather
+					// than resumed from and failing again forever.
+					token = cs.ResumeToken()
+					var c Change
+					if err := cs.Decode(&c); err != nil {
+						if !emit(ctx, Change{}, err) {
+							cs.Close(saveCtx)
+							return
+						}
+						continue
+					}
+
+					c.ResumeToken = token
+					c.once = new(sync.Once)
+					if cps != nil {
+						c.tracked = cps.Track(token)
+					}
+					t, tracked := token, c.tracked
+					c.settle = func(ok bool) {
+						if ok && m.cfg.Checkpoint != nil {
+							if err := m.cfg.Checkpoint(saveCtx, t); err != nil {
+								report(err)
+							}
+						}
+						switch {
+						case tracked == nil:
+						case ok:
+							tracked.Ack()
+						default:
+							tracked.Nack()
+						}
+					}
+
+					if !emit(chord.WithAcker(ctx, c), c, nil) {
+						cs.Close(saveCtx)
+						return
+					}
+				}
+				err = cs.Err()
+				cs.Close(saveCtx)
+			}
+
+			if ctx.Err() != nil || err != nil && !emit(ctx, Change{}, err) || !source.Backoff(ctx, attempt) {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,166 @@
+// Package mqtt is a trigger subscribing to MQTT topics with paho.
+package mqtt
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/0x180db/go-chord"
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+type Config struct {
+	Brokers  []string
+	ClientID string
+	Username string
+	Password string
+	// Topics maps topic filters to the QoS they are subscribed with.
+	Topics map[string]byte
+	// Persistent keeps the broker-side session (and queued QoS 1/2
+	// messages) across reconnects instead of starting clean.
+	Persistent bool
+	// Version selects the protocol: 5 for MQTT v5, anything else for v3.1.1.
+	Version int
+}
+
+type Message struct {
+	Topic      string
+	Payload    []byte
+	QoS        byte
+	Retained   bool
+	Duplicate  bool
+	MessageID  uint16
+	Properties map[string]string
+}
+
+// Bytes returns the payload, for trigger.Decode.
+func (m Message) Bytes() []byte { return m.Payload }
+
+type Trigger struct {
+	cfg Config
+}
+
+func New(cfg Config) chord.Trigger[Message] {
+	return Trigger{cfg}
+}
+
+func (m Trigger) Stage(ctx context.Context) chord.Stage[Message] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Message]) {
+		if m.cfg.Version == 5 {
+			m.runV5(ctx, emit)
+		} else {
+			m.runV3(ctx, emit)
+		}
+	})
+}
+
+func (m Trigger) runV3(ctx context.Context, emit chord.Emit[Message]) {
+	opts := mqtt.NewClientOptions().
+		SetClientID(m.cfg.ClientID).
+		SetUsername(m.cfg.Username).
+		SetPassword(m.cfg.Password).
+		SetCleanSession(!m.cfg.Persistent).
+		SetAutoReconnect(true)
+	for _, b := range m.cfg.Brokers {
+		opts.AddBroker(b)
+	}
+
+	handler := func(_ mqtt.Client, msg mqtt.Message) {
+		emit(ctx, Message{
+			Topic:     msg.Topic(),
+			Payload:   msg.Payload(),
+			QoS:       msg.Qos(),
+			Retained:  msg.Retained(),
+			Duplicate: msg.Duplicate(),
+			MessageID: msg.MessageID(),
+		}, nil)
+	}
+
+	opts.SetOnConnectHandler(func(c mqtt.Client) {
+		if tok := c.SubscribeMultiple(m.cfg.Topics, handler); tok.Wait() && tok.Error() != nil {
+			emit(ctx, Message{}, tok.Error())
+		}
+	})
+
+	c := mqtt.NewClient(opts)
+	if tok := c.Connect(); tok.Wait() && tok.Error() != nil {
+		emit(ctx, Message{}, tok.Error())
+		return
+	}
+	defer c.Disconnect(250)
+
+	<-ctx.Done()
+}
+
+func (m Trigger) runV5(ctx context.Context, emit chord.Emit[Message]) {
+	urls := make([]*url.URL, 0, len(m.cfg.Brokers))
+	for _, b := range m.cfg.Brokers {
+		u, err := url.Parse(b)
+		if err != nil {
+			emit(ctx, Message{}, err)
+			return
+		}
+		urls = append(urls, u)
+	}
+
+	subs := make([]paho.SubscribeOptions, 0, len(m.cfg.Topics))
+	for topic, qos := range m.cfg.Topics {
+		subs = append(subs, paho.SubscribeOptions{Topic: topic, QoS: qos})
+	}
+
+	var expiry uint32
+	if m.cfg.Persistent {
+		expiry = 0xFFFFFFFF
+	}
+
+	cfg := autopaho.ClientConfig{
+		ServerUrls:                    urls,
+		KeepAlive:                     30,
+		CleanStartOnInitialConnection: !m.cfg.Persistent,
+		SessionExpiryInterval:         expiry,
+		ConnectUsername:               m.cfg.Username,
+		ConnectPassword:               []byte(m.cfg.Password),
+		OnConnectionUp: func(cm *autopaho.ConnectionManager, _ *paho.Connack) {
+			if _, err := cm.Subscribe(ctx, &paho.Subscribe{Subscriptions: subs}); err != nil {
+				emit(ctx, Message{}, err)
+			}
+		},
+		ClientConfig: paho.ClientConfig{
+			ClientID: m.cfg.ClientID,
+			OnPublishReceived: []func(paho.PublishReceived) (bool, error){
+				func(pr paho.PublishReceived) (bool, error) {
+					p := pr.Packet
+
+					var props map[string]string
+					if p.Properties != nil && len(p.Properties.User) > 0 {
+						props = make(map[string]string, len(p.Properties.User))
+						for _, u := range p.Properties.User {
+							props[u.Key] = u.Value
+						}
+					}
+
+					emit(ctx, Message{
+						Topic:      p.Topic,
+						Payload:    p.Payload,
+						QoS:        p.QoS,
+						Retained:   p.Retain,
+						Duplicate:  p.Duplicate(),
+						MessageID:  p.PacketID,
+						Properties: props,
+					}, nil)
+					return true, nil
+				},
+			},
+		},
+	}
+
+	cm, err := autopaho.NewConnection(ctx, cfg)
+	if err != nil {
+		emit(ctx, Message{}, err)
+		return
+	}
+
+	<-cm.Done()
+}
@@ -0,0 +1,247 @@
+// Package mysql is a trigger tailing the MySQL binlog.
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/go-mysql-org/go-mysql/canal"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/go-mysql-org/go-mysql/schema"
+)
+
+type RowOp string
+
+const (
+	RowInsert RowOp = "insert"
+	RowUpdate RowOp = "update"
+	RowDelete RowOp = "delete"
+)
+
+// RowChange is a single row-level change. Before is nil for inserts and
+// After is nil for deletes.
+type RowChange struct {
+	Schema    string
+	Table     string
+	Op        RowOp
+	Before    map[string]any
+	After     map[string]any
+	Timestamp time.Time
+}
+
+type BinlogConfig struct {
+	Addr     string
+	User     string
+	Password string
+	// ServerID must be unique among the replicas of the source server.
+	ServerID uint32
+	// Tables are "schema.table" regular expressions; empty follows all.
+	Tables []string
+	// Position resumes from a binlog position instead of the current one.
+	Position mysql.Position
+	// Checkpointer saves the position after each transaction whose rows
+	// are all acked under CheckpointKey, which defaults to "mysql:" and
+	// the address, and the stage resumes from it when Position is unset.
+	Checkpointer  chord.Checkpointer
+	CheckpointKey string
+}
+
+type Binlog struct {
+	cfg BinlogConfig
+}
+
+func NewBinlog(cfg BinlogConfig) chord.Trigger[RowChange] {
+	if cfg.CheckpointKey == "" {
+		cfg.CheckpointKey = "mysql:" + cfg.Addr
+	}
+	return Binlog{cfg}
+}
+
+type mysqlRowHandler struct {
+	canal.DummyEventHandler
+	ctx  context.Context
+	emit chord.Emit[RowChange]
+
+	// Rows are tracked with the position before their transaction, so
+	// resuming replays a transaction whose rows were not all acked.
+	cps *source.Checkpoints
+	pos []byte
+}
+
+// OnPosSynced tracks the end of a transaction, which is saved once its
+// rows are acked.
+func (h *mysqlRowHandler) OnPosSynced(_ *replication.EventHeader, pos mysql.Position, _ mysql.GTIDSet, _ bool) error {
+	if h.cps == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(pos)
+	if err != nil {
+		return err
+	}
+	h.pos = b
+	h.cps.Track(b).Ack()
+	return nil
+}
+
+func mysqlRow(t *schema.Table, row []any) map[string]any {
+	m := make(map[string]any, len(t.Columns))
+	for i, c := range t.Columns {
+		if i < len(row) {
+			m[c.Name] = row[i]
+		}
+	}
+	return m
+}
+
+func (h *mysqlRowHandler) OnRow(e *canal.RowsEvent) error {
+	ts := time.Unix(int64(e.Header.Timestamp), 0)
+
+	step := 1
+	if e.Action == canal.UpdateAction {
+		step = 2
+	}
+
+	for i := 0; i+step <= len(e.Rows); i += step {
+		rc := RowChange{Schema: e.Table.Schema, Table: e.Table.Name, Timestamp: ts}
+
+		switch e.Action {
+		case canal.InsertAction:
+			rc.Op, rc.After = RowInsert, mysqlRow(e.Table, e.Rows[i])
+		case canal.DeleteAction:
+			rc.Op, rc.Before = RowDelete, mysqlRow(e.Table, e.Rows[i])
+		case canal.UpdateAction:
+			rc.Op = RowUpdate
+			rc.Before, rc.After = mysqlRow(e.Table, e.Rows[i]), mysqlRow(e.Table, e.Rows[i+1])
+		default:
+			return nil
+		}
+
+		ctx := h.ctx
+		if h.cps != nil {
+			ctx = chord.WithAcker(ctx, h.cps.Track(h.pos))
+		}
+		if !h.emit(ctx, rc, nil) {
+			return h.ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (h *mysqlRowHandler) String() string {
+	return "chord"
+}
+
+func (m Binlog) Stage(ctx context.Context) chord.Stage[RowChange] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[RowChange]) {
+		cfg := canal.NewDefaultConfig()
+		cfg.Addr = m.cfg.Addr
+		cfg.User = m.cfg.User
+		cfg.Password = m.cfg.Password
+		cfg.ServerID = m.cfg.ServerID
+		cfg.IncludeTableRegex = m.cfg.Tables
+		cfg.Dump.ExecutionPath = ""
+
+		c, err := canal.NewCanal(cfg)
+		if err != nil {
+			emit(ctx, RowChange{}, err)
+			return
+		}
+		defer c.Close()
+
+		h := &mysqlRowHandler{ctx: ctx, emit: emit}
+		c.SetEventHandler(h)
+
+		report, stop := source.SettleErrors(ctx, emit)
+		defer stop()
+
+		pos := m.cfg.Position
+		if m.cfg.Checkpointer != nil {
+			h.cps = source.NewCheckpoints(ctx, m.cfg.Checkpointer, m.cfg.CheckpointKey, report)
+			if pos.Name == "" {
+				b, err := h.cps.Load()
+				if err == nil && b != nil {
+					err = json.Unmarshal(b, &pos)
+				}
+				if err != nil {
+					emit(ctx, RowChange{}, err)
+					return
+				}
+			}
+		}
+		if pos.Name == "" {
+			if pos, err = c.GetMasterPos(); err != nil {
+				emit(ctx, RowChange{}, err)
+				return
+			}
+		}
+		if h.cps != nil {
+			h.pos, _ = json.Marshal(pos)
+		}
+
+		errc := make(chan error, 1)
+		go func() { errc <- c.RunFrom(pos) }()
+
+		select {
+		case <-ctx.Done():
+		case err := <-errc:
+			if err != nil && ctx.Err() == nil {
+				emit(ctx, RowChange{}, err)
+			}
+		}
+	})
+}
+
+type debeziumEnvelope struct {
+	Payload *debeziumEnvelope `json:"payload"`
+	Before  map[string]any    `json:"before"`
+	After   map[string]any    `json:"after"`
+	Op      string            `json:"op"`
+	TsMs    int64             `json:"ts_ms"`
+	Source  struct {
+		Db    string `json:"db"`
+		Table string `json:"table"`
+	} `json:"source"`
+}
+
+// ParseDebezium decodes a Debezium JSON change event, with or without the
+// schema wrapper, so a Debezium topic read by any trigger can be turned into
+// the same RowChange events with a stage:
+//
+//	chord.NewStage(s, func(ctx context.Context, b []byte) (mysql.RowChange, error) {
+//		return mysql.ParseDebezium(b)
+//	})
+func ParseDebezium(b []byte) (RowChange, error) {
+	var e debeziumEnvelope
+	if err := json.Unmarshal(b, &e); err != nil {
+		return RowChange{}, err
+	}
+	if e.Payload != nil {
+		e = *e.Payload
+	}
+
+	rc := RowChange{
+		Schema:    e.Source.Db,
+		Table:     e.Source.Table,
+		Before:    e.Before,
+		After:     e.After,
+		Timestamp: time.UnixMilli(e.TsMs),
+	}
+
+	switch e.Op {
+	case "c", "r":
+		rc.Op = RowInsert
+	case "u":
+		rc.Op = RowUpdate
+	case "d":
+		rc.Op = RowDelete
+	default:
+		return RowChange{}, fmt.Errorf("debezium: unknown op %q", e.Op)
+	}
+	return rc, nil
+}
@@ -0,0 +1,154 @@
+package trigger
+
+import (
+	"context"
+	"database/sql"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+)
+
+type OutboxConfig struct {
+	// Table is the outbox table written by sink.NewOutbox.
+	Table string
+	// BatchSize caps the rows read per query. It defaults to 100.
+	BatchSize int
+	// Interval is how often the table is polled once it has been drained.
+	// It defaults to 1s.
+	Interval time.Duration
+}
+
+// OutboxRow is a message recorded by sink.NewOutbox. Acking it deletes
+// the row; a nacked row is emitted again by a later poll.
+type OutboxRow struct {
+	ID        int64
+	Topic     string
+	Key       string
+	Payload   []byte
+	CreatedAt time.Time
+
+	settle func(bool)
+	once   *sync.Once
+}
+
+func (r OutboxRow) Ack() {
+	r.once.Do(func() { r.settle(true) })
+}
+
+func (r OutboxRow) Nack() {
+	r.once.Do(func() { r.settle(false) })
+}
+
+type Outbox struct {
+	db  *sql.DB
+	cfg OutboxConfig
+}
+
+// NewOutbox is the relay side of the outbox pattern: it emits the rows of
+// an outbox table in insertion order, for a flow whose sink publishes them
+// to a broker. Rows are deleted once published, so delivery is at least
+// once. Run one relay per table.
+func NewOutbox(db *sql.DB, cfg OutboxConfig) chord.Trigger[OutboxRow] {
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = 100
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Second
+	}
+	return Outbox{db, cfg}
+}
+
+// poll reads the oldest rows that are not in flight.
+func (o Outbox) poll(ctx context.Context, inflight map[int64]bool) ([]OutboxRow, error) {
+	rows, err := o.db.QueryContext(ctx, "SELECT id, topic, key, payload, created_at FROM "+o.cfg.Table+
+		" ORDER BY id LIMIT $1", o.cfg.BatchSize+len(inflight))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []OutboxRow
+	for rows.Next() {
+		var r OutboxRow
+		if err := rows.Scan(&r.ID, &r.Topic, &r.Key, &r.Payload, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		if !inflight[r.ID] {
+			out = append(out, r)
+		}
+	}
+	return out, rows.Err()
+}
+
+func (o Outbox) Ping(ctx context.Context) error { return o.db.PingContext(ctx) }
+
+func (o Outbox) Stage(ctx context.Context) chord.Stage[OutboxRow] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[OutboxRow]) {
+		report, stop := source.SettleErrors(ctx, emit)
+		defer stop()
+
+		var mu sync.Mutex
+		inflight := make(map[int64]bool)
+
+		settle := func(id int64) func(bool) {
+			return func(ok bool) {
+				var err error
+				if ok {
+					_, err = o.db.ExecContext(context.WithoutCancel(ctx), "DELETE FROM "+o.cfg.Table+" WHERE id = $1", id)
+				}
+
+				mu.Lock()
+				delete(inflight, id)
+				mu.Unlock()
+
+				if err != nil {
+					report(err)
+				}
+			}
+		}
+
+		for attempt := 0; ; {
+			mu.Lock()
+			busy := maps.Clone(inflight)
+			mu.Unlock()
+
+			rows, err := o.poll(ctx, busy)
+
+			mu.Lock()
+			for _, r := range rows {
+				inflight[r.ID] = true
+			}
+			mu.Unlock()
+
+			if err != nil {
+				if ctx.Err() != nil || !emit(ctx, OutboxRow{}, err) || !source.Backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				continue
+			}
+			attempt = 0
+
+			for _, r := range rows {
+				r.settle = settle(r.ID)
+				r.once = new(sync.Once)
+				if !emit(chord.WithAcker(ctx, r), r, nil) {
+					return
+				}
+			}
+
+			if len(rows) < o.cfg.BatchSize {
+				t := chord.ClockFrom(ctx).NewTimer(o.cfg.Interval)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return
+				case <-t.C():
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,93 @@
+// Package postgres is a trigger emitting Postgres notifications.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/jackc/pgx/v5"
+)
+
+type NotifyConfig struct {
+	ConnString string
+	Channels   []string
+	// KeepAlive is how long the connection may sit idle before it is
+	// pinged. A failed ping reconnects and re-issues LISTEN.
+	KeepAlive time.Duration
+}
+
+type Notification struct {
+	Channel string
+	Payload string
+	PID     uint32
+}
+
+type Notify struct {
+	cfg NotifyConfig
+}
+
+func NewNotify(cfg NotifyConfig) chord.Trigger[Notification] {
+	if cfg.KeepAlive == 0 {
+		cfg.KeepAlive = 30 * time.Second
+	}
+	return Notify{cfg}
+}
+
+func (p Notify) listen(ctx context.Context) (*pgx.Conn, error) {
+	conn, err := pgx.Connect(ctx, p.cfg.ConnString)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ch := range p.cfg.Channels {
+		if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{ch}.Sanitize()); err != nil {
+			conn.Close(context.WithoutCancel(ctx))
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// wait emits notifications from conn until it fails or ctx is done.
+func (p Notify) wait(ctx context.Context, conn *pgx.Conn, emit chord.Emit[Notification]) error {
+	for {
+		waitCtx, cancel := context.WithTimeout(ctx, p.cfg.KeepAlive)
+		n, err := conn.WaitForNotification(waitCtx)
+		cancel()
+
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, context.DeadlineExceeded):
+			if err := conn.Ping(ctx); err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			if !emit(ctx, Notification{Channel: n.Channel, Payload: n.Payload, PID: n.PID}, nil) {
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+func (p Notify) Stage(ctx context.Context) chord.Stage[Notification] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Notification]) {
+		for attempt := 0; ; attempt++ {
+			conn, err := p.listen(ctx)
+			if err == nil {
+				attempt = 0
+				err = p.wait(ctx, conn, emit)
+				conn.Close(context.WithoutCancel(ctx))
+			}
+
+			if ctx.Err() != nil || !emit(ctx, Notification{}, err) || !source.Backoff(ctx, attempt) {
+				return
+			}
+		}
+	})
+}
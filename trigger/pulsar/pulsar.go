@@ -0,0 +1,65 @@
+// Package pulsar is a trigger consuming Apache Pulsar topics.
+package pulsar
+
+import (
+	"context"
+	"sync"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+type Message struct {
+	pulsar.Message
+	consumer pulsar.Consumer
+	once     *sync.Once
+}
+
+func (m Message) Ack() {
+	m.once.Do(func() { m.consumer.Ack(m.Message) })
+}
+
+func (m Message) Nack() {
+	m.once.Do(func() { m.consumer.Nack(m.Message) })
+}
+
+type Trigger struct {
+	client pulsar.Client
+	opts   pulsar.ConsumerOptions
+}
+
+// New subscribes with opts once the stage starts. The subscription
+// mode (shared, failover, key-shared, exclusive) is taken from opts.Type.
+func New(client pulsar.Client, opts pulsar.ConsumerOptions) chord.Trigger[Message] {
+	return Trigger{client, opts}
+}
+
+func (p Trigger) Stage(ctx context.Context) chord.Stage[Message] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Message]) {
+		consumer, err := p.client.Subscribe(p.opts)
+		if err != nil {
+			emit(ctx, Message{}, err)
+			return
+		}
+		defer consumer.Close()
+
+		for attempt := 0; ; {
+			m, err := consumer.Receive(ctx)
+			if err != nil {
+				if ctx.Err() != nil || !emit(ctx, Message{}, err) || !source.Backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				continue
+			}
+			attempt = 0
+
+			msg := Message{Message: m, consumer: consumer, once: new(sync.Once)}
+			if !emit(chord.WithAcker(ctx, msg), msg, nil) {
+				consumer.Nack(m)
+				return
+			}
+		}
+	})
+}
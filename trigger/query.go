@@ -0,0 +1,48 @@
+package trigger
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Query[T any] struct {
+	db    *sql.DB
+	scan  func(*sql.Rows) (T, error)
+	query string
+	args  []any
+}
+
+// NewQuery emits one event per row of query, read with scan, and completes
+// the stage after the last row. It suits backfills from a database (see
+// chord.Backfill). Rows that fail to scan are routed to the error path.
+func NewQuery[T any](db *sql.DB, scan func(*sql.Rows) (T, error), query string, args ...any) chord.Trigger[T] {
+	return Query[T]{db, scan, query, args}
+}
+
+func (q Query[T]) Ping(ctx context.Context) error { return q.db.PingContext(ctx) }
+
+func (q Query[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[T]) {
+		var zero T
+
+		rows, err := q.db.QueryContext(ctx, q.query, q.args...)
+		if err != nil {
+			emit(ctx, zero, err)
+			return
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			v, err := q.scan(rows)
+			if !emit(ctx, v, err) {
+				return
+			}
+		}
+
+		if err := rows.Err(); err != nil {
+			emit(ctx, zero, err)
+		}
+	})
+}
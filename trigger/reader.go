@@ -0,0 +1,98 @@
+package trigger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+
+	"github.com/0x180db/go-chord"
+)
+
+type ReaderConfig struct {
+	// Size emits fixed-size chunks; the last one may be shorter.
+	Size int
+	// Delimiter splits the stream instead, dropping the delimiter.
+	Delimiter []byte
+	// MaxSize caps a delimited chunk. It defaults to 1MiB.
+	MaxSize int
+}
+
+type Reader struct {
+	io.Reader
+	cfg ReaderConfig
+}
+
+// NewReader emits the stream read from r in chunks and completes the stage
+// at EOF. Without a Size or Delimiter it emits whatever each Read returns.
+func NewReader(r io.Reader, cfg ReaderConfig) chord.Trigger[[]byte] {
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = 1 << 20
+	}
+	return Reader{r, cfg}
+}
+
+func splitDelimiter(delim []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.Index(data, delim); i >= 0 {
+			return i + len(delim), data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
+
+func (r Reader) Stage(ctx context.Context) chord.Stage[[]byte] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[[]byte]) {
+		if len(r.cfg.Delimiter) > 0 {
+			sc := bufio.NewScanner(r.Reader)
+			sc.Buffer(make([]byte, 0, min(64<<10, r.cfg.MaxSize)), r.cfg.MaxSize)
+			sc.Split(splitDelimiter(r.cfg.Delimiter))
+
+			for sc.Scan() {
+				if !emit(ctx, bytes.Clone(sc.Bytes()), nil) {
+					return
+				}
+			}
+			if err := sc.Err(); err != nil {
+				emit(ctx, nil, err)
+			}
+			return
+		}
+
+		size := r.cfg.Size
+		if size == 0 {
+			size = 32 << 10
+		}
+
+		for {
+			buf := make([]byte, size)
+
+			var (
+				n   int
+				err error
+			)
+			if r.cfg.Size > 0 {
+				n, err = io.ReadFull(r.Reader, buf)
+			} else {
+				n, err = r.Read(buf)
+			}
+
+			if n > 0 && !emit(ctx, buf[:n], nil) {
+				return
+			}
+
+			switch {
+			case err == nil:
+			case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+				return
+			default:
+				emit(ctx, nil, err)
+				return
+			}
+		}
+	})
+}
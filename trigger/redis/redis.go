@@ -0,0 +1,213 @@
+// Package redis holds triggers reading Redis channels and streams.
+package redis
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/redis/go-redis/v9"
+)
+
+type PubSubConfig struct {
+	Channels []string
+	Patterns []string
+}
+
+type Message struct {
+	Channel string
+	Pattern string
+	Payload string
+}
+
+type PubSub struct {
+	redis.UniversalClient
+	cfg PubSubConfig
+}
+
+func NewPubSub(rdb redis.UniversalClient, cfg PubSubConfig) chord.Trigger[Message] {
+	return PubSub{rdb, cfg}
+}
+
+// Stage subscribes to the configured channels and patterns. Connection
+// errors are reported to the error path; the subscription reconnects and
+// resubscribes on the next receive.
+func (r PubSub) Stage(ctx context.Context) chord.Stage[Message] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Message]) {
+		ps := r.Subscribe(ctx, r.cfg.Channels...)
+		defer ps.Close()
+
+		if len(r.cfg.Patterns) > 0 {
+			if err := ps.PSubscribe(ctx, r.cfg.Patterns...); err != nil {
+				emit(ctx, Message{}, err)
+				return
+			}
+		}
+
+		for attempt := 0; ; {
+			m, err := ps.ReceiveMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil || !emit(ctx, Message{}, err) || !source.Backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				continue
+			}
+			attempt = 0
+
+			if !emit(ctx, Message{Channel: m.Channel, Pattern: m.Pattern, Payload: m.Payload}, nil) {
+				return
+			}
+		}
+	})
+}
+
+type StreamConfig struct {
+	Stream   string
+	Group    string
+	Consumer string
+	// Count caps the entries read per XREADGROUP call.
+	Count int64
+	// Block is how long a read waits for new entries.
+	Block time.Duration
+	// ClaimMinIdle is how long an entry must stay pending before it is
+	// reclaimed from its consumer with XAUTOCLAIM. Zero disables reclaiming.
+	ClaimMinIdle time.Duration
+	// ClaimInterval is how often pending entries are checked.
+	ClaimInterval time.Duration
+}
+
+type StreamMessage struct {
+	Stream string
+	ID     string
+	Values map[string]any
+	ack    func()
+	once   *sync.Once
+}
+
+// Ack removes the entry from the group's pending list.
+func (m StreamMessage) Ack() {
+	m.once.Do(m.ack)
+}
+
+// Nack leaves the entry pending so it is reclaimed once ClaimMinIdle passes.
+func (m StreamMessage) Nack() {
+	m.once.Do(func() {})
+}
+
+type Stream struct {
+	redis.UniversalClient
+	cfg StreamConfig
+}
+
+func NewStream(rdb redis.UniversalClient, cfg StreamConfig) chord.Trigger[StreamMessage] {
+	if cfg.Block == 0 {
+		cfg.Block = 5 * time.Second
+	}
+	if cfg.ClaimInterval == 0 {
+		cfg.ClaimInterval = cfg.ClaimMinIdle
+	}
+	return Stream{rdb, cfg}
+}
+
+func (r Stream) message(ctx context.Context, m redis.XMessage) StreamMessage {
+	ackCtx := context.WithoutCancel(ctx)
+
+	return StreamMessage{
+		Stream: r.cfg.Stream,
+		ID:     m.ID,
+		Values: m.Values,
+		ack: func() {
+			r.XAck(ackCtx, r.cfg.Stream, r.cfg.Group, m.ID)
+		},
+		once: new(sync.Once),
+	}
+}
+
+// claim emits entries left pending by other consumers for at least
+// ClaimMinIdle. It reports false once the trigger context is done.
+func (r Stream) claim(ctx context.Context, emit chord.Emit[StreamMessage]) bool {
+	start := "0-0"
+
+	for {
+		msgs, next, err := r.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   r.cfg.Stream,
+			Group:    r.cfg.Group,
+			Consumer: r.cfg.Consumer,
+			MinIdle:  r.cfg.ClaimMinIdle,
+			Start:    start,
+			Count:    r.cfg.Count,
+		}).Result()
+		if err != nil {
+			return ctx.Err() == nil && emit(ctx, StreamMessage{}, err)
+		}
+
+		for _, m := range msgs {
+			msg := r.message(ctx, m)
+			if !emit(chord.WithAcker(ctx, msg), msg, nil) {
+				return false
+			}
+		}
+
+		if next == "0-0" || len(msgs) == 0 {
+			return true
+		}
+		start = next
+	}
+}
+
+func (r Stream) Stage(ctx context.Context) chord.Stage[StreamMessage] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[StreamMessage]) {
+		err := r.XGroupCreateMkStream(ctx, r.cfg.Stream, r.cfg.Group, "$").Err()
+		if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			emit(ctx, StreamMessage{}, err)
+			return
+		}
+
+		clock := chord.ClockFrom(ctx)
+		var lastClaim time.Time
+
+		for attempt := 0; ; {
+			// A failed claim waits for the next ClaimInterval too, rather
+			// than being retried before every read.
+			if now := clock.Now(); r.cfg.ClaimMinIdle > 0 && now.Sub(lastClaim) >= r.cfg.ClaimInterval {
+				lastClaim = now
+				if !r.claim(ctx, emit) {
+					return
+				}
+			}
+
+			streams, err := r.XReadGroup(ctx, &redis.XReadGroupArgs{
+				Group:    r.cfg.Group,
+				Consumer: r.cfg.Consumer,
+				Streams:  []string{r.cfg.Stream, ">"},
+				Count:    r.cfg.Count,
+				Block:    r.cfg.Block,
+			}).Result()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if err != nil {
+				if ctx.Err() != nil || !emit(ctx, StreamMessage{}, err) || !source.Backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				continue
+			}
+			attempt = 0
+
+			for _, s := range streams {
+				for _, m := range s.Messages {
+					msg := r.message(ctx, m)
+					if !emit(chord.WithAcker(ctx, msg), msg, nil) {
+						return
+					}
+				}
+			}
+		}
+	})
+}
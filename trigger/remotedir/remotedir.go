@@ -0,0 +1,277 @@
+// Package remotedir is a trigger polling SFTP and FTP directories.
+package remotedir
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/jlaffaye/ftp"
+	"github.com/pkg/sftp"
+)
+
+// FS is the subset of a remote file server the remote directory
+// trigger needs. See SftpFS and FtpFS.
+type FS interface {
+	ReadDir(dir string) ([]fs.FileInfo, error)
+	Open(name string) (io.ReadCloser, error)
+}
+
+type sftpFS struct {
+	*sftp.Client
+}
+
+func SftpFS(c *sftp.Client) FS {
+	return sftpFS{c}
+}
+
+func (s sftpFS) Open(name string) (io.ReadCloser, error) {
+	return s.Client.Open(name)
+}
+
+type ftpFS struct {
+	*ftp.ServerConn
+}
+
+// FtpFS adapts an FTP connection. FTP serves one transfer per connection,
+// so close a file's body before the next scan lists the directory.
+func FtpFS(c *ftp.ServerConn) FS {
+	return ftpFS{c}
+}
+
+type ftpFileInfo struct {
+	*ftp.Entry
+}
+
+func (f ftpFileInfo) Name() string       { return f.Entry.Name }
+func (f ftpFileInfo) Size() int64        { return int64(f.Entry.Size) }
+func (f ftpFileInfo) Mode() fs.FileMode  { return 0 }
+func (f ftpFileInfo) ModTime() time.Time { return f.Entry.Time }
+func (f ftpFileInfo) IsDir() bool        { return f.Entry.Type == ftp.EntryTypeFolder }
+func (f ftpFileInfo) Sys() any           { return f.Entry }
+
+func (f ftpFS) ReadDir(dir string) ([]fs.FileInfo, error) {
+	entries, err := f.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		if e.Type == ftp.EntryTypeFile {
+			infos = append(infos, ftpFileInfo{e})
+		}
+	}
+	return infos, nil
+}
+
+func (f ftpFS) Open(name string) (io.ReadCloser, error) {
+	return f.Retr(name)
+}
+
+// ProcessedNames remembers which remote files have been handled so each is
+// emitted only once, including across restarts when it is persistent.
+type ProcessedNames interface {
+	Contains(name string) bool
+	Add(name string) error
+}
+
+type memNames struct {
+	mu    sync.Mutex
+	names map[string]bool
+}
+
+func (m *memNames) Contains(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.names[name]
+}
+
+func (m *memNames) Add(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.names[name] = true
+	return nil
+}
+
+type Config struct {
+	Dir string
+	// Pattern is a path.Match glob applied to file names.
+	Pattern  string
+	Interval time.Duration
+	// Processed defaults to an in-memory set.
+	Processed ProcessedNames
+	// Checkpointer saves the modification time and name of the files
+	// acked so far under CheckpointKey, which defaults to "remotedir:" and
+	// Dir, so that an in-memory Processed set survives restarts: files at
+	// or before the checkpoint are skipped, including ones that appear
+	// later with an older modification time.
+	Checkpointer  chord.Checkpointer
+	CheckpointKey string
+}
+
+// File is a file found on the remote server. Its content is not
+// fetched until Open is called, so large files stream through the stage.
+type File struct {
+	Path    string
+	Name    string
+	Size    int64
+	ModTime time.Time
+	fs      FS
+	settle  func(bool)
+	once    *sync.Once
+	tracked chord.Acker
+}
+
+func (f File) Open() (io.ReadCloser, error) {
+	return f.fs.Open(f.Path)
+}
+
+// Ack records the file as processed.
+func (f File) Ack() {
+	f.once.Do(func() { f.settle(true) })
+}
+
+// Nack lets the next scan emit the file again.
+func (f File) Nack() {
+	f.once.Do(func() { f.settle(false) })
+}
+
+func (f File) AckIn(ctx context.Context, tx chord.Transaction) error {
+	return source.AckIn(ctx, f.tracked, tx)
+}
+
+type Trigger struct {
+	fs  FS
+	cfg Config
+}
+
+func New(fs FS, cfg Config) chord.Trigger[File] {
+	if cfg.Interval == 0 {
+		cfg.Interval = 30 * time.Second
+	}
+	if cfg.Processed == nil {
+		cfg.Processed = &memNames{names: make(map[string]bool)}
+	}
+	if cfg.CheckpointKey == "" {
+		cfg.CheckpointKey = "remotedir:" + cfg.Dir
+	}
+	return Trigger{fs, cfg}
+}
+
+func (r Trigger) Stage(ctx context.Context) chord.Stage[File] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[File]) {
+		report, stop := source.SettleErrors(ctx, emit)
+		defer stop()
+
+		var mu sync.Mutex
+		inflight := make(map[string]bool)
+
+		var (
+			cps  *source.Checkpoints
+			done *source.FilePos
+		)
+		if r.cfg.Checkpointer != nil {
+			cps = source.NewCheckpoints(ctx, r.cfg.Checkpointer, r.cfg.CheckpointKey, report)
+
+			var err error
+			if done, err = source.LoadFilePos(cps); err != nil {
+				emit(ctx, File{}, err)
+				return
+			}
+		}
+
+		settle := func(name string, tracked chord.Acker) func(bool) {
+			return func(ok bool) {
+				var err error
+				if ok {
+					err = r.cfg.Processed.Add(name)
+				}
+
+				mu.Lock()
+				delete(inflight, name)
+				mu.Unlock()
+
+				switch {
+				case tracked == nil:
+				case ok:
+					tracked.Ack()
+				default:
+					tracked.Nack()
+				}
+				if err != nil {
+					report(err)
+				}
+			}
+		}
+
+		tick := chord.ClockFrom(ctx).NewTicker(r.cfg.Interval)
+		defer tick.Stop()
+
+		for {
+			infos, err := r.fs.ReadDir(r.cfg.Dir)
+			if err != nil && !emit(ctx, File{}, err) {
+				return
+			}
+			slices.SortFunc(infos, func(a, b fs.FileInfo) int {
+				return source.FilePos{Mod: a.ModTime(), Name: a.Name()}.Compare(source.FilePos{Mod: b.ModTime(), Name: b.Name()})
+			})
+
+			for _, fi := range infos {
+				name := fi.Name()
+				if fi.IsDir() || r.cfg.Processed.Contains(name) {
+					continue
+				}
+				if r.cfg.Pattern != "" {
+					if ok, err := path.Match(r.cfg.Pattern, name); err != nil || !ok {
+						continue
+					}
+				}
+
+				pos := source.FilePos{Mod: fi.ModTime(), Name: name}
+				if done != nil && pos.Compare(*done) <= 0 {
+					continue
+				}
+
+				mu.Lock()
+				busy := inflight[name]
+				inflight[name] = true
+				mu.Unlock()
+				if busy {
+					continue
+				}
+
+				var tracked chord.Acker
+				if cps != nil {
+					tracked = cps.Track(pos.Encode())
+				}
+
+				f := File{
+					Path:    path.Join(r.cfg.Dir, name),
+					Name:    name,
+					Size:    fi.Size(),
+					ModTime: fi.ModTime(),
+					fs:      r.fs,
+					settle:  settle(name, tracked),
+					once:    new(sync.Once),
+					tracked: tracked,
+				}
+				if !emit(chord.WithAcker(ctx, f), f, nil) {
+					return
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C():
+			}
+		}
+	})
+}
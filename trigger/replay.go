@@ -0,0 +1,103 @@
+package trigger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type recordedEvent[T any] struct {
+	Time  time.Time `json:"t"`
+	Event T         `json:"v"`
+}
+
+type Recorder[T any] struct {
+	t  chord.Trigger[T]
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+// Record wraps t so that every event it emits is also written to w as a
+// timestamped JSON line, for later use with NewReplay. Events that fail to
+// be recorded are routed to the error path.
+func Record[T any](t chord.Trigger[T], w io.Writer) chord.Trigger[T] {
+	return Recorder[T]{t, new(sync.Mutex), w}
+}
+
+func (r Recorder[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return chord.NewStage(r.t.Stage(ctx), func(ctx context.Context, v T) (T, error) {
+		b, err := json.Marshal(recordedEvent[T]{chord.ClockFrom(ctx).Now(), v})
+		if err != nil {
+			return v, err
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		_, err = r.w.Write(append(b, '\n'))
+		return v, err
+	})
+}
+
+type Replay[T any] struct {
+	r     io.Reader
+	speed float64
+}
+
+// NewReplay re-emits a recording made with Record and completes the stage
+// at its end. A speed of 1 keeps the original pacing, 2 replays twice as
+// fast, and 0 emits everything as fast as the pipeline accepts it.
+func NewReplay[T any](r io.Reader, speed float64) chord.Trigger[T] {
+	return Replay[T]{r, speed}
+}
+
+func (rp Replay[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[T]) {
+		sc := bufio.NewScanner(rp.r)
+		sc.Buffer(make([]byte, 0, 64<<10), 16<<20)
+
+		var first time.Time
+		clock := chord.ClockFrom(ctx)
+		start := clock.Now()
+
+		for sc.Scan() {
+			var ev recordedEvent[T]
+			if err := json.Unmarshal(sc.Bytes(), &ev); err != nil {
+				if !emit(ctx, ev.Event, err) {
+					return
+				}
+				continue
+			}
+
+			if first.IsZero() {
+				first = ev.Time
+			}
+			if rp.speed > 0 {
+				due := start.Add(time.Duration(float64(ev.Time.Sub(first)) / rp.speed))
+				if wait := due.Sub(clock.Now()); wait > 0 {
+					t := clock.NewTimer(wait)
+					select {
+					case <-ctx.Done():
+						t.Stop()
+						return
+					case <-t.C():
+					}
+				}
+			}
+
+			if !emit(ctx, ev.Event, nil) {
+				return
+			}
+		}
+
+		if err := sc.Err(); err != nil {
+			var zero T
+			emit(ctx, zero, err)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package trigger
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Scanner struct {
+	io.Reader
+	split bufio.SplitFunc
+}
+
+// NewScanner emits the tokens split from r, one per event, and completes
+// the stage when r is exhausted. A nil split scans lines.
+func NewScanner(r io.Reader, split bufio.SplitFunc) chord.Trigger[string] {
+	if split == nil {
+		split = bufio.ScanLines
+	}
+	return Scanner{r, split}
+}
+
+func NewStdin() chord.Trigger[string] {
+	return NewScanner(os.Stdin, bufio.ScanLines)
+}
+
+func (s Scanner) Stage(ctx context.Context) chord.Stage[string] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[string]) {
+		sc := bufio.NewScanner(s.Reader)
+		sc.Split(s.split)
+
+		for sc.Scan() {
+			if !emit(ctx, sc.Text(), nil) {
+				return
+			}
+		}
+
+		if err := sc.Err(); err != nil {
+			emit(ctx, "", err)
+		}
+	})
+}
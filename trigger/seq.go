@@ -0,0 +1,33 @@
+package trigger
+
+import (
+	"context"
+	"iter"
+	"slices"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Seq[T any] struct {
+	seq iter.Seq[T]
+}
+
+// FromSeq emits each element of seq once and then completes the stage.
+func FromSeq[T any](seq iter.Seq[T]) chord.Trigger[T] {
+	return Seq[T]{seq}
+}
+
+// FromSlice emits each item once and then completes the stage.
+func FromSlice[T any](items []T) chord.Trigger[T] {
+	return FromSeq(slices.Values(items))
+}
+
+func (s Seq[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[T]) {
+		for v := range s.seq {
+			if !emit(ctx, v, nil) {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,77 @@
+// Package serial is a trigger reading serial devices.
+package serial
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+
+	"github.com/0x180db/go-chord"
+	"go.bug.st/serial"
+)
+
+type Config struct {
+	Device string
+	// Mode sets baud rate, data bits, parity and stop bits.
+	Mode serial.Mode
+	// Split frames the byte stream, e.g. bufio.ScanLines or
+	// SplitLengthPrefix. When nil, each read is emitted as it arrives.
+	Split bufio.SplitFunc
+	// MaxSize caps a frame. It defaults to 64KiB.
+	MaxSize int
+}
+
+type Trigger struct {
+	cfg Config
+}
+
+func New(cfg Config) chord.Trigger[[]byte] {
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = 64 << 10
+	}
+	return Trigger{cfg}
+}
+
+func (s Trigger) Stage(ctx context.Context) chord.Stage[[]byte] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[[]byte]) {
+		port, err := serial.Open(s.cfg.Device, &s.cfg.Mode)
+		if err != nil {
+			emit(ctx, nil, err)
+			return
+		}
+		defer port.Close()
+
+		stop := context.AfterFunc(ctx, func() { port.Close() })
+		defer stop()
+
+		if s.cfg.Split == nil {
+			buf := make([]byte, s.cfg.MaxSize)
+			for {
+				n, err := port.Read(buf)
+				if ctx.Err() != nil {
+					return
+				}
+				if err != nil {
+					emit(ctx, nil, err)
+					return
+				}
+				if n > 0 && !emit(ctx, bytes.Clone(buf[:n]), nil) {
+					return
+				}
+			}
+		}
+
+		sc := bufio.NewScanner(port)
+		sc.Buffer(make([]byte, 0, min(4<<10, s.cfg.MaxSize)), s.cfg.MaxSize)
+		sc.Split(s.cfg.Split)
+
+		for sc.Scan() {
+			if !emit(ctx, bytes.Clone(sc.Bytes()), nil) {
+				return
+			}
+		}
+		if err := sc.Err(); err != nil && ctx.Err() == nil {
+			emit(ctx, nil, err)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package trigger
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/0x180db/go-chord"
+)
+
+type Signal struct {
+	sigs []os.Signal
+}
+
+// NewSignal emits each received signal of the given kinds, or of every
+// kind when none are given. Signals delivered while the pipeline is busy
+// with the previous one may be coalesced.
+func NewSignal(sigs ...os.Signal) chord.Trigger[os.Signal] {
+	return Signal{sigs}
+}
+
+func (s Signal) Stage(ctx context.Context) chord.Stage[os.Signal] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[os.Signal]) {
+		sc := make(chan os.Signal, 1)
+		signal.Notify(sc, s.sigs...)
+		defer signal.Stop(sc)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-sc:
+				if !emit(ctx, sig, nil) {
+					return
+				}
+			}
+		}
+	})
+}
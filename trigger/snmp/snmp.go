@@ -0,0 +1,76 @@
+// Package snmp is a trigger receiving SNMP traps and informs.
+package snmp
+
+import (
+	"context"
+	"net"
+
+	"github.com/0x180db/go-chord"
+	"github.com/gosnmp/gosnmp"
+)
+
+type TrapConfig struct {
+	Addr string
+	// Params carries the version, community and SNMPv3 security settings
+	// used to decode traps. It defaults to gosnmp.Default.
+	Params *gosnmp.GoSNMP
+}
+
+type Varbind struct {
+	OID   string
+	Type  gosnmp.Asn1BER
+	Value any
+}
+
+// Trap is a received trap or inform. Octet strings are decoded to
+// string values; everything else is kept as gosnmp decodes it.
+type Trap struct {
+	Addr      *net.UDPAddr
+	Version   gosnmp.SnmpVersion
+	Community string
+	PDUType   gosnmp.PDUType
+	Variables []Varbind
+}
+
+type TrapListener struct {
+	cfg TrapConfig
+}
+
+func NewTrap(cfg TrapConfig) chord.Trigger[Trap] {
+	if cfg.Params == nil {
+		cfg.Params = gosnmp.Default
+	}
+	return TrapListener{cfg}
+}
+
+func (s TrapListener) Stage(ctx context.Context) chord.Stage[Trap] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Trap]) {
+		tl := gosnmp.NewTrapListener()
+		tl.Params = s.cfg.Params
+		tl.OnNewTrap = func(p *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+			trap := Trap{
+				Addr:      addr,
+				Version:   p.Version,
+				Community: p.Community,
+				PDUType:   p.PDUType,
+				Variables: make([]Varbind, 0, len(p.Variables)),
+			}
+			for _, v := range p.Variables {
+				value := v.Value
+				if b, ok := value.([]byte); ok && v.Type == gosnmp.OctetString {
+					value = string(b)
+				}
+				trap.Variables = append(trap.Variables, Varbind{OID: v.Name, Type: v.Type, Value: value})
+			}
+
+			emit(ctx, trap, nil)
+		}
+
+		stop := context.AfterFunc(ctx, tl.Close)
+		defer stop()
+
+		if err := tl.Listen(s.cfg.Addr); err != nil && ctx.Err() == nil {
+			emit(ctx, Trap{}, err)
+		}
+	})
+}
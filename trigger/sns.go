@@ -0,0 +1,257 @@
+package trigger
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+const snsMaxBody = 1 << 20
+
+var snsCertHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+type SnsAttribute struct {
+	Type  string
+	Value string
+}
+
+type SnsMessage struct {
+	MessageId         string
+	TopicArn          string
+	Subject           string
+	Message           string
+	Timestamp         time.Time
+	MessageAttributes map[string]SnsAttribute
+}
+
+type snsEnvelope struct {
+	Type              string
+	MessageId         string
+	Token             string
+	TopicArn          string
+	Subject           string
+	Message           string
+	Timestamp         string
+	SignatureVersion  string
+	Signature         string
+	SigningCertURL    string
+	SubscribeURL      string
+	MessageAttributes map[string]SnsAttribute
+}
+
+// stringToSign builds the canonical string SNS signs for the envelope type.
+func (e snsEnvelope) stringToSign() string {
+	var fields [][2]string
+
+	switch e.Type {
+	case "Notification":
+		fields = [][2]string{{"Message", e.Message}, {"MessageId", e.MessageId}}
+		if e.Subject != "" {
+			fields = append(fields, [2]string{"Subject", e.Subject})
+		}
+		fields = append(fields,
+			[2]string{"Timestamp", e.Timestamp},
+			[2]string{"TopicArn", e.TopicArn},
+			[2]string{"Type", e.Type},
+		)
+	default:
+		fields = [][2]string{
+			{"Message", e.Message},
+			{"MessageId", e.MessageId},
+			{"SubscribeURL", e.SubscribeURL},
+			{"Timestamp", e.Timestamp},
+			{"Token", e.Token},
+			{"TopicArn", e.TopicArn},
+			{"Type", e.Type},
+		}
+	}
+
+	var b strings.Builder
+	for _, f := range fields {
+		b.WriteString(f[0] + "\n" + f[1] + "\n")
+	}
+	return b.String()
+}
+
+type snsVerifier struct {
+	client *http.Client
+	mu     sync.Mutex
+	certs  map[string]*x509.Certificate
+}
+
+func (v *snsVerifier) cert(ctx context.Context, rawURL string) (*x509.Certificate, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" || !snsCertHost.MatchString(u.Hostname()) {
+		return nil, fmt.Errorf("sns: untrusted signing certificate url %q", rawURL)
+	}
+
+	v.mu.Lock()
+	c, ok := v.certs[rawURL]
+	v.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sns: fetching signing certificate: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, snsMaxBody))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(body)
+	if block == nil {
+		return nil, errors.New("sns: signing certificate is not PEM encoded")
+	}
+	c, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	v.certs[rawURL] = c
+	v.mu.Unlock()
+
+	return c, nil
+}
+
+func (v *snsVerifier) verify(ctx context.Context, e snsEnvelope) error {
+	sig, err := base64.StdEncoding.DecodeString(e.Signature)
+	if err != nil {
+		return err
+	}
+
+	c, err := v.cert(ctx, e.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	key, ok := c.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("sns: signing certificate does not hold an RSA key")
+	}
+
+	switch e.SignatureVersion {
+	case "1":
+		sum := sha1.Sum([]byte(e.stringToSign()))
+		return rsa.VerifyPKCS1v15(key, crypto.SHA1, sum[:], sig)
+	case "2":
+		sum := sha256.Sum256([]byte(e.stringToSign()))
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig)
+	default:
+		return fmt.Errorf("sns: unsupported signature version %q", e.SignatureVersion)
+	}
+}
+
+type snsHandler struct {
+	webhook[SnsMessage]
+	verifier *snsVerifier
+}
+
+func (h snsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var e snsEnvelope
+	if err := json.NewDecoder(io.LimitReader(r.Body, snsMaxBody)).Decode(&e); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.verifier.verify(r.Context(), e); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	switch e.Type {
+	case "SubscriptionConfirmation":
+		if err := h.confirm(r.Context(), e.SubscribeURL); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+	case "Notification":
+		ts, _ := time.Parse(time.RFC3339, e.Timestamp)
+		msg := SnsMessage{
+			MessageId:         e.MessageId,
+			TopicArn:          e.TopicArn,
+			Subject:           e.Subject,
+			Message:           e.Message,
+			Timestamp:         ts,
+			MessageAttributes: e.MessageAttributes,
+		}
+
+		if !h.deliver(r, msg) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h snsHandler) confirm(ctx context.Context, subscribeURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, subscribeURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := h.verifier.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("sns: confirming subscription: %s", resp.Status)
+	}
+	return nil
+}
+
+type Sns struct {
+	webhook[SnsMessage]
+}
+
+func NewSns(s *http.Server, pattern string) chord.Trigger[SnsMessage] {
+	wh := newWebhook[SnsMessage](s)
+
+	mount(s, pattern, snsHandler{
+		webhook: wh,
+		verifier: &snsVerifier{
+			client: http.DefaultClient,
+			certs:  make(map[string]*x509.Certificate),
+		},
+	})
+
+	return Sns{wh}
+}
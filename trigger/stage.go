@@ -0,0 +1,16 @@
+package trigger
+
+import (
+	"context"
+
+	"github.com/0x180db/go-chord"
+)
+
+// emitFunc sends a value or an error downstream with the given result
+// context. It reports false once the trigger context is done.
+type emitFunc[T any] = chord.Emit[T]
+
+// stage runs fn in its own goroutine; see chord.NewProducer.
+func stage[T any](ctx context.Context, fn func(context.Context, emitFunc[T])) chord.Stage[T] {
+	return chord.NewProducer(ctx, fn)
+}
@@ -0,0 +1,295 @@
+package trigger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type SyslogConfig struct {
+	// Network is "udp" or "tcp".
+	Network string
+	Addr    string
+	// MaxSize caps a single record. It defaults to 64KiB.
+	MaxSize int
+}
+
+// SyslogRecord is a parsed RFC 3164 or RFC 5424 message. Version is 0 for
+// RFC 3164 messages, which carry no structured data or message ID.
+type SyslogRecord struct {
+	Facility       int
+	Severity       int
+	Version        int
+	Timestamp      time.Time
+	Hostname       string
+	AppName        string
+	ProcID         string
+	MsgID          string
+	StructuredData map[string]map[string]string
+	Message        string
+	Addr           net.Addr
+}
+
+var errSyslogFormat = errors.New("syslog: malformed message")
+
+// ParseSyslog parses a single RFC 5424 or RFC 3164 message.
+func ParseSyslog(b []byte) (SyslogRecord, error) {
+	s := string(bytes.TrimRight(b, "\r\n\x00"))
+
+	if !strings.HasPrefix(s, "<") {
+		return SyslogRecord{}, errSyslogFormat
+	}
+	end := strings.IndexByte(s, '>')
+	if end < 2 || end > 4 {
+		return SyslogRecord{}, errSyslogFormat
+	}
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri > 191 {
+		return SyslogRecord{}, errSyslogFormat
+	}
+
+	r := SyslogRecord{Facility: pri / 8, Severity: pri % 8}
+	s = s[end+1:]
+
+	if len(s) > 1 && s[0] >= '1' && s[0] <= '9' && s[1] == ' ' {
+		return r, parseSyslog5424(&r, s)
+	}
+	parseSyslog3164(&r, s)
+	return r, nil
+}
+
+func syslogNil(s string) string {
+	if s == "-" {
+		return ""
+	}
+	return s
+}
+
+func parseSyslog5424(r *SyslogRecord, s string) error {
+	f := strings.SplitN(s, " ", 7)
+	if len(f) < 7 {
+		return errSyslogFormat
+	}
+
+	r.Version, _ = strconv.Atoi(f[0])
+	if f[1] != "-" {
+		ts, err := time.Parse(time.RFC3339Nano, f[1])
+		if err != nil {
+			return fmt.Errorf("syslog: timestamp: %w", err)
+		}
+		r.Timestamp = ts
+	}
+	r.Hostname = syslogNil(f[2])
+	r.AppName = syslogNil(f[3])
+	r.ProcID = syslogNil(f[4])
+	r.MsgID = syslogNil(f[5])
+
+	rest := f[6]
+	if strings.HasPrefix(rest, "-") {
+		rest = rest[1:]
+	} else {
+		sd, n, err := parseStructuredData(rest)
+		if err != nil {
+			return err
+		}
+		r.StructuredData = sd
+		rest = rest[n:]
+	}
+
+	rest = strings.TrimPrefix(rest, " ")
+	r.Message = strings.TrimPrefix(rest, "\ufeff")
+	return nil
+}
+
+// parseStructuredData parses consecutive SD-ELEMENTs and returns how many
+// bytes they span.
+func parseStructuredData(s string) (map[string]map[string]string, int, error) {
+	sd := make(map[string]map[string]string)
+	i := 0
+
+	for i < len(s) && s[i] == '[' {
+		i++
+		j := strings.IndexAny(s[i:], " ]")
+		if j < 0 {
+			return nil, 0, errSyslogFormat
+		}
+		id := s[i : i+j]
+		params := make(map[string]string)
+		sd[id] = params
+		i += j
+
+		for i < len(s) && s[i] == ' ' {
+			i++
+			eq := strings.IndexByte(s[i:], '=')
+			if eq < 0 || i+eq+1 >= len(s) || s[i+eq+1] != '"' {
+				return nil, 0, errSyslogFormat
+			}
+			name := s[i : i+eq]
+			i += eq + 2
+
+			var v strings.Builder
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+					i++
+				}
+				v.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, 0, errSyslogFormat
+			}
+			params[name] = v.String()
+			i++
+		}
+
+		if i >= len(s) || s[i] != ']' {
+			return nil, 0, errSyslogFormat
+		}
+		i++
+	}
+	return sd, i, nil
+}
+
+// parseSyslog3164 is lenient, as RFC 3164 only describes common practice:
+// whatever does not look like a timestamp, host and tag is kept as message.
+func parseSyslog3164(r *SyslogRecord, s string) {
+	if len(s) >= 16 && s[15] == ' ' {
+		if ts, err := time.ParseInLocation(time.Stamp, s[:15], time.Local); err == nil {
+			now := time.Now()
+			ts = ts.AddDate(now.Year(), 0, 0)
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			r.Timestamp = ts
+			s = s[16:]
+
+			if sp := strings.IndexByte(s, ' '); sp > 0 {
+				r.Hostname = s[:sp]
+				s = s[sp+1:]
+			}
+		}
+	}
+
+	if colon := strings.Index(s, ": "); colon > 0 && !strings.ContainsAny(s[:colon], " ") {
+		tag := s[:colon]
+		if open := strings.IndexByte(tag, '['); open > 0 && strings.HasSuffix(tag, "]") {
+			r.ProcID = tag[open+1 : len(tag)-1]
+			tag = tag[:open]
+		}
+		r.AppName = tag
+		s = s[colon+2:]
+	}
+	r.Message = s
+}
+
+// splitSyslog frames TCP syslog streams using octet counting when a record
+// starts with its length (RFC 6587) and newlines otherwise.
+func splitSyslog(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) == 0 {
+		return 0, nil, nil
+	}
+	if data[0] < '1' || data[0] > '9' {
+		return bufio.ScanLines(data, atEOF)
+	}
+
+	sp := bytes.IndexByte(data, ' ')
+	if sp < 0 {
+		if atEOF {
+			return 0, nil, errSyslogFormat
+		}
+		return 0, nil, nil
+	}
+	n, err := strconv.Atoi(string(data[:sp]))
+	if err != nil {
+		return 0, nil, errSyslogFormat
+	}
+	if len(data) < sp+1+n {
+		if atEOF {
+			return 0, nil, errSyslogFormat
+		}
+		return 0, nil, nil
+	}
+	return sp + 1 + n, data[sp+1 : sp+1+n], nil
+}
+
+type Syslog struct {
+	cfg SyslogConfig
+}
+
+func NewSyslog(cfg SyslogConfig) chord.Trigger[SyslogRecord] {
+	if cfg.Network == "" {
+		cfg.Network = "udp"
+	}
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = 64 << 10
+	}
+	return Syslog{cfg}
+}
+
+func (sl Syslog) emitRecord(ctx context.Context, emit emitFunc[SyslogRecord], b []byte, addr net.Addr) bool {
+	r, err := ParseSyslog(b)
+	r.Addr = addr
+	return emit(ctx, r, err)
+}
+
+func (sl Syslog) Stage(ctx context.Context) chord.Stage[SyslogRecord] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[SyslogRecord]) {
+		if sl.cfg.Network == "tcp" {
+			ln, err := net.Listen("tcp", sl.cfg.Addr)
+			if err != nil {
+				emit(ctx, SyslogRecord{}, err)
+				return
+			}
+
+			err = serveConns(ctx, ln, func(c net.Conn) {
+				defer c.Close()
+
+				err := readFrames(ctx, c, splitSyslog, sl.cfg.MaxSize, 0, func(b []byte) bool {
+					return sl.emitRecord(ctx, emit, b, c.RemoteAddr())
+				})
+				if err != nil {
+					emit(ctx, SyslogRecord{Addr: c.RemoteAddr()}, err)
+				}
+			})
+			if err != nil {
+				emit(ctx, SyslogRecord{}, err)
+			}
+			return
+		}
+
+		conn, err := net.ListenPacket(sl.cfg.Network, sl.cfg.Addr)
+		if err != nil {
+			emit(ctx, SyslogRecord{}, err)
+			return
+		}
+		defer conn.Close()
+
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+		defer stop()
+
+		buf := make([]byte, sl.cfg.MaxSize)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				if !emit(ctx, SyslogRecord{}, err) {
+					return
+				}
+				continue
+			}
+
+			if !sl.emitRecord(ctx, emit, buf[:n], addr) {
+				return
+			}
+		}
+	})
+}
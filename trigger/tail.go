@@ -0,0 +1,224 @@
+package trigger
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+)
+
+type TailConfig struct {
+	Path string
+	// FromStart reads the existing content first instead of only lines
+	// appended after the stage starts.
+	FromStart bool
+	// Poll is how often the file is checked for new data, rotation and
+	// truncation once the end is reached.
+	Poll time.Duration
+	// Checkpointer saves the offset of acked lines under CheckpointKey,
+	// which defaults to "tail:" and the path. After a restart the stage
+	// resumes from there, or from the start if the file has shrunk since.
+	Checkpointer  chord.Checkpointer
+	CheckpointKey string
+}
+
+// TailLine is one line without its trailing newline. Offset is the
+// position just past the line, for resuming with a later Seek.
+type TailLine struct {
+	Path   string
+	Text   string
+	Offset int64
+}
+
+// Bytes returns the line, for Decode.
+func (l TailLine) Bytes() []byte { return []byte(l.Text) }
+
+type Tail struct {
+	cfg TailConfig
+}
+
+func NewTail(cfg TailConfig) chord.Trigger[TailLine] {
+	if cfg.Poll == 0 {
+		cfg.Poll = 250 * time.Millisecond
+	}
+	if cfg.CheckpointKey == "" {
+		cfg.CheckpointKey = "tail:" + cfg.Path
+	}
+	return Tail{cfg}
+}
+
+type tailFile struct {
+	*os.File
+	r       *bufio.Reader
+	offset  int64
+	partial strings.Builder
+}
+
+// open opens the file at resume, or at its start or end if resume is
+// negative.
+func (t Tail) open(fromStart bool, resume int64) (*tailFile, error) {
+	f, err := os.Open(t.cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var offset int64
+	switch {
+	case resume >= 0:
+		fi, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if resume <= fi.Size() {
+			offset = resume
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+	case !fromStart:
+		if offset, err = f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return &tailFile{File: f, r: bufio.NewReader(f), offset: offset}, nil
+}
+
+// drain emits every complete line available in f.
+func (t Tail) drain(ctx context.Context, f *tailFile, cps *source.Checkpoints, emit emitFunc[TailLine]) (bool, error) {
+	for {
+		s, err := f.r.ReadString('\n')
+		f.offset += int64(len(s))
+		f.partial.WriteString(s)
+
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return true, err
+		}
+
+		line := strings.TrimRight(f.partial.String(), "\r\n")
+		f.partial.Reset()
+
+		lctx := ctx
+		if cps != nil {
+			lctx = chord.WithAcker(ctx, cps.Track([]byte(strconv.FormatInt(f.offset, 10))))
+		}
+		if !emit(lctx, TailLine{Path: t.cfg.Path, Text: line, Offset: f.offset}, nil) {
+			return false, nil
+		}
+	}
+}
+
+// rotated reports whether the path now names a different file than f, or
+// f has been truncated below what was already read.
+func (t Tail) rotated(f *tailFile) (replaced, truncated bool) {
+	cur, err := f.Stat()
+	if err != nil {
+		return true, false
+	}
+	fi, err := os.Stat(t.cfg.Path)
+	if err != nil {
+		return false, false
+	}
+	if !os.SameFile(cur, fi) {
+		return true, false
+	}
+	return false, fi.Size() < f.offset
+}
+
+func (t Tail) Stage(ctx context.Context) chord.Stage[TailLine] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[TailLine]) {
+		tick := chord.ClockFrom(ctx).NewTicker(t.cfg.Poll)
+		defer tick.Stop()
+
+		var f *tailFile
+		defer func() {
+			if f != nil {
+				f.Close()
+			}
+		}()
+
+		fromStart := t.cfg.FromStart
+		resume := int64(-1)
+
+		report, stop := source.SettleErrors(ctx, emit)
+		defer stop()
+
+		var cps *source.Checkpoints
+		if t.cfg.Checkpointer != nil {
+			cps = source.NewCheckpoints(ctx, t.cfg.Checkpointer, t.cfg.CheckpointKey, report)
+
+			b, err := cps.Load()
+			if err == nil && b != nil {
+				var n int64
+				if n, err = strconv.ParseInt(string(b), 10, 64); err == nil {
+					resume = n
+				}
+			}
+			if err != nil && !emit(ctx, TailLine{}, err) {
+				return
+			}
+		}
+
+		for {
+			if f == nil {
+				var err error
+				f, err = t.open(fromStart, resume)
+				if err == nil {
+					resume = -1
+				}
+				switch {
+				case errors.Is(err, os.ErrNotExist):
+					fromStart = true
+				case err != nil:
+					if !emit(ctx, TailLine{}, err) {
+						return
+					}
+				}
+			}
+
+			if f != nil {
+				ok, err := t.drain(ctx, f, cps, emit)
+				if !ok || err != nil && !emit(ctx, TailLine{}, err) {
+					return
+				}
+
+				replaced, truncated := t.rotated(f)
+				switch {
+				case replaced:
+					if ok, _ := t.drain(ctx, f, cps, emit); !ok {
+						return
+					}
+					f.Close()
+					f = nil
+					fromStart = true
+					continue
+				case truncated:
+					if _, err := f.Seek(0, io.SeekStart); err != nil && !emit(ctx, TailLine{}, err) {
+						return
+					}
+					f.r.Reset(f.File)
+					f.offset = 0
+					f.partial.Reset()
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-tick.C():
+			}
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package trigger
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type TcpConfig struct {
+	Addr string
+	// ReadTimeout closes a connection that sends nothing for this long.
+	// Zero waits forever.
+	ReadTimeout time.Duration
+	// Split frames messages on a connection. It defaults to lines; see
+	// SplitLengthPrefix for binary protocols.
+	Split bufio.SplitFunc
+	// MaxSize caps a single message. It defaults to 1MiB.
+	MaxSize int
+}
+
+type TcpMessage struct {
+	Data []byte
+	// Conn is the connection the message arrived on, for replies.
+	Conn net.Conn
+}
+
+// Bytes returns the message, for Decode.
+func (m TcpMessage) Bytes() []byte { return m.Data }
+
+// SplitLengthPrefix frames messages preceded by their length as a 4-byte
+// big-endian integer.
+func SplitLengthPrefix(data []byte, atEOF bool) (int, []byte, error) {
+	if len(data) < 4 {
+		if atEOF && len(data) > 0 {
+			return 0, nil, errors.New("tcp: truncated length prefix")
+		}
+		return 0, nil, nil
+	}
+
+	n := int(binary.BigEndian.Uint32(data))
+	if len(data) < 4+n {
+		if atEOF {
+			return 0, nil, errors.New("tcp: truncated message")
+		}
+		return 0, nil, nil
+	}
+	return 4 + n, data[4 : 4+n], nil
+}
+
+// serveConns accepts connections until ctx is done or accepting fails,
+// then closes the listener and the open connections and waits for handlers
+// to return. It returns the error that stopped it before ctx was done.
+func serveConns(ctx context.Context, ln net.Listener, handle func(net.Conn)) error {
+	var (
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+		wg    sync.WaitGroup
+	)
+
+	stop := context.AfterFunc(ctx, func() { ln.Close() })
+	defer stop()
+
+	var err error
+	for {
+		var c net.Conn
+		if c, err = ln.Accept(); err != nil {
+			break
+		}
+
+		mu.Lock()
+		conns[c] = struct{}{}
+		mu.Unlock()
+
+		wg.Go(func() {
+			defer func() {
+				mu.Lock()
+				delete(conns, c)
+				mu.Unlock()
+			}()
+			handle(c)
+		})
+	}
+	ln.Close()
+
+	mu.Lock()
+	for c := range conns {
+		c.Close()
+	}
+	mu.Unlock()
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+type TcpConn struct {
+	addr string
+}
+
+// NewTcpConn emits every accepted connection. The flow owns it and must
+// close it.
+func NewTcpConn(addr string) chord.Trigger[net.Conn] {
+	return TcpConn{addr}
+}
+
+func (t TcpConn) Stage(ctx context.Context) chord.Stage[net.Conn] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[net.Conn]) {
+		ln, err := net.Listen("tcp", t.addr)
+		if err != nil {
+			emit(ctx, nil, err)
+			return
+		}
+
+		err = serveConns(ctx, ln, func(c net.Conn) {
+			if !emit(ctx, c, nil) {
+				c.Close()
+			}
+		})
+		if err != nil {
+			emit(ctx, nil, err)
+		}
+	})
+}
+
+type Tcp struct {
+	cfg TcpConfig
+}
+
+// NewTcp emits the framed messages received on every accepted connection.
+func NewTcp(cfg TcpConfig) chord.Trigger[TcpMessage] {
+	if cfg.Split == nil {
+		cfg.Split = bufio.ScanLines
+	}
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = 1 << 20
+	}
+	return Tcp{cfg}
+}
+
+// readFrames splits c with split and calls fn for each frame until the
+// connection ends or fn returns false. Read timeouts end the connection
+// quietly; other read errors are returned.
+func readFrames(ctx context.Context, c net.Conn, split bufio.SplitFunc, maxSize int, timeout time.Duration, fn func([]byte) bool) error {
+	sc := bufio.NewScanner(c)
+	sc.Buffer(make([]byte, 0, min(64<<10, maxSize)), maxSize)
+	sc.Split(split)
+
+	for {
+		if timeout > 0 && ctx.Err() == nil {
+			c.SetReadDeadline(time.Now().Add(timeout))
+		}
+		if !sc.Scan() {
+			break
+		}
+		if !fn(bytes.Clone(sc.Bytes())) {
+			return nil
+		}
+	}
+
+	var ne net.Error
+	if err := sc.Err(); err != nil && ctx.Err() == nil && !(errors.As(err, &ne) && ne.Timeout()) {
+		return err
+	}
+	return nil
+}
+
+func (t Tcp) read(ctx context.Context, c net.Conn, emit emitFunc[TcpMessage]) {
+	defer c.Close()
+
+	err := readFrames(ctx, c, t.cfg.Split, t.cfg.MaxSize, t.cfg.ReadTimeout, func(b []byte) bool {
+		return emit(ctx, TcpMessage{Data: b, Conn: c}, nil)
+	})
+	if err != nil {
+		emit(ctx, TcpMessage{Conn: c}, err)
+	}
+}
+
+func (t Tcp) Stage(ctx context.Context) chord.Stage[TcpMessage] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[TcpMessage]) {
+		ln, err := net.Listen("tcp", t.cfg.Addr)
+		if err != nil {
+			emit(ctx, TcpMessage{}, err)
+			return
+		}
+
+		err = serveConns(ctx, ln, func(c net.Conn) {
+			t.read(ctx, c, emit)
+		})
+		if err != nil {
+			emit(ctx, TcpMessage{}, err)
+		}
+	})
+}
@@ -5,35 +5,32 @@ import (
 	"time"
 
 	"github.com/0x180db/go-chord"
-	"github.com/0x180db/go-conduit"
 )
 
 type Ticker struct {
-	*time.Ticker
+	d time.Duration
 }
 
+// NewTicker emits the current time every d. Ticks are dropped while the
+// flow is busy, as with time.Ticker.
 func NewTicker(d time.Duration) chord.Trigger[time.Time] {
-	return Ticker{time.NewTicker(time.Second)}
+	return Ticker{d}
 }
 
 func (t Ticker) Stage(ctx context.Context) chord.Stage[time.Time] {
-	return func() <-chan conduit.Result[time.Time] {
-		ch := make(chan conduit.Result[time.Time])
-
-		go func() {
-			defer t.Stop()
-			defer close(ch)
-
-			for {
-				select {
-				case <-ctx.Done():
+	return stage(ctx, func(ctx context.Context, emit emitFunc[time.Time]) {
+		tick := chord.ClockFrom(ctx).NewTicker(t.d)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-tick.C():
+				if !emit(ctx, now, nil) {
 					return
-				default:
-					ch <- conduit.Ok(ctx, <-t.C)
 				}
 			}
-		}()
-
-		return ch
-	}
+		}
+	})
 }
@@ -0,0 +1,75 @@
+package trigger
+
+import (
+	"context"
+	"net"
+
+	"github.com/0x180db/go-chord"
+)
+
+type UdpConfig struct {
+	Addr string
+	// MaxSize is the largest datagram accepted; longer ones are truncated.
+	// It defaults to 64KiB.
+	MaxSize int
+	// ReadBuffer sets the socket receive buffer, so bursts are not dropped
+	// by the kernel while the pipeline is busy. Zero keeps the OS default.
+	ReadBuffer int
+}
+
+type UdpDatagram struct {
+	Data []byte
+	Addr net.Addr
+}
+
+type Udp struct {
+	cfg UdpConfig
+}
+
+func NewUdp(cfg UdpConfig) chord.Trigger[UdpDatagram] {
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = 64 << 10
+	}
+	return Udp{cfg}
+}
+
+func (u Udp) Stage(ctx context.Context) chord.Stage[UdpDatagram] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[UdpDatagram]) {
+		conn, err := net.ListenPacket("udp", u.cfg.Addr)
+		if err != nil {
+			emit(ctx, UdpDatagram{}, err)
+			return
+		}
+		defer conn.Close()
+
+		if u.cfg.ReadBuffer > 0 {
+			if err := conn.(*net.UDPConn).SetReadBuffer(u.cfg.ReadBuffer); err != nil {
+				emit(ctx, UdpDatagram{}, err)
+				return
+			}
+		}
+
+		stop := context.AfterFunc(ctx, func() { conn.Close() })
+		defer stop()
+
+		buf := make([]byte, u.cfg.MaxSize)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				if !emit(ctx, UdpDatagram{}, err) {
+					return
+				}
+				continue
+			}
+
+			data := make([]byte, n)
+			copy(data, buf[:n])
+			if !emit(ctx, UdpDatagram{Data: data, Addr: addr}, nil) {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package trigger
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"time"
+
+	"github.com/0x180db/go-chord"
+)
+
+type UnixConfig struct {
+	Path string
+	// Datagram listens on a unixgram socket instead of a stream socket.
+	Datagram bool
+	// Mode is applied to the socket file after it is created, to control
+	// which local users may connect. Zero keeps the umask default.
+	Mode fs.FileMode
+	// Split frames messages on stream connections. It defaults to lines.
+	Split bufio.SplitFunc
+	// MaxSize caps a single message or datagram. It defaults to 64KiB.
+	MaxSize     int
+	ReadTimeout time.Duration
+}
+
+// UnixMessage carries the connection it arrived on in stream mode and the
+// sender address in datagram mode.
+type UnixMessage struct {
+	Data []byte
+	Conn net.Conn
+	Addr net.Addr
+}
+
+type Unix struct {
+	cfg UnixConfig
+}
+
+func NewUnix(cfg UnixConfig) chord.Trigger[UnixMessage] {
+	if cfg.Split == nil {
+		cfg.Split = bufio.ScanLines
+	}
+	if cfg.MaxSize == 0 {
+		cfg.MaxSize = 64 << 10
+	}
+	return Unix{cfg}
+}
+
+// removeStale deletes a socket file left behind by a previous process.
+// Anything that is not a socket is left alone so Listen reports it.
+func (u Unix) removeStale() error {
+	fi, err := os.Lstat(u.cfg.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&fs.ModeSocket != 0 {
+		return os.Remove(u.cfg.Path)
+	}
+	return nil
+}
+
+func (u Unix) chmod() error {
+	if u.cfg.Mode == 0 {
+		return nil
+	}
+	return os.Chmod(u.cfg.Path, u.cfg.Mode)
+}
+
+func (u Unix) stream(ctx context.Context, emit emitFunc[UnixMessage]) {
+	ln, err := net.Listen("unix", u.cfg.Path)
+	if err == nil {
+		if err = u.chmod(); err != nil {
+			ln.Close()
+		}
+	}
+	if err != nil {
+		emit(ctx, UnixMessage{}, err)
+		return
+	}
+
+	err = serveConns(ctx, ln, func(c net.Conn) {
+		defer c.Close()
+
+		err := readFrames(ctx, c, u.cfg.Split, u.cfg.MaxSize, u.cfg.ReadTimeout, func(b []byte) bool {
+			return emit(ctx, UnixMessage{Data: b, Conn: c}, nil)
+		})
+		if err != nil {
+			emit(ctx, UnixMessage{Conn: c}, err)
+		}
+	})
+	if err != nil {
+		emit(ctx, UnixMessage{}, err)
+	}
+}
+
+func (u Unix) datagram(ctx context.Context, emit emitFunc[UnixMessage]) {
+	conn, err := net.ListenPacket("unixgram", u.cfg.Path)
+	if err != nil {
+		emit(ctx, UnixMessage{}, err)
+		return
+	}
+	defer os.Remove(u.cfg.Path)
+	defer conn.Close()
+
+	if err := u.chmod(); err != nil {
+		emit(ctx, UnixMessage{}, err)
+		return
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	buf := make([]byte, u.cfg.MaxSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if !emit(ctx, UnixMessage{}, err) {
+				return
+			}
+			continue
+		}
+
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		if !emit(ctx, UnixMessage{Data: data, Addr: addr}, nil) {
+			return
+		}
+	}
+}
+
+func (u Unix) Stage(ctx context.Context) chord.Stage[UnixMessage] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[UnixMessage]) {
+		if err := u.removeStale(); err != nil {
+			emit(ctx, UnixMessage{}, err)
+			return
+		}
+
+		if u.cfg.Datagram {
+			u.datagram(ctx, emit)
+		} else {
+			u.stream(ctx, emit)
+		}
+	})
+}
@@ -0,0 +1,46 @@
+package trigger
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/0x180db/go-chord"
+)
+
+// webhook hands events decoded by an HTTP handler to the stage and shuts
+// the server down with it.
+type webhook[T any] struct {
+	*http.Server
+	ch chan T
+}
+
+func newWebhook[T any](s *http.Server) webhook[T] {
+	return webhook[T]{s, make(chan T)}
+}
+
+// deliver passes v to the stage, giving up if the request goes away first.
+func (w webhook[T]) deliver(r *http.Request, v T) bool {
+	select {
+	case w.ch <- v:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+func (w webhook[T]) Stage(ctx context.Context) chord.Stage[T] {
+	return stage(ctx, func(ctx context.Context, emit emitFunc[T]) {
+		defer w.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v := <-w.ch:
+				if !emit(ctx, v, nil) {
+					return
+				}
+			}
+		}
+	})
+}
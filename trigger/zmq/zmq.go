@@ -0,0 +1,96 @@
+// Package zmq is a trigger receiving from ZeroMQ sockets.
+package zmq
+
+import (
+	"context"
+
+	"github.com/0x180db/go-chord"
+	"github.com/0x180db/go-chord/trigger/internal/source"
+	"github.com/go-zeromq/zmq4"
+)
+
+type Socket int
+
+const (
+	Sub Socket = iota
+	Pull
+)
+
+type Config struct {
+	Socket    Socket
+	Endpoints []string
+	// Bind listens on the endpoints instead of connecting to them.
+	Bind bool
+	// Topics are the SUB prefix filters. An empty list subscribes to all.
+	Topics []string
+}
+
+type Message struct {
+	Frames [][]byte
+}
+
+type Trigger struct {
+	cfg Config
+}
+
+func New(cfg Config) chord.Trigger[Message] {
+	return Trigger{cfg}
+}
+
+func (z Trigger) socket(ctx context.Context) (zmq4.Socket, error) {
+	if z.cfg.Socket == Pull {
+		return zmq4.NewPull(ctx), nil
+	}
+
+	sock := zmq4.NewSub(ctx)
+	topics := z.cfg.Topics
+	if len(topics) == 0 {
+		topics = []string{""}
+	}
+	for _, t := range topics {
+		if err := sock.SetOption(zmq4.OptionSubscribe, t); err != nil {
+			sock.Close()
+			return nil, err
+		}
+	}
+	return sock, nil
+}
+
+func (z Trigger) Stage(ctx context.Context) chord.Stage[Message] {
+	return chord.NewProducer(ctx, func(ctx context.Context, emit chord.Emit[Message]) {
+		sock, err := z.socket(ctx)
+		if err != nil {
+			emit(ctx, Message{}, err)
+			return
+		}
+		defer sock.Close()
+
+		for _, e := range z.cfg.Endpoints {
+			if z.cfg.Bind {
+				err = sock.Listen(e)
+			} else {
+				err = sock.Dial(e)
+			}
+			if err != nil {
+				emit(ctx, Message{}, err)
+				return
+			}
+		}
+
+		for attempt := 0; ; {
+			msg, err := sock.Recv()
+			if err != nil {
+				if ctx.Err() != nil || !emit(ctx, Message{}, err) || !source.Backoff(ctx, attempt) {
+					return
+				}
+				attempt++
+				continue
+			}
+			attempt = 0
+
+			if !emit(ctx, Message{Frames: msg.Frames}, nil) {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package chord
+
+import "context"
+
+// Transaction is an output staged by a TwoPhaseSink: Commit makes it
+// visible and Abort discards it.
+type Transaction interface {
+	Commit(ctx context.Context) error
+	Abort(ctx context.Context) error
+}
+
+// TwoPhaseSink is implemented by sinks that write outputs in transactions,
+// such as a Kafka transactional producer or a SQL database. RunFlow then
+// prepares each output instead of calling OnSuccess, lets the event's
+// TxAcker acknowledge it within the transaction, commits, and only then
+// acks the event. If any step fails the transaction is aborted and the
+// error goes to OnError. With a source whose acknowledgment is part of the
+// transaction, processing is exactly once end to end.
+type TwoPhaseSink[T any] interface {
+	Prepare(ctx context.Context, v T) (Transaction, error)
+}
+
+// TxAcker is implemented by Ackers that can acknowledge their event within
+// a sink's transaction, such as a consumer offset sent to a Kafka
+// producer's transaction or a checkpoint written with the output's SQL
+// transaction. The Ack that follows the commit then only has to forget the
+// event. Ackers wrapping the Acker of their source pass AckIn on to it.
+type TxAcker interface {
+	Acker
+	AckIn(ctx context.Context, tx Transaction) error
+}
+
+// twoPhase returns the sink of f if it writes outputs in two phases.
+func twoPhase[In, Out any](f Flow[In, Out]) (TwoPhaseSink[Out], bool) {
+	s, ok := sinkOf(f).(TwoPhaseSink[Out])
+	return s, ok
+}
+
+// commitOutput writes v and acknowledges its event in one transaction.
+func commitOutput[T any](ctx context.Context, s TwoPhaseSink[T], v T) error {
+	tx, err := s.Prepare(ctx, v)
+	if err != nil {
+		return err
+	}
+
+	if err := ackIn(ctx, tx); err != nil {
+		tx.Abort(context.WithoutCancel(ctx))
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		tx.Abort(context.WithoutCancel(ctx))
+		return err
+	}
+	return nil
+}
+
+// ackIn acknowledges the event of ctx within tx, if its Acker can.
+func ackIn(ctx context.Context, tx Transaction) error {
+	if ta, ok := acker(ctx).(TxAcker); ok {
+		return ta.AckIn(ctx, tx)
+	}
+	return nil
+}
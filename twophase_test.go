@@ -0,0 +1,134 @@
+package chord
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// txLog records the steps of a two-phase write in order.
+type txLog struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+func (l *txLog) add(step string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.steps = append(l.steps, step)
+}
+
+type testTx struct {
+	log       *txLog
+	commitErr error
+}
+
+func (tx testTx) Commit(context.Context) error {
+	tx.log.add("commit")
+	return tx.commitErr
+}
+
+func (tx testTx) Abort(context.Context) error {
+	tx.log.add("abort")
+	return nil
+}
+
+type testTxSink struct {
+	log                   *txLog
+	prepareErr, commitErr error
+}
+
+func (s testTxSink) Prepare(context.Context, int) (Transaction, error) {
+	s.log.add("prepare")
+	if s.prepareErr != nil {
+		return nil, s.prepareErr
+	}
+	return testTx{s.log, s.commitErr}, nil
+}
+
+func (s testTxSink) OnSuccess(context.Context, int) error {
+	s.log.add("onsuccess")
+	return nil
+}
+
+func (s testTxSink) OnError(context.Context, error) {
+	s.log.add("onerror")
+}
+
+type testTxAcker struct {
+	log      *txLog
+	ackInErr error
+}
+
+func (a testTxAcker) Ack()  { a.log.add("ack") }
+func (a testTxAcker) Nack() { a.log.add("nack") }
+
+func (a testTxAcker) AckIn(context.Context, Transaction) error {
+	a.log.add("ackin")
+	return a.ackInErr
+}
+
+func TestRunFlowTwoPhase(t *testing.T) {
+	errStep := errors.New("step failed")
+	tests := []struct {
+		name  string
+		sink  testTxSink
+		acker testTxAcker
+		want  []string
+	}{
+		{
+			name: "Commit",
+			want: []string{"prepare", "ackin", "commit", "ack"},
+		},
+		{
+			name: "PrepareFails",
+			sink: testTxSink{prepareErr: errStep},
+			want: []string{"prepare", "onerror", "nack"},
+		},
+		{
+			name:  "AckInFails",
+			acker: testTxAcker{ackInErr: errStep},
+			want:  []string{"prepare", "ackin", "abort", "onerror", "nack"},
+		},
+		{
+			name: "CommitFails",
+			sink: testTxSink{commitErr: errStep},
+			want: []string{"prepare", "ackin", "commit", "abort", "onerror", "nack"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := new(txLog)
+			tt.sink.log, tt.acker.log = log, log
+
+			src := NewProducer(context.Background(), func(ctx context.Context, emit Emit[int]) {
+				emit(WithAcker(ctx, tt.acker), 1, nil)
+			})
+			if err := RunFlow(src, NewFlow(identity[int], Sink[int](tt.sink))); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(log.steps, tt.want) {
+				t.Errorf("steps = %v, want %v", log.steps, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunFlowTwoPhaseWithPlainAcker(t *testing.T) {
+	log := new(txLog)
+	a := new(testAcker)
+	src := NewProducer(context.Background(), func(ctx context.Context, emit Emit[int]) {
+		emit(WithAcker(ctx, a), 1, nil)
+	})
+
+	if err := RunFlow(src, NewFlow(identity[int], Sink[int](testTxSink{log: log}))); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"prepare", "commit"}; !slices.Equal(log.steps, want) {
+		t.Errorf("steps = %v, want %v", log.steps, want)
+	}
+	checkSettled(t, []*testAcker{a}, [][2]int{{1, 0}})
+}
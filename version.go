@@ -0,0 +1,364 @@
+package chord
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+)
+
+// mirrorBuffer is how many mirrored copies may wait for a version before
+// further ones are dropped.
+const mirrorBuffer = 100
+
+type versionKey struct{}
+
+// VersionFrom returns the version of a Versioned flow that an event was
+// routed to, and whether it is a mirrored copy whose outcome does not
+// settle the event with its source.
+func VersionFrom(ctx context.Context) (name string, mirrored bool) {
+	v, _ := ctx.Value(versionKey{}).(routed)
+	return v.name, v.mirrored
+}
+
+type routed struct {
+	name     string
+	mirrored bool
+	// version is the *version an event was routed to, which still
+	// handles its outputs if it is dropped meanwhile.
+	version any
+}
+
+// routing is swapped as a whole, so every event sees one consistent
+// assignment of versions.
+type routing struct {
+	active, previous string
+	candidate        string
+	mirror           bool
+	split            float64
+}
+
+// Versioned is a Flow running several versions of a flow side by side for
+// blue/green upgrades of long-running services. Events go to the active
+// version, and a candidate registered with Register can receive a copy of
+// each (Mirror) or a share of them (Split) until it is promoted or
+// dropped. Each version keeps its own pipeline and sink, so a mirrored
+// candidate should write somewhere harmless.
+type Versioned[In, Out any] struct {
+	// Clone, if set, copies each event before it is mirrored. Without it
+	// both versions get the same value, so neither pipeline may modify
+	// what it points to.
+	Clone func(In) In
+
+	mu       sync.RWMutex
+	versions map[string]*version[In, Out]
+	routing  atomic.Pointer[routing]
+	// dropped counts the versions dropped, so running pipelines notice.
+	dropped  atomic.Uint64
+	overflow atomic.Int64
+}
+
+type version[In, Out any] struct {
+	name string
+	f    Flow[In, Out]
+}
+
+// NewVersioned returns a Versioned flow whose active version is f.
+func NewVersioned[In, Out any](name string, f Flow[In, Out]) *Versioned[In, Out] {
+	v := &Versioned[In, Out]{versions: map[string]*version[In, Out]{name: {name, f}}}
+	v.routing.Store(&routing{active: name})
+	return v
+}
+
+// Register adds a version without routing events to it.
+func (v *Versioned[In, Out]) Register(name string, f Flow[In, Out]) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.versions[name]; ok {
+		return fmt.Errorf("chord: version %q already registered", name)
+	}
+	v.versions[name] = &version[In, Out]{name, f}
+	return nil
+}
+
+// Drop removes a version that is not active, such as a candidate that
+// failed, stopping its mirroring or split and its pipeline once the
+// events routed to it are done. Its name can then be registered again.
+func (v *Versioned[In, Out]) Drop(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if _, ok := v.versions[name]; !ok {
+		return fmt.Errorf("chord: unknown version %q", name)
+	}
+	for {
+		old := v.routing.Load()
+		if old.active == name {
+			return fmt.Errorf("chord: version %q is active", name)
+		}
+		r := *old
+		if r.candidate == name {
+			r.candidate, r.mirror, r.split = "", false, 0
+		}
+		if r.previous == name {
+			r.previous = ""
+		}
+		if v.routing.CompareAndSwap(old, &r) {
+			break
+		}
+	}
+	delete(v.versions, name)
+	v.dropped.Add(1)
+	return nil
+}
+
+func (v *Versioned[In, Out]) version(name string) *version[In, Out] {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.versions[name]
+}
+
+// Dropped returns how many mirrored copies were dropped because the
+// mirrored version had too many waiting.
+func (v *Versioned[In, Out]) Dropped() int {
+	return int(v.overflow.Load())
+}
+
+// update applies fn to a copy of the routing, after checking that name is
+// a registered version.
+func (v *Versioned[In, Out]) update(name string, fn func(r *routing)) error {
+	if v.version(name) == nil {
+		return fmt.Errorf("chord: unknown version %q", name)
+	}
+	for {
+		old := v.routing.Load()
+		r := *old
+		fn(&r)
+		if v.routing.CompareAndSwap(old, &r) {
+			return nil
+		}
+	}
+}
+
+// Mirror sends a copy of every event to the version name alongside the
+// active one. The copies are settled on their own, so the candidate's
+// failures, even fatal ones, only reach its OnError. A candidate falling
+// behind does not hold up the active version: copies are dropped, and
+// counted by Dropped, while it has 100 waiting.
+func (v *Versioned[In, Out]) Mirror(name string) error {
+	return v.update(name, func(r *routing) {
+		r.candidate, r.mirror, r.split = name, true, 0
+	})
+}
+
+// Split routes the given fraction of events, chosen at random, to the
+// version name instead of the active one. The fraction must be between 0
+// and 1.
+func (v *Versioned[In, Out]) Split(name string, fraction float64) error {
+	if !(fraction >= 0 && fraction <= 1) {
+		return fmt.Errorf("chord: split fraction %v not between 0 and 1", fraction)
+	}
+	return v.update(name, func(r *routing) {
+		r.candidate, r.mirror, r.split = name, false, fraction
+	})
+}
+
+// Promote makes name the active version and stops mirroring or splitting.
+// The version it replaces is kept for Rollback.
+func (v *Versioned[In, Out]) Promote(name string) error {
+	return v.update(name, func(r *routing) {
+		prev := r.previous
+		if r.active != name {
+			prev = r.active
+		}
+		*r = routing{active: name, previous: prev}
+	})
+}
+
+// Rollback makes the version active before the last Promote active again,
+// and stops mirroring or splitting.
+func (v *Versioned[In, Out]) Rollback() error {
+	r := v.routing.Load()
+	if r.previous == "" {
+		return fmt.Errorf("chord: no version to roll back to")
+	}
+	return v.Promote(r.previous)
+}
+
+// Active returns the name of the active version.
+func (v *Versioned[In, Out]) Active() string {
+	return v.routing.Load().active
+}
+
+// targets returns the versions an event goes to, the first of which
+// settles it.
+func (r *routing) targets() []routed {
+	switch {
+	case r.candidate == "" || r.candidate == r.active:
+		return []routed{{name: r.active}}
+	case r.mirror:
+		return []routed{{name: r.active}, {name: r.candidate, mirrored: true}}
+	case rand.Float64() < r.split:
+		return []routed{{name: r.candidate}}
+	default:
+		return []routed{{name: r.active}}
+	}
+}
+
+type mirrorAck struct{}
+
+func (mirrorAck) Ack()  {}
+func (mirrorAck) Nack() {}
+
+// Pipeline routes each event into the pipeline of its version, building
+// the pipeline of a version the first time an event is routed to it.
+func (v *Versioned[In, Out]) Pipeline(s Stage[In]) Stage[Out] {
+	return newProducer(context.Background(), func(_ context.Context, emit Emit[Out]) {
+		type input struct {
+			ver *version[In, Out]
+			ch  chan Result[In]
+		}
+		var (
+			wg      sync.WaitGroup
+			inputs  = make(map[string]input)
+			dropped uint64
+		)
+
+		inputOf := func(ver *version[In, Out]) chan Result[In] {
+			if in, ok := inputs[ver.name]; ok && in.ver == ver {
+				return in.ch
+			}
+			in := make(chan Result[In], mirrorBuffer)
+			inputs[ver.name] = input{ver, in}
+
+			p := ver.f.Pipeline(func() <-chan Result[In] { return in })
+			wg.Go(func() {
+				// Mirrored outputs are handled here rather than
+				// downstream, so a slow candidate sink holds up only
+				// its own copies.
+				NewConsumer(p,
+					func(ctx context.Context, out Out) error {
+						if _, mirrored := VersionFrom(ctx); mirrored {
+							return v.OnSuccess(ctx, out)
+						}
+						emit(ctx, out, nil)
+						return nil
+					},
+					func(ctx context.Context, err error) {
+						if _, mirrored := VersionFrom(ctx); mirrored {
+							v.OnError(ctx, err)
+							return
+						}
+						var zero Out
+						emit(ctx, zero, err)
+					},
+				)
+			})
+			return in
+		}
+
+		// closeDropped closes the inputs of dropped versions, so their
+		// pipelines complete.
+		closeDropped := func() {
+			if n := v.dropped.Load(); n != dropped {
+				dropped = n
+				for name, in := range inputs {
+					if v.version(name) != in.ver {
+						close(in.ch)
+						delete(inputs, name)
+					}
+				}
+			}
+		}
+
+		NewConsumer(s,
+			func(ctx context.Context, in In) error {
+				closeDropped()
+				for _, t := range v.routing.Load().targets() {
+					ver := v.version(t.name)
+					if ver == nil {
+						continue
+					}
+					t.version = ver
+					c := context.WithValue(ctx, versionKey{}, t)
+					ch := inputOf(ver)
+
+					if !t.mirrored {
+						select {
+						case ch <- Ok(c, in):
+						case <-ctx.Done():
+							var zero Out
+							emit(c, zero, context.Cause(ctx))
+						}
+						continue
+					}
+
+					c = WithAcker(c, mirrorAck{})
+					copied := in
+					if v.Clone != nil {
+						copied = v.Clone(in)
+					}
+					select {
+					case ch <- Ok(c, copied):
+					default:
+						v.overflow.Add(1)
+					}
+				}
+				return nil
+			},
+			func(ctx context.Context, err error) {
+				var zero Out
+				name := v.Active()
+				emit(context.WithValue(ctx, versionKey{}, routed{name: name, version: v.version(name)}), zero, err)
+			},
+		)
+
+		for _, in := range inputs {
+			close(in.ch)
+		}
+		wg.Wait()
+	})
+}
+
+// versionOf returns the version an output of ctx was routed to, or the
+// active one for errors that were not routed.
+func (v *Versioned[In, Out]) versionOf(ctx context.Context) (*version[In, Out], bool) {
+	r, _ := ctx.Value(versionKey{}).(routed)
+	if ver, ok := r.version.(*version[In, Out]); ok {
+		return ver, r.mirrored
+	}
+	return v.version(v.Active()), false
+}
+
+// OnSuccess hands out to the sink of its version, committing it with the
+// event's acknowledgment if that sink is a TwoPhaseSink, and acks the event
+// unless the sink settles it itself.
+func (v *Versioned[In, Out]) OnSuccess(ctx context.Context, out Out) error {
+	ver, mirrored := v.versionOf(ctx)
+	f := ver.f
+
+	var err error
+	tp, isTwoPhase := twoPhase(f)
+	if isTwoPhase {
+		err = commitOutput(ctx, tp, out)
+	} else {
+		err = f.OnSuccess(ctx, out)
+	}
+	if err == nil && (isTwoPhase || !settles(sinkOf(f))) {
+		Ack(ctx)
+	}
+	if err != nil && mirrored {
+		f.OnError(ctx, err)
+		return nil
+	}
+	return err
+}
+
+func (v *Versioned[In, Out]) SettlesOutputs() bool { return true }
+
+func (v *Versioned[In, Out]) OnError(ctx context.Context, err error) {
+	ver, _ := v.versionOf(ctx)
+	ver.f.OnError(ctx, err)
+}